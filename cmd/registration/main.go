@@ -16,6 +16,7 @@ import (
 	"open-cluster-management.io/registration/pkg/cmd/hub"
 	"open-cluster-management.io/registration/pkg/cmd/spoke"
 	"open-cluster-management.io/registration/pkg/cmd/webhook"
+	"open-cluster-management.io/registration/pkg/metrics"
 	"open-cluster-management.io/registration/pkg/version"
 )
 
@@ -56,6 +57,13 @@ func newRegistrationCommand() *cobra.Command {
 		cmd.Version = v
 	}
 
+	var metricsPrefix string
+	cmd.PersistentFlags().StringVar(&metricsPrefix, "metrics-prefix", "",
+		"Prefix applied to the names of all registered metrics. An empty prefix keeps the original names.")
+	cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		metrics.SetPrefix(metricsPrefix)
+	}
+
 	cmd.AddCommand(hub.NewController())
 	cmd.AddCommand(spoke.NewAgent())
 	cmd.AddCommand(webhook.NewAdmissionHook())
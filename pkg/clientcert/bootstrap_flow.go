@@ -0,0 +1,147 @@
+package clientcert
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	certificates "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	csrclient "k8s.io/client-go/kubernetes/typed/certificates/v1"
+	"k8s.io/client-go/util/keyutil"
+)
+
+// SecretWriter persists the client certificate data produced by a bootstrap flow.
+type SecretWriter func(ctx context.Context, data map[string][]byte) error
+
+// ApprovalWaiter waits until the csr with the given name is approved and signed on the
+// hub and returns the issued certificate.
+type ApprovalWaiter interface {
+	WaitForCertificate(ctx context.Context, csrName string) ([]byte, error)
+}
+
+// BootstrapFlow runs one bootstrap round of a hub client certificate as a sequential
+// flow: create a private key and a csr, wait until the hub approves and signs the csr,
+// and persist the issued certificate. Its dependencies are injectable, so the flow can
+// be exercised against fake clients or envtest without a real hub.
+type BootstrapFlow struct {
+	// CSROption holds the options the csr is created with.
+	CSROption CSROption
+	// CSRClient creates and reads the csr on the hub.
+	CSRClient csrclient.CertificateSigningRequestInterface
+	// Writer persists the issued client certificate and its private key.
+	Writer SecretWriter
+	// Waiter waits for the approval of the csr.
+	Waiter ApprovalWaiter
+}
+
+// Run executes the bootstrap flow once. It returns an error if the csr is not approved
+// and signed by the waiter, or if the issued certificate cannot be persisted.
+func (f *BootstrapFlow) Run(ctx context.Context) error {
+	keyData, err := keyutil.MakeEllipticPrivateKeyPEM()
+	if err != nil {
+		return err
+	}
+
+	csr, err := newCSR(f.CSROption, keyData)
+	if err != nil {
+		return err
+	}
+	req, err := f.CSRClient.Create(ctx, csr, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+
+	certData, err := f.Waiter.WaitForCertificate(ctx, req.Name)
+	if err != nil {
+		return fmt.Errorf("the csr %q was not approved and signed: %w", req.Name, err)
+	}
+
+	if _, err := tls.X509KeyPair(certData, keyData); err != nil {
+		return fmt.Errorf("the certificate issued for csr %q does not match the private key: %w", req.Name, err)
+	}
+
+	return f.Writer(ctx, map[string][]byte{
+		TLSCertFile: certData,
+		TLSKeyFile:  keyData,
+	})
+}
+
+// CSRApprovalWaiter waits for the approval of a csr by polling it on the hub until it
+// is approved and its certificate is issued. A denied csr fails the wait immediately
+// instead of waiting out the timeout.
+type CSRApprovalWaiter struct {
+	CSRClient csrclient.CertificateSigningRequestInterface
+	Interval  time.Duration
+	Timeout   time.Duration
+}
+
+func (w *CSRApprovalWaiter) WaitForCertificate(ctx context.Context, csrName string) ([]byte, error) {
+	var certData []byte
+	err := wait.PollImmediate(w.Interval, w.Timeout, func() (bool, error) {
+		csr, err := w.CSRClient.Get(ctx, csrName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, condition := range csr.Status.Conditions {
+			if condition.Type == certificates.CertificateDenied {
+				return false, fmt.Errorf("the csr %q is denied", csrName)
+			}
+		}
+		if !isCSRApproved(csr) || len(csr.Status.Certificate) == 0 {
+			return false, nil
+		}
+		certData = csr.Status.Certificate
+		return true, nil
+	})
+	return certData, err
+}
+
+// InMemoryApproval is an ApprovalWaiter that approves and signs the csr itself with the
+// given sign function, simulating the hub side of the bootstrap in tests. When Deny is
+// set it denies the csr instead.
+type InMemoryApproval struct {
+	CSRClient csrclient.CertificateSigningRequestInterface
+	Sign      func(request []byte) ([]byte, error)
+	Deny      bool
+}
+
+func (a *InMemoryApproval) WaitForCertificate(ctx context.Context, csrName string) ([]byte, error) {
+	csr, err := a.CSRClient.Get(ctx, csrName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	csr = csr.DeepCopy()
+
+	if a.Deny {
+		csr.Status.Conditions = append(csr.Status.Conditions, certificates.CertificateSigningRequestCondition{
+			Type:    certificates.CertificateDenied,
+			Status:  corev1.ConditionTrue,
+			Reason:  "DeniedInMemory",
+			Message: "Denied by the in-memory approval.",
+		})
+		if _, err := a.CSRClient.UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{}); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("the csr %q is denied", csrName)
+	}
+
+	csr.Status.Conditions = append(csr.Status.Conditions, certificates.CertificateSigningRequestCondition{
+		Type:    certificates.CertificateApproved,
+		Status:  corev1.ConditionTrue,
+		Reason:  "ApprovedInMemory",
+		Message: "Approved by the in-memory approval.",
+	})
+	certData, err := a.Sign(csr.Spec.Request)
+	if err != nil {
+		return nil, err
+	}
+	csr.Status.Certificate = certData
+	if _, err := a.CSRClient.UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return certData, nil
+}
@@ -0,0 +1,135 @@
+package clientcert
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	cryptorand "crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestBootstrapFlow(t *testing.T) {
+	csrOption := CSROption{
+		ObjectMeta: metav1.ObjectMeta{Name: "bootstrap-csr"},
+		Subject:    &pkix.Name{CommonName: "test-agent"},
+		SignerName: "kubernetes.io/kube-apiserver-client",
+	}
+
+	t.Run("the flow persists the issued certificate on approval", func(t *testing.T) {
+		csrClient := kubefake.NewSimpleClientset().CertificatesV1().CertificateSigningRequests()
+		var written map[string][]byte
+		flow := &BootstrapFlow{
+			CSROption: csrOption,
+			CSRClient: csrClient,
+			Writer: func(ctx context.Context, data map[string][]byte) error {
+				written = data
+				return nil
+			},
+			Waiter: &InMemoryApproval{CSRClient: csrClient, Sign: newCSRSigner(t)},
+		}
+
+		if err := flow.Run(context.TODO()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(written[TLSCertFile]) == 0 || len(written[TLSKeyFile]) == 0 {
+			t.Errorf("expected the certificate and key to be persisted, but got %v", written)
+		}
+	})
+
+	t.Run("the flow fails when the csr is denied", func(t *testing.T) {
+		csrClient := kubefake.NewSimpleClientset().CertificatesV1().CertificateSigningRequests()
+		flow := &BootstrapFlow{
+			CSROption: csrOption,
+			CSRClient: csrClient,
+			Writer: func(ctx context.Context, data map[string][]byte) error {
+				t.Error("no certificate must be persisted for a denied csr")
+				return nil
+			},
+			Waiter: &InMemoryApproval{CSRClient: csrClient, Deny: true},
+		}
+
+		err := flow.Run(context.TODO())
+		if err == nil || !strings.Contains(err.Error(), "denied") {
+			t.Errorf("expected a denial error, but got: %v", err)
+		}
+	})
+
+	t.Run("the flow fails when the approval times out", func(t *testing.T) {
+		csrClient := kubefake.NewSimpleClientset().CertificatesV1().CertificateSigningRequests()
+		flow := &BootstrapFlow{
+			CSROption: csrOption,
+			CSRClient: csrClient,
+			Writer: func(ctx context.Context, data map[string][]byte) error {
+				t.Error("no certificate must be persisted for a pending csr")
+				return nil
+			},
+			// the csr is never approved, the waiter runs into its timeout
+			Waiter: &CSRApprovalWaiter{CSRClient: csrClient, Interval: 10 * time.Millisecond, Timeout: 50 * time.Millisecond},
+		}
+
+		err := flow.Run(context.TODO())
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("expected a timeout error, but got: %v", err)
+		}
+	})
+}
+
+// newCSRSigner returns a sign function that issues a certificate for a csr request with
+// a generated CA, standing in for the hub signer.
+func newCSRSigner(t *testing.T) func(request []byte) ([]byte, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), cryptorand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(cryptorand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return func(request []byte) ([]byte, error) {
+		block, _ := pem.Decode(request)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM block found in the csr request")
+		}
+		certificateRequest, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      certificateRequest.Subject,
+			NotBefore:    time.Now().Add(-time.Minute),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		certDER, err := x509.CreateCertificate(cryptorand.Reader, template, caCert, certificateRequest.PublicKey, caKey)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), nil
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"crypto/tls"
 	"crypto/x509/pkix"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"reflect"
 	"time"
 
@@ -37,6 +40,15 @@ const (
 	// TLSCertFile is the name of the tls cert file in kubeconfigSecret
 	TLSCertFile = "tls.crt"
 
+	// previousTLSKeyFile and previousTLSCertFile hold the key material superseded by the
+	// last certificate rotation while it is retained for the configured grace
+	previousTLSKeyFile  = "tls.key.previous"
+	previousTLSCertFile = "tls.crt.previous"
+
+	// credentialRotatedAtAnnotation records when the retained superseded credential was
+	// rotated out, so the retention grace can be measured
+	credentialRotatedAtAnnotation = "open-cluster-management.io/credential-rotated-at"
+
 	clusterNameAnnotation = "open-cluster-management.io/cluster-name"
 	ClusterNameFile       = "cluster-name"
 	AgentNameFile         = "agent-name"
@@ -48,6 +60,10 @@ const (
 // ControllerResyncInterval is exposed so that integration tests can crank up the constroller sync speed.
 var ControllerResyncInterval = 5 * time.Minute
 
+// DefaultSupersededCredentialGracePeriod is the grace the registration agent retains the
+// key material superseded by a certificate rotation in the hub kubeconfig secret.
+var DefaultSupersededCredentialGracePeriod = 5 * time.Minute
+
 // CSROption includes options that is used to create and monitor csrs
 type CSROption struct {
 	// ObjectMeta is the ObjectMeta shared by all created csrs. It should use GenerateName instead of Name
@@ -76,6 +92,22 @@ type ClientCertOption struct {
 	// AdditonalSecretDataSensitive is true indicates the client cert is sensitive to the AdditonalSecretData.
 	// That means once AdditonalSecretData changes, the client cert will be recreated.
 	AdditonalSecretDataSensitive bool
+	// SecretLabels and SecretAnnotations are merged into the metadata of the client
+	// certificate secret every time it is saved, e.g. to satisfy secret-management
+	// policies. Labels and annotations set by others are kept.
+	SecretLabels      map[string]string
+	SecretAnnotations map[string]string
+	// SupersededCredentialGracePeriod is how long the key material superseded by a
+	// certificate rotation is retained in the secret under tls.key.previous and
+	// tls.crt.previous before it is removed, so a consumer still using the previous
+	// credential is not cut off right away. A zero period disables the retention and
+	// keeps exactly one credential at all times.
+	SupersededCredentialGracePeriod time.Duration
+	// CredentialMirrorDir is a directory the current tls.key, tls.crt and kubeconfig
+	// are additionally written to every time the secret is saved, so a co-located
+	// consumer can watch the files instead of re-reading the secret. The files are
+	// written atomically with mode 0600. An empty directory disables the mirror.
+	CredentialMirrorDir string
 }
 
 // clientCertificateController implements the common logic of hub client certification creation/rotation. It
@@ -103,6 +135,10 @@ type clientCertificateController struct {
 	//   3. csrName set, keyData set: we are waiting for a new cert to be signed.
 	//   4. csrName empty, keydata set: the CSR failed to create, this shouldn't happen, it's a bug.
 	keyData []byte
+
+	// csrApproved indicates whether the approval of the pending csr has been observed,
+	// so the approval milestone event is emitted only once per csr.
+	csrApproved bool
 }
 
 // NewClientCertificateController return an instance of clientCertificateController
@@ -166,7 +202,7 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 
 	// reconcile pending csr if exists
 	if len(c.csrName) > 0 {
-		newSecretConfig, err := c.syncCSR()
+		newSecretConfig, err := c.syncCSR(syncCtx)
 		if err != nil {
 			c.reset()
 			return err
@@ -178,6 +214,20 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 		for k, v := range c.AdditonalSecretData {
 			newSecretConfig[k] = v
 		}
+		// retain the credential superseded by this rotation for the configured grace,
+		// a consumer may still hold a client built from the previous key material
+		if c.SupersededCredentialGracePeriod > 0 {
+			if oldKey, ok := secret.Data[TLSKeyFile]; ok && !reflect.DeepEqual(oldKey, newSecretConfig[TLSKeyFile]) {
+				newSecretConfig[previousTLSKeyFile] = oldKey
+				if oldCert, ok := secret.Data[TLSCertFile]; ok {
+					newSecretConfig[previousTLSCertFile] = oldCert
+				}
+				if secret.Annotations == nil {
+					secret.Annotations = map[string]string{}
+				}
+				secret.Annotations[credentialRotatedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+			}
+		}
 		secret.Data = newSecretConfig
 		// save the changes into secret
 		if err := c.saveSecret(secret); err != nil {
@@ -198,6 +248,12 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 	case c.AdditonalSecretDataSensitive && !c.hasAdditonalSecretData(secret):
 		syncCtx.Recorder().Eventf("AdditonalSecretDataChanged", "The additonal secret data is changed. Re-create the client certificate for %s", c.controllerName)
 	default:
+		// the current certificate proved itself working, remove the retained superseded
+		// key material once the grace elapsed
+		if err := c.pruneSupersededCredential(secret, syncCtx); err != nil {
+			return err
+		}
+
 		notBefore, notAfter, err := getCertValidityPeriod(secret)
 		if err != nil {
 			return err
@@ -231,7 +287,7 @@ func (c *clientCertificateController) sync(ctx context.Context, syncCtx factory.
 	return nil
 }
 
-func (c *clientCertificateController) syncCSR() (map[string][]byte, error) {
+func (c *clientCertificateController) syncCSR(syncCtx factory.SyncContext) (map[string][]byte, error) {
 	// skip if there is no ongoing csr
 	if len(c.csrName) == 0 {
 		return nil, fmt.Errorf("no ongoing csr")
@@ -255,6 +311,12 @@ func (c *clientCertificateController) syncCSR() (map[string][]byte, error) {
 		return nil, nil
 	}
 
+	// the csr may be approved before its certificate is issued, record the approval once
+	if !c.csrApproved {
+		c.csrApproved = true
+		syncCtx.Recorder().Eventf("CSRApproved", "The csr %q created for %s is approved by the hub", c.csrName, c.controllerName)
+	}
+
 	// skip if csr has no certificate in its status yet
 	if len(csr.Status.Certificate) == 0 {
 		return nil, nil
@@ -279,17 +341,31 @@ func (c *clientCertificateController) syncCSR() (map[string][]byte, error) {
 }
 
 func (c *clientCertificateController) createCSR(ctx context.Context) (string, error) {
-	privateKey, err := keyutil.ParsePrivateKeyPEM(c.keyData)
+	csr, err := newCSR(c.CSROption, c.keyData)
 	if err != nil {
-		return "", fmt.Errorf("invalid private key for certificate request: %w", err)
+		return "", err
 	}
-	csrData, err := certutil.MakeCSR(privateKey, c.Subject, c.DNSNames, nil)
+	req, err := c.hubCSRClient.Create(ctx, csr, metav1.CreateOptions{})
 	if err != nil {
-		return "", fmt.Errorf("unable to generate certificate request: %w", err)
+		return "", err
 	}
+	return req.Name, nil
+}
 
-	csr := &certificates.CertificateSigningRequest{
-		ObjectMeta: c.ObjectMeta,
+// newCSR builds a CertificateSigningRequest for the given private key with the csr
+// options.
+func newCSR(option CSROption, keyData []byte) (*certificates.CertificateSigningRequest, error) {
+	privateKey, err := keyutil.ParsePrivateKeyPEM(keyData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key for certificate request: %w", err)
+	}
+	csrData, err := certutil.MakeCSR(privateKey, option.Subject, option.DNSNames, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate certificate request: %w", err)
+	}
+
+	return &certificates.CertificateSigningRequest{
+		ObjectMeta: option.ObjectMeta,
 		Spec: certificates.CertificateSigningRequestSpec{
 			Request: csrData,
 			Usages: []certificates.KeyUsage{
@@ -297,30 +373,112 @@ func (c *clientCertificateController) createCSR(ctx context.Context) (string, er
 				certificates.UsageKeyEncipherment,
 				certificates.UsageClientAuth,
 			},
-			SignerName: c.SignerName,
+			SignerName: option.SignerName,
 		},
-	}
-
-	req, err := c.hubCSRClient.Create(ctx, csr, metav1.CreateOptions{})
-	if err != nil {
-		return "", err
-	}
-	return req.Name, nil
+	}, nil
 }
 
 func (c *clientCertificateController) saveSecret(secret *corev1.Secret) error {
+	if len(c.SecretLabels) > 0 && secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	for k, v := range c.SecretLabels {
+		secret.Labels[k] = v
+	}
+	if len(c.SecretAnnotations) > 0 && secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	for k, v := range c.SecretAnnotations {
+		secret.Annotations[k] = v
+	}
+
 	var err error
 	if secret.ResourceVersion == "" {
 		_, err = c.spokeCoreClient.Secrets(c.SecretNamespace).Create(context.Background(), secret, metav1.CreateOptions{})
+	} else {
+		_, err = c.spokeCoreClient.Secrets(c.SecretNamespace).Update(context.Background(), secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
 		return err
 	}
-	_, err = c.spokeCoreClient.Secrets(c.SecretNamespace).Update(context.Background(), secret, metav1.UpdateOptions{})
-	return err
+	return c.mirrorCredential(secret)
+}
+
+// mirrorCredential writes the current credential files of the secret into the configured
+// mirror directory, so a co-located consumer can pick up a rotated credential by watching
+// the files. Each file is written atomically by renaming a temporary file into place.
+func (c *clientCertificateController) mirrorCredential(secret *corev1.Secret) error {
+	if len(c.CredentialMirrorDir) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(c.CredentialMirrorDir, 0700); err != nil {
+		return fmt.Errorf("unable to create the credential mirror directory %q: %w", c.CredentialMirrorDir, err)
+	}
+	for _, file := range []string{TLSKeyFile, TLSCertFile, KubeconfigFile} {
+		data, ok := secret.Data[file]
+		if !ok {
+			continue
+		}
+		if err := atomicWriteFile(filepath.Join(c.CredentialMirrorDir, file), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// atomicWriteFile writes data into a temporary file next to path with mode 0600 and
+// renames it into place, so a reader never observes a partially written file.
+func atomicWriteFile(path string, data []byte) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if err := tmpFile.Chmod(0600); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// pruneSupersededCredential removes the retained superseded key material from the secret
+// once the configured grace elapsed after the rotation, so the secret converges back to
+// exactly one credential. Without a parseable rotation time the material is removed right
+// away, its age cannot be determined.
+func (c *clientCertificateController) pruneSupersededCredential(secret *corev1.Secret, syncCtx factory.SyncContext) error {
+	if _, ok := secret.Data[previousTLSKeyFile]; !ok {
+		return nil
+	}
+
+	if rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[credentialRotatedAtAnnotation]); err == nil {
+		if time.Since(rotatedAt) < c.SupersededCredentialGracePeriod {
+			return nil
+		}
+	}
+
+	secret = secret.DeepCopy()
+	delete(secret.Data, previousTLSKeyFile)
+	delete(secret.Data, previousTLSCertFile)
+	delete(secret.Annotations, credentialRotatedAtAnnotation)
+	if err := c.saveSecret(secret); err != nil {
+		return err
+	}
+	syncCtx.Recorder().Eventf("SupersededCredentialRemoved",
+		"The key material superseded by the last certificate rotation for %s is removed from the secret", c.controllerName)
+	return nil
 }
 
 func (c *clientCertificateController) reset() {
 	c.csrName = ""
 	c.keyData = nil
+	c.csrApproved = false
 }
 
 func (c *clientCertificateController) hasValidClientCertificate(secret *corev1.Secret) bool {
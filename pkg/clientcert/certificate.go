@@ -16,10 +16,10 @@ import (
 
 // HasValidClientCertificate checks if there exists a valid client certificate in the given secret
 // Returns true if all the conditions below are met:
-//   1. KubeconfigFile exists when hasKubeconfig is true
-//   2. TLSKeyFile exists
-//   3. TLSCertFile exists and the certificate is not expired
-//   4. If subject is specified, it matches the subject in the certificate stored in TLSCertFile
+//  1. KubeconfigFile exists when hasKubeconfig is true
+//  2. TLSKeyFile exists
+//  3. TLSCertFile exists and the certificate is not expired
+//  4. If subject is specified, it matches the subject in the certificate stored in TLSCertFile
 func HasValidHubKubeconfig(secret *corev1.Secret, subject *pkix.Name) bool {
 	if len(secret.Data) == 0 {
 		klog.V(4).Infof("No data found in secret %q", secret.Namespace+"/"+secret.Name)
@@ -4,6 +4,9 @@ import (
 	"context"
 	"crypto/x509/pkix"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -15,6 +18,8 @@ import (
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 
+	"github.com/openshift/library-go/pkg/operator/events"
+
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 	"open-cluster-management.io/registration/pkg/hub/user"
 )
@@ -41,6 +46,8 @@ func TestSync(t *testing.T) {
 		keyDataExpected              bool
 		csrNameExpected              bool
 		additonalSecretDataSensitive bool
+		secretLabels                 map[string]string
+		secretAnnotations            map[string]string
 		validateActions              func(t *testing.T, hubActions, agentActions []clienttesting.Action)
 	}{
 		{
@@ -84,6 +91,38 @@ func TestSync(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:     "apply configured secret labels and annotations on rotation",
+			queueKey: testSecretName,
+			secrets: []runtime.Object{
+				func() runtime.Object {
+					secret := testinghelpers.NewHubKubeconfigSecret(testNamespace, testSecretName, "1", nil, map[string][]byte{
+						ClusterNameFile: []byte(testinghelpers.TestManagedClusterName),
+						AgentNameFile:   []byte(testAgentName),
+					})
+					// a label set by others must be kept
+					secret.Labels = map[string]string{"owner": "test"}
+					return secret
+				}(),
+			},
+			approvedCSRCert:   testinghelpers.NewTestCert(commonName, 10*time.Second),
+			secretLabels:      map[string]string{"policy.example.com/credential": "hub-kubeconfig"},
+			secretAnnotations: map[string]string{"policy.example.com/rotated-by": "agent"},
+			validateActions: func(t *testing.T, hubActions, agentActions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, agentActions, "get", "update")
+				actual := agentActions[1].(clienttesting.UpdateActionImpl).Object
+				secret := actual.(*corev1.Secret)
+				if secret.Labels["policy.example.com/credential"] != "hub-kubeconfig" {
+					t.Errorf("expected the configured label on the secret, but got %v", secret.Labels)
+				}
+				if secret.Labels["owner"] != "test" {
+					t.Errorf("expected the pre-existing label to be kept, but got %v", secret.Labels)
+				}
+				if secret.Annotations["policy.example.com/rotated-by"] != "agent" {
+					t.Errorf("expected the configured annotation on the secret, but got %v", secret.Annotations)
+				}
+			},
+		},
 		{
 			name:     "sync a valid hub kubeconfig secret",
 			queueKey: testSecretName,
@@ -171,6 +210,8 @@ func TestSync(t *testing.T) {
 					AgentNameFile:   []byte(testAgentName),
 				},
 				AdditonalSecretDataSensitive: c.additonalSecretDataSensitive,
+				SecretLabels:                 c.secretLabels,
+				SecretAnnotations:            c.secretAnnotations,
 			}
 			csrOption := CSROption{
 				ObjectMeta: metav1.ObjectMeta{
@@ -213,3 +254,275 @@ func TestSync(t *testing.T) {
 		})
 	}
 }
+
+// TestSupersededCredentialCleanup verifies that a certificate rotation retains the
+// superseded key material for the configured grace and that it is removed again once the
+// new certificate proved itself working and the grace elapsed.
+func TestSupersededCredentialCleanup(t *testing.T) {
+	testSubject := &pkix.Name{CommonName: commonName}
+	newCert := testinghelpers.NewTestCert(commonName, 10000*time.Second)
+	oldCert := testinghelpers.NewTestCert(commonName, -3*time.Second)
+
+	newController := func(agentKubeClient *kubefake.Clientset, gracePeriod time.Duration) *clientCertificateController {
+		hubKubeClient := kubefake.NewSimpleClientset()
+		hubInformerFactory := informers.NewSharedInformerFactory(hubKubeClient, 3*time.Minute)
+		return &clientCertificateController{
+			ClientCertOption: ClientCertOption{
+				SecretNamespace:                 testNamespace,
+				SecretName:                      testSecretName,
+				SupersededCredentialGracePeriod: gracePeriod,
+			},
+			CSROption: CSROption{
+				Subject:    testSubject,
+				SignerName: certificates.KubeAPIServerClientSignerName,
+			},
+			hubCSRLister:    hubInformerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+			hubCSRClient:    hubKubeClient.CertificatesV1().CertificateSigningRequests(),
+			spokeCoreClient: agentKubeClient.CoreV1(),
+			controllerName:  "test-agent",
+		}
+	}
+
+	t.Run("a rotation retains the superseded credential", func(t *testing.T) {
+		approvedCSR := testinghelpers.NewApprovedCSR(testinghelpers.CSRHolder{Name: testCSRName})
+		approvedCSR.Status.Certificate = newCert.Cert
+		hubKubeClient := kubefake.NewSimpleClientset(approvedCSR)
+		hubInformerFactory := informers.NewSharedInformerFactory(hubKubeClient, 3*time.Minute)
+		agentKubeClient := kubefake.NewSimpleClientset(
+			testinghelpers.NewHubKubeconfigSecret(testNamespace, testSecretName, "1", oldCert, map[string][]byte{}))
+
+		controller := newController(agentKubeClient, time.Minute)
+		controller.hubCSRLister = hubInformerFactory.Certificates().V1().CertificateSigningRequests().Lister()
+		controller.hubCSRClient = hubKubeClient.CertificatesV1().CertificateSigningRequests()
+		controller.csrName = testCSRName
+		controller.keyData = newCert.Key
+
+		if err := controller.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testSecretName)); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		agentActions := agentKubeClient.Actions()
+		testinghelpers.AssertActions(t, agentActions, "get", "update")
+		secret := agentActions[1].(clienttesting.UpdateActionImpl).Object.(*corev1.Secret)
+		if string(secret.Data[previousTLSKeyFile]) != string(oldCert.Key) {
+			t.Errorf("expected the superseded private key to be retained, but got %v", secret.Data)
+		}
+		if string(secret.Data[previousTLSCertFile]) != string(oldCert.Cert) {
+			t.Errorf("expected the superseded certificate to be retained, but got %v", secret.Data)
+		}
+		if _, err := time.Parse(time.RFC3339, secret.Annotations[credentialRotatedAtAnnotation]); err != nil {
+			t.Errorf("expected a parseable rotation time annotation, but got %q", secret.Annotations[credentialRotatedAtAnnotation])
+		}
+	})
+
+	t.Run("the superseded credential is removed after the grace", func(t *testing.T) {
+		secret := testinghelpers.NewHubKubeconfigSecret(testNamespace, testSecretName, "1", newCert, map[string][]byte{
+			KubeconfigFile:      testinghelpers.NewKubeconfig(nil, nil),
+			previousTLSKeyFile:  oldCert.Key,
+			previousTLSCertFile: oldCert.Cert,
+		})
+		secret.Annotations = map[string]string{
+			credentialRotatedAtAnnotation: time.Now().Add(-2 * time.Minute).UTC().Format(time.RFC3339),
+		}
+		agentKubeClient := kubefake.NewSimpleClientset(secret)
+
+		controller := newController(agentKubeClient, time.Minute)
+		if err := controller.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testSecretName)); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		agentActions := agentKubeClient.Actions()
+		testinghelpers.AssertActions(t, agentActions, "get", "update")
+		updated := agentActions[1].(clienttesting.UpdateActionImpl).Object.(*corev1.Secret)
+		if _, ok := updated.Data[previousTLSKeyFile]; ok {
+			t.Errorf("expected the superseded private key to be removed, but got %v", updated.Data)
+		}
+		if _, ok := updated.Data[previousTLSCertFile]; ok {
+			t.Errorf("expected the superseded certificate to be removed, but got %v", updated.Data)
+		}
+		if _, ok := updated.Annotations[credentialRotatedAtAnnotation]; ok {
+			t.Errorf("expected the rotation time annotation to be removed, but got %v", updated.Annotations)
+		}
+	})
+
+	t.Run("the superseded credential is kept within the grace", func(t *testing.T) {
+		secret := testinghelpers.NewHubKubeconfigSecret(testNamespace, testSecretName, "1", newCert, map[string][]byte{
+			KubeconfigFile:      testinghelpers.NewKubeconfig(nil, nil),
+			previousTLSKeyFile:  oldCert.Key,
+			previousTLSCertFile: oldCert.Cert,
+		})
+		secret.Annotations = map[string]string{
+			credentialRotatedAtAnnotation: time.Now().UTC().Format(time.RFC3339),
+		}
+		agentKubeClient := kubefake.NewSimpleClientset(secret)
+
+		controller := newController(agentKubeClient, time.Minute)
+		if err := controller.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testSecretName)); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		testinghelpers.AssertActions(t, agentKubeClient.Actions(), "get")
+	})
+}
+
+// TestCredentialMirror verifies that a certificate rotation writes the current credential
+// files into the configured mirror directory with mode 0600 and leaves no temporary files
+// behind, so a co-located consumer watching the files only observes complete writes.
+func TestCredentialMirror(t *testing.T) {
+	mirrorDir, err := ioutil.TempDir("", "credentialmirror")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer os.RemoveAll(mirrorDir)
+
+	testCert := testinghelpers.NewTestCert(commonName, 10000*time.Second)
+	kubeconfigData := testinghelpers.NewKubeconfig(nil, nil)
+	approvedCSR := testinghelpers.NewApprovedCSR(testinghelpers.CSRHolder{Name: testCSRName})
+	approvedCSR.Status.Certificate = testCert.Cert
+	hubKubeClient := kubefake.NewSimpleClientset(approvedCSR)
+	hubInformerFactory := informers.NewSharedInformerFactory(hubKubeClient, 3*time.Minute)
+	agentKubeClient := kubefake.NewSimpleClientset()
+
+	controller := &clientCertificateController{
+		ClientCertOption: ClientCertOption{
+			SecretNamespace:     testNamespace,
+			SecretName:          testSecretName,
+			AdditonalSecretData: map[string][]byte{KubeconfigFile: kubeconfigData},
+			CredentialMirrorDir: filepath.Join(mirrorDir, "hub-credential"),
+		},
+		CSROption: CSROption{
+			Subject:    &pkix.Name{CommonName: commonName},
+			SignerName: certificates.KubeAPIServerClientSignerName,
+		},
+		hubCSRLister:    hubInformerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+		hubCSRClient:    hubKubeClient.CertificatesV1().CertificateSigningRequests(),
+		spokeCoreClient: agentKubeClient.CoreV1(),
+		controllerName:  "test-agent",
+		csrName:         testCSRName,
+		keyData:         testCert.Key,
+	}
+
+	if err := controller.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testSecretName)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expectedFiles := map[string][]byte{
+		TLSKeyFile:     testCert.Key,
+		TLSCertFile:    testCert.Cert,
+		KubeconfigFile: kubeconfigData,
+	}
+	for file, expectedData := range expectedFiles {
+		path := filepath.Join(controller.CredentialMirrorDir, file)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("expected the mirrored file %q to have mode 0600, but got %v", file, info.Mode().Perm())
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if string(data) != string(expectedData) {
+			t.Errorf("expected the mirrored file %q to match the secret data", file)
+		}
+	}
+
+	entries, err := ioutil.ReadDir(controller.CredentialMirrorDir)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(entries) != len(expectedFiles) {
+		t.Errorf("expected only the mirrored credential files, but got %v", entries)
+	}
+}
+
+// TestNewCSRCarriesClusterNameLabel verifies that a csr built with the metadata the
+// registration agent configures carries the cluster name label, so operators and the hub
+// approver can tell the cluster of a csr without parsing its common name.
+func TestNewCSRCarriesClusterNameLabel(t *testing.T) {
+	option := CSROption{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-", testinghelpers.TestManagedClusterName),
+			Labels:       map[string]string{ClusterNameLabel: testinghelpers.TestManagedClusterName},
+		},
+		Subject:    &pkix.Name{CommonName: commonName},
+		SignerName: certificates.KubeAPIServerClientSignerName,
+	}
+
+	csr, err := newCSR(option, testinghelpers.NewTestCert(commonName, 10*time.Second).Key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if csr.Labels[ClusterNameLabel] != testinghelpers.TestManagedClusterName {
+		t.Errorf("expected the csr to carry the cluster name label, but got %v", csr.Labels)
+	}
+}
+
+// TestBootstrapEventSequence verifies the sequence of events recorded for the bootstrap
+// milestones when an agent bootstraps successfully against a fake hub.
+func TestBootstrapEventSequence(t *testing.T) {
+	testCert := testinghelpers.NewTestCert(commonName, 10*time.Second)
+	approvedCSR := testinghelpers.NewApprovedCSR(testinghelpers.CSRHolder{Name: testCSRName})
+	approvedCSR.Status.Certificate = testCert.Cert
+	hubKubeClient := kubefake.NewSimpleClientset(approvedCSR)
+
+	// GenerateName is not working for fake clent, we set the name with prepend reactor
+	hubKubeClient.PrependReactor(
+		"create",
+		"certificatesigningrequests",
+		func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+			return true, testinghelpers.NewCSR(testinghelpers.CSRHolder{Name: testCSRName}), nil
+		},
+	)
+	hubInformerFactory := informers.NewSharedInformerFactory(hubKubeClient, 3*time.Minute)
+	agentKubeClient := kubefake.NewSimpleClientset()
+
+	controller := &clientCertificateController{
+		ClientCertOption: ClientCertOption{
+			SecretNamespace: testNamespace,
+			SecretName:      testSecretName,
+			AdditonalSecretData: map[string][]byte{
+				ClusterNameFile: []byte(testinghelpers.TestManagedClusterName),
+				AgentNameFile:   []byte(testAgentName),
+			},
+		},
+		CSROption: CSROption{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+			},
+			Subject:    &pkix.Name{CommonName: commonName},
+			SignerName: certificates.KubeAPIServerClientSignerName,
+		},
+		hubCSRLister:    hubInformerFactory.Certificates().V1().CertificateSigningRequests().Lister(),
+		hubCSRClient:    hubKubeClient.CertificatesV1().CertificateSigningRequests(),
+		spokeCoreClient: agentKubeClient.CoreV1(),
+		controllerName:  "test-agent",
+	}
+
+	recorder := events.NewInMemoryRecorder("test-agent")
+	syncCtx := testinghelpers.NewFakeSyncContextWithRecorder(testSecretName, recorder)
+
+	// no valid client certificate exists yet, the first sync creates a csr
+	if err := controller.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// the csr is approved and signed on the hub, sync again to save the client certificate
+	controller.keyData = testCert.Key
+	if err := controller.sync(context.TODO(), syncCtx); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expectedReasons := []string{"NoValidCertificateFound", "CSRCreated", "CSRApproved", "ClientCertificateCreated"}
+	actualEvents := recorder.Events()
+	if len(actualEvents) != len(expectedReasons) {
+		t.Fatalf("expected %d events, but got %d: %v", len(expectedReasons), len(actualEvents), actualEvents)
+	}
+	for i, event := range actualEvents {
+		if event.Reason != expectedReasons[i] {
+			t.Errorf("expected event %q at index %d, but got %q", expectedReasons[i], i, event.Reason)
+		}
+	}
+}
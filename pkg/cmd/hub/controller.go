@@ -6,6 +6,12 @@ import (
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 
 	"open-cluster-management.io/registration/pkg/hub"
+	"open-cluster-management.io/registration/pkg/hub/clusterrole"
+	"open-cluster-management.io/registration/pkg/hub/csr"
+	"open-cluster-management.io/registration/pkg/hub/lease"
+	"open-cluster-management.io/registration/pkg/hub/managedcluster"
+	"open-cluster-management.io/registration/pkg/hub/managedclusterset"
+	"open-cluster-management.io/registration/pkg/hub/rbacfinalizerdeletion"
 	"open-cluster-management.io/registration/pkg/version"
 )
 
@@ -15,6 +21,40 @@ func NewController() *cobra.Command {
 		NewCommand()
 	cmd.Use = "controller"
 	cmd.Short = "Start the Cluster Registration Controller"
+	cmd.Flags().IntVar(&managedcluster.ControllerWorkers, "managed-cluster-controller-workers", managedcluster.ControllerWorkers,
+		"The number of workers of the managed cluster controller, reconciling that many clusters in parallel.")
+	cmd.Flags().IntVar(&rbacfinalizerdeletion.ControllerWorkers, "rbac-finalizer-controller-workers", rbacfinalizerdeletion.ControllerWorkers,
+		"The number of workers of the finalize controller, unblocking the namespaces of that many deleting clusters in parallel.")
+	cmd.Flags().StringVar(&hub.HealthProbeAddress, "health-probe-address", hub.HealthProbeAddress,
+		"The address the aggregated health endpoint of the hub controllers binds to, e.g. :8000. An empty address disables the endpoint.")
+	cmd.Flags().IntVar(&hub.OverloadProtectionThreshold, "apiserver-overload-threshold", hub.OverloadProtectionThreshold,
+		"The number of consecutive overload responses (429 or timeout) from the apiserver after which the hub controllers back off globally. Zero disables the protection.")
+	cmd.Flags().DurationVar(&hub.OverloadProtectionInitialBackoff, "apiserver-overload-initial-backoff", hub.OverloadProtectionInitialBackoff,
+		"The initial backoff once the apiserver overload protection triggers, doubled on continued overload.")
+	cmd.Flags().DurationVar(&hub.OverloadProtectionMaxBackoff, "apiserver-overload-max-backoff", hub.OverloadProtectionMaxBackoff,
+		"The upper bound of the apiserver overload backoff.")
+	cmd.Flags().BoolVar(&managedclusterset.EnableBindingPruning, "prune-orphaned-clusterset-bindings", managedclusterset.EnableBindingPruning,
+		"Delete ManagedClusterSetBindings whose ManagedClusterSet no longer exists. Disabled by default.")
+	cmd.Flags().DurationVar(&lease.InitialGracePeriod, "cluster-initial-grace-period", lease.InitialGracePeriod,
+		"The period after a cluster is accepted during which it is not marked unavailable for a missing lease, giving the agent time to start its heartbeat. A zero period disables the grace.")
+	cmd.Flags().StringVar(&lease.AvailabilitySinkFile, "availability-sink-file", lease.AvailabilitySinkFile,
+		"The file the availability transitions of managed clusters are appended to as JSON lines, '-' writes to stdout. An empty path disables the sink.")
+	cmd.Flags().DurationVar(&lease.MaxFutureClockSkew, "max-future-clock-skew", lease.MaxFutureClockSkew,
+		"The maximum amount a cluster lease renew time may be ahead of the hub clock before the lease is rejected as invalid. A zero bound disables the check.")
+	cmd.Flags().StringSliceVar(&lease.CriticalAddOns, "availability-critical-addons", lease.CriticalAddOns,
+		"A list of add-on names a managed cluster must report as available to be considered available itself, despite a fresh lease. An empty list keeps the availability lease-only.")
+	cmd.Flags().StringVar(&clusterrole.AggregationLabel, "fleet-reader-aggregation-label", clusterrole.AggregationLabel,
+		"The label set on the generated fleet reader clusterrole, so it can be aggregated into other clusterroles through an aggregation rule selecting this label.")
+	cmd.Flags().DurationVar(&csr.ClientCertExpiryWindow, "client-cert-expiry-window", csr.ClientCertExpiryWindow,
+		"The window before client certificate expiration within which a managed cluster is flagged as expiring soon. A zero window disables the check.")
+	cmd.Flags().BoolVar(&csr.PrintClientCertExpiryReport, "print-client-cert-expiry-report", csr.PrintClientCertExpiryReport,
+		"Log a report listing the clusters whose client certificates expire within the expiry window on every resync.")
+	cmd.Flags().StringSliceVar(&csr.ExpectedCSRUsages, "csr-expected-usages", csr.ExpectedCSRUsages,
+		"If non-empty, only CSRs whose requested key usages exactly match this set are auto approved, e.g. 'digital signature,key encipherment,client auth'. CSRs with extra or missing usages are left pending.")
+	cmd.Flags().StringSliceVar(&csr.RequiredCSROrganizations, "csr-required-organizations", csr.RequiredCSROrganizations,
+		"If non-empty, only CSRs whose certificate request carries every listed organization are auto approved. CSRs missing a required organization are left pending.")
+	cmd.Flags().DurationVar(&csr.CSRMaxPendingAge, "csr-max-pending-age", csr.CSRMaxPendingAge,
+		"If set, CSRs the controller declines to approve are denied once they have been pending longer than this age, so the agent retries with a fresh request. A zero age leaves them pending forever.")
 
 	return cmd
 }
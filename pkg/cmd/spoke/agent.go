@@ -1,6 +1,8 @@
 package spoke
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
@@ -22,5 +24,28 @@ func NewAgent() *cobra.Command {
 	agentOptions.AddFlags(flags)
 
 	flags.BoolVar(&cmdConfig.DisableLeaderElection, "disable-leader-election", false, "Disable leader election for the agent.")
+
+	cmd.AddCommand(newValidateBootstrapCommand())
+	return cmd
+}
+
+// newValidateBootstrapCommand returns the subcommand that validates a bootstrap
+// kubeconfig before it is shipped to a spoke cluster, so onboarding automation catches
+// an unreachable server or an expired credential early.
+func newValidateBootstrapCommand() *cobra.Command {
+	var bootstrapKubeconfig string
+	cmd := &cobra.Command{
+		Use:   "validate-bootstrap",
+		Short: "Validate a bootstrap kubeconfig before shipping it to a spoke cluster",
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := spoke.ValidateBootstrapKubeconfig(bootstrapKubeconfig); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.OutOrStdout(), "The bootstrap kubeconfig %q is valid\n", bootstrapKubeconfig)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&bootstrapKubeconfig, "bootstrap-kubeconfig", "",
+		"The path of the bootstrap kubeconfig file to validate.")
 	return cmd
 }
@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	clusterwebhook "open-cluster-management.io/registration/pkg/webhook/cluster"
+)
+
+const (
+	// defaultWebhookTimeoutSeconds is the recommended admission timeout for webhooks
+	// that only inspect the request or issue a single authorization check.
+	defaultWebhookTimeoutSeconds int32 = 3
+	// resolvingWebhookTimeoutSeconds is the recommended admission timeout when the
+	// validating webhook resolves client config hosts, each lookup may take up to
+	// the resolve timeout before the webhook answers.
+	resolvingWebhookTimeoutSeconds int32 = 10
+)
+
+// newRenderWebhookConfigCommand returns the `render-webhook-config` subcommand, which
+// prints recommended ValidatingWebhookConfiguration and MutatingWebhookConfiguration
+// manifests derived from the webhook flags, so operators deploy the webhook with a
+// failure policy and timeout matching the features they enabled.
+func newRenderWebhookConfigCommand() *cobra.Command {
+	clusterValidatingHook := clusterwebhook.NewManagedClusterValidatingAdmissionHook(nil)
+
+	cmd := &cobra.Command{
+		Use:   "render-webhook-config",
+		Short: "Render recommended webhook configuration manifests",
+		RunE: func(c *cobra.Command, args []string) error {
+			return renderWebhookConfig(c.OutOrStdout(), clusterValidatingHook)
+		},
+	}
+	clusterValidatingHook.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// renderWebhookConfig writes the recommended webhook configuration manifests as YAML.
+// The validating webhooks authorize requests and fail closed; the mutating webhook only
+// defaults fields the controllers reconcile anyway and fails open, so a webhook outage
+// does not block cluster registration twice over.
+func renderWebhookConfig(w io.Writer, clusterValidatingHook *clusterwebhook.ManagedClusterValidatingAdmissionHook) error {
+	validatingTimeout := defaultWebhookTimeoutSeconds
+	if clusterValidatingHook.EffectiveConfig().ResolveClientConfigHosts {
+		validatingTimeout = resolvingWebhookTimeoutSeconds
+	}
+
+	manifests := []interface{}{
+		&admissionregistrationv1.ValidatingWebhookConfiguration{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+				Kind:       "ValidatingWebhookConfiguration",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "managedclustervalidators.admission.cluster.open-cluster-management.io",
+			},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{
+				newValidatingWebhook(
+					"managedclustervalidators.admission.cluster.open-cluster-management.io",
+					"/apis/admission.cluster.open-cluster-management.io/v1/managedclustervalidators",
+					"managedclusters",
+					admissionregistrationv1.Fail,
+					validatingTimeout,
+				),
+			},
+		},
+		&admissionregistrationv1.MutatingWebhookConfiguration{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+				Kind:       "MutatingWebhookConfiguration",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "managedclustermutators.admission.cluster.open-cluster-management.io",
+			},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{
+				newMutatingWebhook(
+					"managedclustermutators.admission.cluster.open-cluster-management.io",
+					"/apis/admission.cluster.open-cluster-management.io/v1/managedclustermutators",
+					"managedclusters",
+					admissionregistrationv1.Ignore,
+					defaultWebhookTimeoutSeconds,
+				),
+			},
+		},
+		&admissionregistrationv1.ValidatingWebhookConfiguration{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+				Kind:       "ValidatingWebhookConfiguration",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "managedclustersetbindingvalidators.admission.cluster.open-cluster-management.io",
+			},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{
+				newValidatingWebhook(
+					"managedclustersetbindingvalidators.admission.cluster.open-cluster-management.io",
+					"/apis/admission.cluster.open-cluster-management.io/v1/managedclustersetbindingvalidators",
+					"managedclustersetbindings",
+					admissionregistrationv1.Fail,
+					defaultWebhookTimeoutSeconds,
+				),
+			},
+		},
+	}
+
+	for i, manifest := range manifests {
+		encoded, err := yaml.Marshal(manifest)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintln(w, "---")
+		}
+		fmt.Fprint(w, string(encoded))
+	}
+	return nil
+}
+
+func newValidatingWebhook(name, path, resource string, failurePolicy admissionregistrationv1.FailurePolicyType, timeoutSeconds int32) admissionregistrationv1.ValidatingWebhook {
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+	return admissionregistrationv1.ValidatingWebhook{
+		Name:                    name,
+		ClientConfig:            newWebhookClientConfig(path),
+		Rules:                   newWebhookRules(resource),
+		FailurePolicy:           &failurePolicy,
+		SideEffects:             &sideEffects,
+		TimeoutSeconds:          &timeoutSeconds,
+		AdmissionReviewVersions: []string{"v1beta1"},
+	}
+}
+
+func newMutatingWebhook(name, path, resource string, failurePolicy admissionregistrationv1.FailurePolicyType, timeoutSeconds int32) admissionregistrationv1.MutatingWebhook {
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+	return admissionregistrationv1.MutatingWebhook{
+		Name:                    name,
+		ClientConfig:            newWebhookClientConfig(path),
+		Rules:                   newWebhookRules(resource),
+		FailurePolicy:           &failurePolicy,
+		SideEffects:             &sideEffects,
+		TimeoutSeconds:          &timeoutSeconds,
+		AdmissionReviewVersions: []string{"v1beta1"},
+	}
+}
+
+// newWebhookClientConfig reaches the webhook via the registered aggregated API.
+func newWebhookClientConfig(path string) admissionregistrationv1.WebhookClientConfig {
+	return admissionregistrationv1.WebhookClientConfig{
+		Service: &admissionregistrationv1.ServiceReference{
+			Namespace: "default",
+			Name:      "kubernetes",
+			Path:      &path,
+		},
+	}
+}
+
+func newWebhookRules(resource string) []admissionregistrationv1.RuleWithOperations {
+	return []admissionregistrationv1.RuleWithOperations{
+		{
+			Operations: []admissionregistrationv1.OperationType{
+				admissionregistrationv1.Create,
+				admissionregistrationv1.Update,
+			},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{"cluster.open-cluster-management.io"},
+				APIVersions: []string{"*"},
+				Resources:   []string{resource},
+			},
+		},
+	}
+}
@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	clusterwebhook "open-cluster-management.io/registration/pkg/webhook/cluster"
+	clustersetwebhook "open-cluster-management.io/registration/pkg/webhook/clusterset"
+	clustersetbindingwebhook "open-cluster-management.io/registration/pkg/webhook/clustersetbinding"
+)
+
+// newSimulateCommand returns the `simulate` subcommand, which replays the static admission
+// rules against a directory of ManagedCluster, ManagedClusterSet and ManagedClusterSetBinding
+// manifests. It lets operators see how many existing objects a new validation rule would
+// reject before enabling enforcement. The rules needing a live cluster, such as the
+// SubjectAccessReview based authorization checks, are skipped and noted.
+func newSimulateCommand() *cobra.Command {
+	clusterValidatingHook := clusterwebhook.NewManagedClusterValidatingAdmissionHook(nil)
+	clusterSetValidatingHook := &clustersetwebhook.ManagedClusterSetValidatingAdmissionHook{
+		ReservedNameSubresource: defaultReservedNameSubresource,
+	}
+	clusterSetBindingValidatingHook := &clustersetbindingwebhook.ManagedClusterSetBindingValidatingAdmissionHook{}
+
+	var manifestDir string
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulate the admission decisions for a directory of manifests",
+		RunE: func(c *cobra.Command, args []string) error {
+			return simulateAdmission(c.OutOrStdout(), manifestDir,
+				clusterValidatingHook, clusterSetValidatingHook, clusterSetBindingValidatingHook)
+		},
+	}
+	cmd.Flags().StringVar(&manifestDir, "manifest-dir", "",
+		"The directory containing the YAML manifests the admission decisions are simulated for.")
+	clusterValidatingHook.AddFlags(cmd.Flags())
+	clusterSetValidatingHook.AddFlags(cmd.Flags())
+	clusterSetBindingValidatingHook.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// simulatedResources maps the manifest kinds the simulation handles to their resource
+// names in the cluster.open-cluster-management.io group.
+var simulatedResources = map[string]string{
+	"ManagedCluster":           "managedclusters",
+	"ManagedClusterSet":        "managedclustersets",
+	"ManagedClusterSetBinding": "managedclustersetbindings",
+}
+
+// simulateAdmission runs the validating webhooks over every manifest in the given
+// directory and reports the decision per object. The hooks are exercised through the same
+// Validate handlers the admission server calls; since no kube client is initialized, a
+// rule that needs a live cluster answers with its not-ready status, which the report
+// translates into a note that the authorization rules were skipped.
+func simulateAdmission(w io.Writer, manifestDir string,
+	clusterValidatingHook *clusterwebhook.ManagedClusterValidatingAdmissionHook,
+	clusterSetValidatingHook *clustersetwebhook.ManagedClusterSetValidatingAdmissionHook,
+	clusterSetBindingValidatingHook *clustersetbindingwebhook.ManagedClusterSetBindingValidatingAdmissionHook) error {
+	if len(manifestDir) == 0 {
+		return fmt.Errorf("manifest-dir is required")
+	}
+
+	entries, err := ioutil.ReadDir(manifestDir)
+	if err != nil {
+		return fmt.Errorf("unable to read the manifest directory %q: %w", manifestDir, err)
+	}
+
+	fileNames := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if ext := path.Ext(entry.Name()); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		fileNames = append(fileNames, entry.Name())
+	}
+	sort.Strings(fileNames)
+
+	objectCount, deniedCount := 0, 0
+	for _, fileName := range fileNames {
+		content, err := ioutil.ReadFile(path.Clean(path.Join(manifestDir, fileName)))
+		if err != nil {
+			return fmt.Errorf("unable to read the manifest %q: %w", fileName, err)
+		}
+
+		for _, document := range bytes.Split(content, []byte("\n---")) {
+			object, err := utilyaml.ToJSON(document)
+			if err != nil {
+				return fmt.Errorf("unable to parse the manifest %q: %w", fileName, err)
+			}
+			typeMeta := metav1.TypeMeta{}
+			if err := yaml.Unmarshal(object, &typeMeta); err != nil || len(typeMeta.Kind) == 0 {
+				// an empty document or a fragment without a kind
+				continue
+			}
+			partial := &metav1.PartialObjectMetadata{}
+			_ = yaml.Unmarshal(object, partial)
+
+			resource, ok := simulatedResources[typeMeta.Kind]
+			if !ok {
+				fmt.Fprintf(w, "%s: %s/%s: skipped, the kind is not validated by the webhooks\n",
+					fileName, typeMeta.Kind, partial.Name)
+				continue
+			}
+
+			groupVersion := strings.SplitN(typeMeta.APIVersion, "/", 2)
+			request := &admissionv1beta1.AdmissionRequest{
+				Resource: metav1.GroupVersionResource{
+					Group:    "cluster.open-cluster-management.io",
+					Version:  groupVersion[len(groupVersion)-1],
+					Resource: resource,
+				},
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: object},
+			}
+
+			var response *admissionv1beta1.AdmissionResponse
+			switch resource {
+			case "managedclusters":
+				response = clusterValidatingHook.Validate(request)
+			case "managedclustersets":
+				response = clusterSetValidatingHook.Validate(request)
+			case "managedclustersetbindings":
+				response = clusterSetBindingValidatingHook.Validate(request)
+			}
+
+			objectCount++
+			switch {
+			case response.Allowed:
+				fmt.Fprintf(w, "%s: %s/%s: allowed\n", fileName, typeMeta.Kind, partial.Name)
+			case response.Result != nil && response.Result.Reason == metav1.StatusReasonServiceUnavailable:
+				fmt.Fprintf(w, "%s: %s/%s: allowed by the static rules, the rules needing a live cluster were skipped\n",
+					fileName, typeMeta.Kind, partial.Name)
+			default:
+				deniedCount++
+				fmt.Fprintf(w, "%s: %s/%s: denied: %s\n", fileName, typeMeta.Kind, partial.Name, response.Result.Message)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "%d of %d objects would be denied by the static rules\n", deniedCount, objectCount)
+	return nil
+}
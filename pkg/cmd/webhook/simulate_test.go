@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestSimulateAdmission(t *testing.T) {
+	clusterManifests := `apiVersion: cluster.open-cluster-management.io/v1
+kind: ManagedCluster
+metadata:
+  name: cluster1
+spec:
+  hubAcceptsClient: false
+---
+apiVersion: cluster.open-cluster-management.io/v1
+kind: ManagedCluster
+metadata:
+  name: cluster2
+spec:
+  taints:
+  - key: ""
+    effect: NoSelect
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unrelated
+`
+	clusterSetManifests := `apiVersion: cluster.open-cluster-management.io/v1beta1
+kind: ManagedClusterSet
+metadata:
+  name: dev-clusters
+---
+apiVersion: cluster.open-cluster-management.io/v1beta1
+kind: ManagedClusterSetBinding
+metadata:
+  name: mismatched
+  namespace: cluster1
+spec:
+  clusterSet: dev-clusters
+---
+apiVersion: cluster.open-cluster-management.io/v1beta1
+kind: ManagedClusterSetBinding
+metadata:
+  name: dev-clusters
+  namespace: cluster1
+spec:
+  clusterSet: dev-clusters
+`
+
+	cases := []struct {
+		name           string
+		args           []string
+		expectedOutput string
+	}{
+		{
+			name: "simulate with the default rules",
+			expectedOutput: `clusters.yaml: ManagedCluster/cluster1: allowed by the static rules, the rules needing a live cluster were skipped
+clusters.yaml: ManagedCluster/cluster2: denied: taint key of taints[0] is empty
+clusters.yaml: ConfigMap/unrelated: skipped, the kind is not validated by the webhooks
+sets.yaml: ManagedClusterSet/dev-clusters: allowed
+sets.yaml: ManagedClusterSetBinding/mismatched: denied: The ManagedClusterSetBinding must have the same name as the target ManagedClusterSet
+sets.yaml: ManagedClusterSetBinding/dev-clusters: allowed by the static rules, the rules needing a live cluster were skipped
+2 of 5 objects would be denied by the static rules
+`,
+		},
+		{
+			name: "simulate a new rule before enforcement",
+			args: []string{"--require-owner-annotation"},
+			expectedOutput: `clusters.yaml: ManagedCluster/cluster1: denied: a non-empty owner annotation is required, set the annotation to the email or team owning the cluster
+clusters.yaml: ManagedCluster/cluster2: denied: taint key of taints[0] is empty
+clusters.yaml: ConfigMap/unrelated: skipped, the kind is not validated by the webhooks
+sets.yaml: ManagedClusterSet/dev-clusters: allowed
+sets.yaml: ManagedClusterSetBinding/mismatched: denied: The ManagedClusterSetBinding must have the same name as the target ManagedClusterSet
+sets.yaml: ManagedClusterSetBinding/dev-clusters: allowed by the static rules, the rules needing a live cluster were skipped
+3 of 5 objects would be denied by the static rules
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "simulate")
+			if err != nil {
+				t.Fatalf("unable to create a tmp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+			if err := ioutil.WriteFile(path.Join(dir, "clusters.yaml"), []byte(clusterManifests), 0600); err != nil {
+				t.Fatalf("unable to write the fixture: %v", err)
+			}
+			if err := ioutil.WriteFile(path.Join(dir, "sets.yaml"), []byte(clusterSetManifests), 0600); err != nil {
+				t.Fatalf("unable to write the fixture: %v", err)
+			}
+
+			out := &bytes.Buffer{}
+			cmd := newSimulateCommand()
+			cmd.SetOut(out)
+			cmd.SetArgs(append([]string{"--manifest-dir", dir}, c.args...))
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if out.String() != c.expectedOutput {
+				t.Errorf("expected output \n%s but got: \n%s", c.expectedOutput, out.String())
+			}
+		})
+	}
+}
+
+func TestSimulateAdmissionMissingDir(t *testing.T) {
+	cmd := newSimulateCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err == nil || err.Error() != "manifest-dir is required" {
+		t.Errorf("expected the manifest-dir error but got: %v", err)
+	}
+}
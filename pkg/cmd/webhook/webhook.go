@@ -1,22 +1,43 @@
 package webhook
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 
 	admissionserver "github.com/openshift/generic-admission-server/pkg/cmd/server"
 	"github.com/spf13/cobra"
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	genericoptions "k8s.io/apiserver/pkg/server/options"
+	cliflag "k8s.io/component-base/cli/flag"
 	clusterwebhook "open-cluster-management.io/registration/pkg/webhook/cluster"
+	clustersetwebhook "open-cluster-management.io/registration/pkg/webhook/clusterset"
 	clustersetbindingwebhook "open-cluster-management.io/registration/pkg/webhook/clustersetbinding"
+	webhooktracing "open-cluster-management.io/registration/pkg/webhook/tracing"
+	webhookwarnings "open-cluster-management.io/registration/pkg/webhook/warnings"
 )
 
+// defaultReservedNameSubresource is the ManagedClusterSet subresource the create permission
+// for reserved cluster set name prefixes is checked on by default.
+const defaultReservedNameSubresource = "reserve"
+
 func NewAdmissionHook() *cobra.Command {
+	clusterValidatingHook := clusterwebhook.NewManagedClusterValidatingAdmissionHook(nil)
+	clusterMutatingHook := &clusterwebhook.ManagedClusterMutatingAdmissionHook{}
+	clusterSetValidatingHook := &clustersetwebhook.ManagedClusterSetValidatingAdmissionHook{
+		ReservedNameSubresource: defaultReservedNameSubresource,
+	}
+	clusterSetBindingValidatingHook := &clustersetbindingwebhook.ManagedClusterSetBindingValidatingAdmissionHook{}
+
 	o := admissionserver.NewAdmissionServerOptions(
 		os.Stdout,
 		os.Stderr,
-		&clusterwebhook.ManagedClusterValidatingAdmissionHook{},
-		&clusterwebhook.ManagedClusterMutatingAdmissionHook{},
-		&clustersetbindingwebhook.ManagedClusterSetBindingValidatingAdmissionHook{})
+		clusterValidatingHook,
+		clusterMutatingHook,
+		clusterSetValidatingHook,
+		clusterSetBindingValidatingHook)
 
 	cmd := &cobra.Command{
 		Use:   "webhook",
@@ -30,6 +51,16 @@ func NewAdmissionHook() *cobra.Command {
 			if err := o.Validate(args); err != nil {
 				return err
 			}
+			// fail fast on invalid TLS settings, the serving options only resolve
+			// the names when the server starts serving
+			if err := validateTLSOptions(o.RecommendedOptions.SecureServing.SecureServingOptions); err != nil {
+				return err
+			}
+			shutdownTracing, err := webhooktracing.Setup(context.Background())
+			if err != nil {
+				return err
+			}
+			defer shutdownTracing()
 			if err := o.RunAdmissionServer(stopCh); err != nil {
 				return err
 			}
@@ -38,6 +69,73 @@ func NewAdmissionHook() *cobra.Command {
 	}
 
 	o.RecommendedOptions.AddFlags(cmd.Flags())
+	clusterValidatingHook.AddFlags(cmd.Flags())
+	clusterMutatingHook.AddFlags(cmd.Flags())
+	clusterSetValidatingHook.AddFlags(cmd.Flags())
+	clusterSetBindingValidatingHook.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&webhooktracing.OTLPEndpoint, "otlp-endpoint", webhooktracing.OTLPEndpoint,
+		"The endpoint of the OTLP collector the admission spans are exported to, e.g. otel-collector:4317. An empty endpoint disables the tracing.")
+	cmd.Flags().BoolVar(&webhookwarnings.Suppressed, "suppress-admission-warnings", webhookwarnings.Suppressed,
+		"Do not attach admission warnings for soft rule violations to the responses. The admission decisions are not affected.")
+
+	cmd.AddCommand(newConfigCommand())
+	cmd.AddCommand(newRenderWebhookConfigCommand())
+	cmd.AddCommand(newSimulateCommand())
 
 	return cmd
 }
+
+// validateTLSOptions verifies that the tls-min-version and tls-cipher-suites flag values
+// name known TLS constants, so a misconfigured webhook fails at startup with a clear
+// error instead of when the server begins serving.
+func validateTLSOptions(secureServing *genericoptions.SecureServingOptions) error {
+	if secureServing == nil {
+		return nil
+	}
+	if _, err := cliflag.TLSVersion(secureServing.MinTLSVersion); err != nil {
+		return fmt.Errorf("invalid tls-min-version %q: %v", secureServing.MinTLSVersion, err)
+	}
+	if _, err := cliflag.TLSCipherSuites(secureServing.CipherSuites); err != nil {
+		return fmt.Errorf("invalid tls-cipher-suites: %v", err)
+	}
+	return nil
+}
+
+// newConfigCommand returns the `config` subcommand with its `dump` subcommand, which
+// prints the effective, resolved webhook configuration as JSON so operators can confirm
+// what a webhook started with the same flags actually enforces.
+func newConfigCommand() *cobra.Command {
+	clusterValidatingHook := clusterwebhook.NewManagedClusterValidatingAdmissionHook(nil)
+
+	dumpCmd := &cobra.Command{
+		Use:   "dump",
+		Short: "Dump the effective webhook configuration as JSON",
+		RunE: func(c *cobra.Command, args []string) error {
+			return dumpWebhookConfig(c.OutOrStdout(), clusterValidatingHook)
+		},
+	}
+	clusterValidatingHook.AddFlags(dumpCmd.Flags())
+
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the webhook configuration",
+	}
+	configCmd.AddCommand(dumpCmd)
+
+	return configCmd
+}
+
+// dumpWebhookConfig writes the resolved webhook configuration as indented JSON. Only the
+// enforcement configuration is printed; credential-bearing serving options are never
+// included in the dump.
+func dumpWebhookConfig(w io.Writer, clusterValidatingHook *clusterwebhook.ManagedClusterValidatingAdmissionHook) error {
+	config := map[string]interface{}{
+		"managedClusterValidatingWebhook": clusterValidatingHook.EffectiveConfig(),
+	}
+	encoded, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, string(encoded))
+	return nil
+}
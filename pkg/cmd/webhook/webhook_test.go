@@ -0,0 +1,362 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net"
+	"testing"
+
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	genericoptions "k8s.io/apiserver/pkg/server/options"
+)
+
+func TestValidateTLSOptions(t *testing.T) {
+	cases := []struct {
+		name          string
+		minTLSVersion string
+		cipherSuites  []string
+		expectedErr   string
+	}{
+		{
+			name: "default options",
+		},
+		{
+			name:          "known constants",
+			minTLSVersion: "VersionTLS13",
+			cipherSuites:  []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+		},
+		{
+			name:          "unknown min version",
+			minTLSVersion: "VersionTLS14",
+			expectedErr:   "invalid tls-min-version \"VersionTLS14\": unknown tls version \"VersionTLS14\"",
+		},
+		{
+			name:         "unknown cipher suite",
+			cipherSuites: []string{"TLS_BOGUS_SUITE"},
+			expectedErr:  "invalid tls-cipher-suites: Cipher suite TLS_BOGUS_SUITE not supported or doesn't exist",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			secureServing := genericoptions.NewSecureServingOptions()
+			secureServing.MinTLSVersion = c.minTLSVersion
+			secureServing.CipherSuites = c.cipherSuites
+
+			err := validateTLSOptions(secureServing)
+			switch {
+			case len(c.expectedErr) == 0 && err != nil:
+				t.Errorf("unexpected err: %v", err)
+			case len(c.expectedErr) != 0 && err == nil:
+				t.Errorf("expected error %q but got none", c.expectedErr)
+			case len(c.expectedErr) != 0 && err.Error() != c.expectedErr:
+				t.Errorf("expected error %q but got: %q", c.expectedErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestMinTLSVersionAppliedToServingInfo(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer listener.Close()
+
+	secureServing := genericoptions.NewSecureServingOptions()
+	secureServing.Listener = listener
+	secureServing.MinTLSVersion = "VersionTLS13"
+
+	var servingInfo *genericapiserver.SecureServingInfo
+	if err := secureServing.ApplyTo(&servingInfo); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if servingInfo.MinTLSVersion != tls.VersionTLS13 {
+		t.Errorf("expected min TLS version %d but got: %d", tls.VersionTLS13, servingInfo.MinTLSVersion)
+	}
+}
+
+func TestDumpWebhookConfig(t *testing.T) {
+	cases := []struct {
+		name           string
+		args           []string
+		expectedOutput string
+	}{
+		{
+			name: "dump the default configuration",
+			args: []string{"dump"},
+			expectedOutput: `{
+  "managedClusterValidatingWebhook": {
+    "maxTaints": 16,
+    "maxLabels": 64,
+    "excludeReservedLabelsFromCount": false,
+    "resolveClientConfigHosts": false,
+    "strictClientConfigResolution": false,
+    "requireOwnerAnnotation": false,
+    "ownerAnnotationKey": "owner",
+    "reservedNameSubresource": "reservedname",
+    "allowTerminatingClusterNamespace": false,
+    "denyExpiredClientConfigCA": false,
+    "requireExternalCABundle": false,
+    "requireClientConfigsForAccept": false,
+    "freezeOverrideAnnotation": "open-cluster-management.io/freeze-override",
+    "freezeOverrideSubresource": "freezeoverride",
+    "impersonateAuthorizationIdentity": false,
+    "acceptCheckGroup": "register.open-cluster-management.io",
+    "acceptCheckResource": "managedclusters",
+    "acceptCheckSubresource": "accept"
+  }
+}
+`,
+		},
+		{
+			name: "dump a customized configuration",
+			args: []string{"dump", "--max-taints=4", "--allowed-client-config-ports=443,6443,8443"},
+			expectedOutput: `{
+  "managedClusterValidatingWebhook": {
+    "maxTaints": 4,
+    "maxLabels": 64,
+    "excludeReservedLabelsFromCount": false,
+    "allowedClientConfigPorts": [
+      443,
+      6443,
+      8443
+    ],
+    "resolveClientConfigHosts": false,
+    "strictClientConfigResolution": false,
+    "requireOwnerAnnotation": false,
+    "ownerAnnotationKey": "owner",
+    "reservedNameSubresource": "reservedname",
+    "allowTerminatingClusterNamespace": false,
+    "denyExpiredClientConfigCA": false,
+    "requireExternalCABundle": false,
+    "requireClientConfigsForAccept": false,
+    "freezeOverrideAnnotation": "open-cluster-management.io/freeze-override",
+    "freezeOverrideSubresource": "freezeoverride",
+    "impersonateAuthorizationIdentity": false,
+    "acceptCheckGroup": "register.open-cluster-management.io",
+    "acceptCheckResource": "managedclusters",
+    "acceptCheckSubresource": "accept"
+  }
+}
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			cmd := newConfigCommand()
+			cmd.SetOut(out)
+			cmd.SetArgs(c.args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if out.String() != c.expectedOutput {
+				t.Errorf("expected output \n%s but got: \n%s", c.expectedOutput, out.String())
+			}
+		})
+	}
+}
+
+func TestRenderWebhookConfig(t *testing.T) {
+	cases := []struct {
+		name           string
+		args           []string
+		expectedOutput string
+	}{
+		{
+			name: "render the default manifests",
+			expectedOutput: `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  creationTimestamp: null
+  name: managedclustervalidators.admission.cluster.open-cluster-management.io
+webhooks:
+- admissionReviewVersions:
+  - v1beta1
+  clientConfig:
+    service:
+      name: kubernetes
+      namespace: default
+      path: /apis/admission.cluster.open-cluster-management.io/v1/managedclustervalidators
+  failurePolicy: Fail
+  name: managedclustervalidators.admission.cluster.open-cluster-management.io
+  rules:
+  - apiGroups:
+    - cluster.open-cluster-management.io
+    apiVersions:
+    - '*'
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - managedclusters
+  sideEffects: None
+  timeoutSeconds: 3
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: MutatingWebhookConfiguration
+metadata:
+  creationTimestamp: null
+  name: managedclustermutators.admission.cluster.open-cluster-management.io
+webhooks:
+- admissionReviewVersions:
+  - v1beta1
+  clientConfig:
+    service:
+      name: kubernetes
+      namespace: default
+      path: /apis/admission.cluster.open-cluster-management.io/v1/managedclustermutators
+  failurePolicy: Ignore
+  name: managedclustermutators.admission.cluster.open-cluster-management.io
+  rules:
+  - apiGroups:
+    - cluster.open-cluster-management.io
+    apiVersions:
+    - '*'
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - managedclusters
+  sideEffects: None
+  timeoutSeconds: 3
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  creationTimestamp: null
+  name: managedclustersetbindingvalidators.admission.cluster.open-cluster-management.io
+webhooks:
+- admissionReviewVersions:
+  - v1beta1
+  clientConfig:
+    service:
+      name: kubernetes
+      namespace: default
+      path: /apis/admission.cluster.open-cluster-management.io/v1/managedclustersetbindingvalidators
+  failurePolicy: Fail
+  name: managedclustersetbindingvalidators.admission.cluster.open-cluster-management.io
+  rules:
+  - apiGroups:
+    - cluster.open-cluster-management.io
+    apiVersions:
+    - '*'
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - managedclustersetbindings
+  sideEffects: None
+  timeoutSeconds: 3
+`,
+		},
+		{
+			name: "render with host resolution enabled",
+			args: []string{"--resolve-client-config-hosts"},
+			expectedOutput: `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  creationTimestamp: null
+  name: managedclustervalidators.admission.cluster.open-cluster-management.io
+webhooks:
+- admissionReviewVersions:
+  - v1beta1
+  clientConfig:
+    service:
+      name: kubernetes
+      namespace: default
+      path: /apis/admission.cluster.open-cluster-management.io/v1/managedclustervalidators
+  failurePolicy: Fail
+  name: managedclustervalidators.admission.cluster.open-cluster-management.io
+  rules:
+  - apiGroups:
+    - cluster.open-cluster-management.io
+    apiVersions:
+    - '*'
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - managedclusters
+  sideEffects: None
+  timeoutSeconds: 10
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: MutatingWebhookConfiguration
+metadata:
+  creationTimestamp: null
+  name: managedclustermutators.admission.cluster.open-cluster-management.io
+webhooks:
+- admissionReviewVersions:
+  - v1beta1
+  clientConfig:
+    service:
+      name: kubernetes
+      namespace: default
+      path: /apis/admission.cluster.open-cluster-management.io/v1/managedclustermutators
+  failurePolicy: Ignore
+  name: managedclustermutators.admission.cluster.open-cluster-management.io
+  rules:
+  - apiGroups:
+    - cluster.open-cluster-management.io
+    apiVersions:
+    - '*'
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - managedclusters
+  sideEffects: None
+  timeoutSeconds: 3
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  creationTimestamp: null
+  name: managedclustersetbindingvalidators.admission.cluster.open-cluster-management.io
+webhooks:
+- admissionReviewVersions:
+  - v1beta1
+  clientConfig:
+    service:
+      name: kubernetes
+      namespace: default
+      path: /apis/admission.cluster.open-cluster-management.io/v1/managedclustersetbindingvalidators
+  failurePolicy: Fail
+  name: managedclustersetbindingvalidators.admission.cluster.open-cluster-management.io
+  rules:
+  - apiGroups:
+    - cluster.open-cluster-management.io
+    apiVersions:
+    - '*'
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - managedclustersetbindings
+  sideEffects: None
+  timeoutSeconds: 3
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			cmd := newRenderWebhookConfigCommand()
+			cmd.SetOut(out)
+			cmd.SetArgs(c.args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if out.String() != c.expectedOutput {
+				t.Errorf("expected output \n%s but got: \n%s", c.expectedOutput, out.String())
+			}
+		})
+	}
+}
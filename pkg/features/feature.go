@@ -27,6 +27,13 @@ const (
 	// AddonManagement will start new controllers in the spoke-agent to manage the managed cluster addons
 	// registration and maintains the status of managed cluster addons through watching their leases.
 	AddonManagement featuregate.Feature = "AddonManagement"
+
+	// ClusterCapacitySummary will start a new controller in the spoke-agent that periodically
+	// reports the node count and the aggregated allocatable cpu/memory of the managed cluster
+	// as annotations on the ManagedCluster, so the hub can do fleet capacity planning without
+	// a full observability stack. The controller tolerates a missing node list permission by
+	// skipping the report.
+	ClusterCapacitySummary featuregate.Feature = "ClusterCapacitySummary"
 )
 
 var (
@@ -44,6 +51,7 @@ func init() {
 // feature keys.  To add a new feature, define a key for it above and
 // add it here.
 var defaultRegistrationFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
-	ClusterClaim:    {Default: true, PreRelease: featuregate.Beta},
-	AddonManagement: {Default: false, PreRelease: featuregate.Alpha},
+	ClusterClaim:           {Default: true, PreRelease: featuregate.Beta},
+	AddonManagement:        {Default: false, PreRelease: featuregate.Alpha},
+	ClusterCapacitySummary: {Default: false, PreRelease: featuregate.Alpha},
 }
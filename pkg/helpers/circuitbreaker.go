@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitBreaker observes the responses of an apiserver client and backs off globally
+// when it sees sustained overload responses (429 or timeouts), so the controllers stop
+// amplifying an apiserver overload with retries. While the breaker is open, requests are
+// rejected locally without reaching the apiserver; successful responses after the open
+// window shrink the backoff gradually until the breaker is fully closed again.
+type CircuitBreaker struct {
+	failureThreshold int
+	initialBackoff   time.Duration
+	maxBackoff       time.Duration
+
+	lock                sync.Mutex
+	consecutiveFailures int
+	backoff             time.Duration
+	openUntil           time.Time
+
+	// for testing
+	nowFunc func() time.Time
+}
+
+// NewCircuitBreaker returns a circuit breaker that opens after failureThreshold
+// consecutive overload responses, rejecting requests for a backoff window that starts at
+// initialBackoff and doubles up to maxBackoff while the overload persists.
+func NewCircuitBreaker(failureThreshold int, initialBackoff, maxBackoff time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		initialBackoff:   initialBackoff,
+		maxBackoff:       maxBackoff,
+		nowFunc:          time.Now,
+	}
+}
+
+// WrapTransport wraps the given round tripper with the circuit breaker. It is intended
+// to be used with rest.Config.Wrap, so all clients built from the same config share one
+// breaker and back off together.
+func (b *CircuitBreaker) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return &circuitBreakerRoundTripper{breaker: b, delegate: rt}
+}
+
+// allow returns how long requests remain rejected; a zero duration allows the request.
+func (b *CircuitBreaker) allow() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if remaining := b.openUntil.Sub(b.nowFunc()); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// recordOverload counts an overload response and opens the breaker once the threshold of
+// consecutive overloads is reached, doubling the backoff on every subsequent overload.
+func (b *CircuitBreaker) recordOverload() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.consecutiveFailures++
+	if b.consecutiveFailures < b.failureThreshold {
+		return
+	}
+	switch {
+	case b.backoff == 0:
+		b.backoff = b.initialBackoff
+	case b.backoff < b.maxBackoff:
+		b.backoff = b.backoff * 2
+		if b.backoff > b.maxBackoff {
+			b.backoff = b.maxBackoff
+		}
+	}
+	b.openUntil = b.nowFunc().Add(b.backoff)
+}
+
+// recordSuccess halves the backoff instead of dropping it, so a recovering apiserver is
+// reloaded gradually rather than hit with the full request rate at once.
+func (b *CircuitBreaker) recordSuccess() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.consecutiveFailures = 0
+	b.backoff = b.backoff / 2
+	if b.backoff < b.initialBackoff {
+		b.backoff = 0
+	}
+}
+
+type circuitBreakerRoundTripper struct {
+	breaker  *CircuitBreaker
+	delegate http.RoundTripper
+}
+
+func (rt *circuitBreakerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if remaining := rt.breaker.allow(); remaining > 0 {
+		return nil, fmt.Errorf("the apiserver is overloaded, backing off for another %v", remaining.Round(time.Millisecond))
+	}
+
+	resp, err := rt.delegate.RoundTrip(req)
+	switch {
+	case err != nil:
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			rt.breaker.recordOverload()
+		}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		rt.breaker.recordOverload()
+	default:
+		rt.breaker.recordSuccess()
+	}
+	return resp, err
+}
@@ -0,0 +1,83 @@
+package helpers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeRoundTripper struct {
+	statusCodes []int
+	requests    int
+}
+
+func (rt *fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	statusCode := rt.statusCodes[rt.requests]
+	rt.requests++
+	return &http.Response{StatusCode: statusCode}, nil
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(3, time.Second, 4*time.Second)
+	breaker.nowFunc = func() time.Time { return now }
+
+	delegate := &fakeRoundTripper{statusCodes: []int{
+		http.StatusTooManyRequests,
+		http.StatusTooManyRequests,
+		http.StatusTooManyRequests,
+		http.StatusTooManyRequests,
+		http.StatusOK,
+		http.StatusOK,
+	}}
+	rt := breaker.WrapTransport(delegate)
+	request := &http.Request{}
+
+	// the breaker stays closed below the threshold of consecutive overloads
+	for i := 0; i < 2; i++ {
+		if _, err := rt.RoundTrip(request); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	// the third overload opens the breaker, the next request is rejected locally
+	if _, err := rt.RoundTrip(request); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if _, err := rt.RoundTrip(request); err == nil || !strings.Contains(err.Error(), "overloaded") {
+		t.Fatalf("expected an overload error but got: %v", err)
+	}
+	if delegate.requests != 3 {
+		t.Fatalf("expected the rejected request not to reach the apiserver, got %d requests", delegate.requests)
+	}
+
+	// continued overload after the backoff window doubles the backoff
+	now = now.Add(time.Second)
+	if _, err := rt.RoundTrip(request); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if remaining := breaker.allow(); remaining != 2*time.Second {
+		t.Fatalf("expected the backoff to double to 2s but got: %v", remaining)
+	}
+
+	// a success after the backoff window shrinks the backoff gradually
+	now = now.Add(2 * time.Second)
+	if _, err := rt.RoundTrip(request); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if breaker.backoff != time.Second {
+		t.Fatalf("expected the backoff to shrink to 1s but got: %v", breaker.backoff)
+	}
+
+	// the next success closes the breaker completely
+	if _, err := rt.RoundTrip(request); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if breaker.backoff != 0 {
+		t.Fatalf("expected the breaker to be closed but got backoff: %v", breaker.backoff)
+	}
+	if remaining := breaker.allow(); remaining != 0 {
+		t.Fatalf("expected requests to be allowed but got remaining backoff: %v", remaining)
+	}
+}
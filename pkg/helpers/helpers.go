@@ -3,6 +3,7 @@ package helpers
 import (
 	"context"
 	"embed"
+	"encoding/json"
 	"fmt"
 	"net/url"
 
@@ -233,6 +234,47 @@ func ManagedClusterAssetFn(fs embed.FS, managedClusterName string) resourceapply
 	}
 }
 
+// RBACRulesMutator adjusts the policy rules of a generated bootstrap Role or ClusterRole
+// for the agent before it is applied, e.g. to grant the extra permissions a particular
+// hub expects its agents to have. The returned rules replace the generated ones.
+type RBACRulesMutator func(objectName string, rules []rbacv1.PolicyRule) []rbacv1.PolicyRule
+
+// ManagedClusterAssetFnWithRBACMutator works like ManagedClusterAssetFn but passes the
+// rules of every rendered Role and ClusterRole through the given mutator. A nil mutator
+// keeps the generated rules. Only the rules are adjusted; the object names stay as
+// generated, so cleaning up the manifests also removes the augmented objects.
+func ManagedClusterAssetFnWithRBACMutator(fs embed.FS, managedClusterName string, mutator RBACRulesMutator) resourceapply.AssetFunc {
+	assetFn := ManagedClusterAssetFn(fs, managedClusterName)
+	if mutator == nil {
+		return assetFn
+	}
+	return func(name string) ([]byte, error) {
+		data, err := assetFn(name)
+		if err != nil {
+			return nil, err
+		}
+		object, gvk, err := genericCodec.Decode(data, nil, nil)
+		if err != nil {
+			// leave assets this helper cannot decode to the apply path
+			return data, nil
+		}
+		switch t := object.(type) {
+		case *rbacv1.Role:
+			t.Rules = mutator(t.Name, t.Rules)
+		case *rbacv1.ClusterRole:
+			t.Rules = mutator(t.Name, t.Rules)
+		default:
+			return data, nil
+		}
+		// re-encode with the group version kind kept, the apply path decodes the
+		// asset again
+		accessor, _ := meta.TypeAccessor(object)
+		accessor.SetAPIVersion(gvk.GroupVersion().String())
+		accessor.SetKind(gvk.Kind)
+		return json.Marshal(object)
+	}
+}
+
 // FindTaintByKey returns a taint if the managed cluster has a taint with the given key.
 func FindTaintByKey(managedCluster *clusterv1.ManagedCluster, key string) *clusterv1.Taint {
 	if managedCluster == nil {
@@ -0,0 +1,129 @@
+package helpers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	// defaultEventQPS is the default sustained rate of events emitted through a rate
+	// limited recorder, shared by all reasons.
+	defaultEventQPS = 10
+	// defaultEventBurst is the default burst size of events emitted through a rate
+	// limited recorder, both globally and per reason.
+	defaultEventBurst = 25
+	// defaultEventQPSPerReason is the default sustained rate of events emitted through
+	// a rate limited recorder for a single reason.
+	defaultEventQPSPerReason = 1
+)
+
+// eventRateLimiter bounds event emission with token buckets, both globally and per event
+// reason, so a single hot reason cannot consume the whole budget. It is shared by all
+// recorders derived from the same rate limited recorder.
+type eventRateLimiter struct {
+	qpsPerReason   float32
+	burstPerReason int
+
+	globalLimiter flowcontrol.RateLimiter
+
+	lock           sync.Mutex
+	reasonLimiters map[string]flowcontrol.RateLimiter
+}
+
+// accept returns true if an event with the given reason fits into both the per reason
+// and the global budget.
+func (l *eventRateLimiter) accept(reason string) bool {
+	l.lock.Lock()
+	limiter, ok := l.reasonLimiters[reason]
+	if !ok {
+		limiter = flowcontrol.NewTokenBucketRateLimiter(l.qpsPerReason, l.burstPerReason)
+		l.reasonLimiters[reason] = limiter
+	}
+	l.lock.Unlock()
+
+	if !limiter.TryAccept() {
+		return false
+	}
+	return l.globalLimiter.TryAccept()
+}
+
+// rateLimitedRecorder wraps an events.Recorder with an eventRateLimiter so bursts of
+// events, e.g. triggered by a misbehaving client, do not pressure the apiserver. Events
+// exceeding the limits are dropped.
+type rateLimitedRecorder struct {
+	recorder events.Recorder
+	limiter  *eventRateLimiter
+}
+
+// NewRateLimitedRecorder returns a recorder that drops events exceeding the given global
+// rate and burst. Each event reason is additionally limited to defaultEventQPSPerReason
+// with the same burst. Recorders derived with ForComponent, WithComponentSuffix or
+// WithContext share the limits of the recorder they are derived from.
+func NewRateLimitedRecorder(recorder events.Recorder, qps float32, burst int) events.Recorder {
+	return &rateLimitedRecorder{
+		recorder: recorder,
+		limiter: &eventRateLimiter{
+			qpsPerReason:   defaultEventQPSPerReason,
+			burstPerReason: burst,
+			globalLimiter:  flowcontrol.NewTokenBucketRateLimiter(qps, burst),
+			reasonLimiters: map[string]flowcontrol.RateLimiter{},
+		},
+	}
+}
+
+// NewDefaultRateLimitedRecorder returns a rate limited recorder with the default rates.
+func NewDefaultRateLimitedRecorder(recorder events.Recorder) events.Recorder {
+	return NewRateLimitedRecorder(recorder, defaultEventQPS, defaultEventBurst)
+}
+
+func (r *rateLimitedRecorder) Event(reason, message string) {
+	if !r.limiter.accept(reason) {
+		return
+	}
+	r.recorder.Event(reason, message)
+}
+
+func (r *rateLimitedRecorder) Eventf(reason, messageFmt string, args ...interface{}) {
+	if !r.limiter.accept(reason) {
+		return
+	}
+	r.recorder.Eventf(reason, messageFmt, args...)
+}
+
+func (r *rateLimitedRecorder) Warning(reason, message string) {
+	if !r.limiter.accept(reason) {
+		return
+	}
+	r.recorder.Warning(reason, message)
+}
+
+func (r *rateLimitedRecorder) Warningf(reason, messageFmt string, args ...interface{}) {
+	if !r.limiter.accept(reason) {
+		return
+	}
+	r.recorder.Warningf(reason, messageFmt, args...)
+}
+
+func (r *rateLimitedRecorder) ForComponent(componentName string) events.Recorder {
+	return &rateLimitedRecorder{recorder: r.recorder.ForComponent(componentName), limiter: r.limiter}
+}
+
+func (r *rateLimitedRecorder) WithComponentSuffix(componentNameSuffix string) events.Recorder {
+	return &rateLimitedRecorder{recorder: r.recorder.WithComponentSuffix(componentNameSuffix), limiter: r.limiter}
+}
+
+func (r *rateLimitedRecorder) WithContext(ctx context.Context) events.Recorder {
+	return &rateLimitedRecorder{recorder: r.recorder.WithContext(ctx), limiter: r.limiter}
+}
+
+func (r *rateLimitedRecorder) ComponentName() string {
+	return r.recorder.ComponentName()
+}
+
+func (r *rateLimitedRecorder) Shutdown() {
+	r.recorder.Shutdown()
+}
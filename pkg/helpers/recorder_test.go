@@ -0,0 +1,83 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+// countingRecorder counts the events that pass the rate limiter.
+type countingRecorder struct {
+	count int
+}
+
+func (r *countingRecorder) Event(reason, message string)                         { r.count++ }
+func (r *countingRecorder) Eventf(reason, messageFmt string, a ...interface{})   { r.count++ }
+func (r *countingRecorder) Warning(reason, message string)                       { r.count++ }
+func (r *countingRecorder) Warningf(reason, messageFmt string, a ...interface{}) { r.count++ }
+func (r *countingRecorder) ForComponent(componentName string) events.Recorder    { return r }
+func (r *countingRecorder) WithComponentSuffix(suffix string) events.Recorder    { return r }
+func (r *countingRecorder) WithContext(ctx context.Context) events.Recorder      { return r }
+func (r *countingRecorder) ComponentName() string                                { return "test" }
+func (r *countingRecorder) Shutdown()                                            {}
+
+func TestRateLimitedRecorder(t *testing.T) {
+	cases := []struct {
+		name          string
+		qps           float32
+		burst         int
+		emit          func(recorder events.Recorder)
+		expectedCount int
+	}{
+		{
+			name:  "a burst of events with a single reason is bounded by the burst size",
+			qps:   10,
+			burst: 5,
+			emit: func(recorder events.Recorder) {
+				for i := 0; i < 100; i++ {
+					recorder.Event("ManagedClusterDenied", "denied")
+				}
+			},
+			expectedCount: 5,
+		},
+		{
+			name:  "a burst of events with multiple reasons is bounded by the global burst size",
+			qps:   10,
+			burst: 5,
+			emit: func(recorder events.Recorder) {
+				for i := 0; i < 50; i++ {
+					recorder.Eventf("ManagedClusterDenied", "denied %d", i)
+					recorder.Warningf("CSRDenied", "denied %d", i)
+				}
+			},
+			expectedCount: 5,
+		},
+		{
+			name:  "derived recorders share the limits",
+			qps:   10,
+			burst: 5,
+			emit: func(recorder events.Recorder) {
+				derived := recorder.WithComponentSuffix("webhook")
+				for i := 0; i < 50; i++ {
+					recorder.Event("ManagedClusterDenied", "denied")
+					derived.Event("ManagedClusterDenied", "denied")
+				}
+			},
+			expectedCount: 5,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			recorder := &countingRecorder{}
+			c.emit(NewRateLimitedRecorder(recorder, c.qps, c.burst))
+			if recorder.count > c.expectedCount {
+				t.Errorf("expected at most %d events but got: %d", c.expectedCount, recorder.count)
+			}
+			if recorder.count == 0 {
+				t.Errorf("expected some events to pass the rate limiter but got none")
+			}
+		})
+	}
+}
@@ -59,6 +59,16 @@ func NewFakeSyncContext(t *testing.T, clusterName string) *FakeSyncContext {
 	}
 }
 
+// NewFakeSyncContextWithRecorder works like NewFakeSyncContext but records events with the
+// given recorder, so tests can assert the events emitted during sync.
+func NewFakeSyncContextWithRecorder(clusterName string, recorder events.Recorder) *FakeSyncContext {
+	return &FakeSyncContext{
+		spokeName: clusterName,
+		recorder:  recorder,
+		queue:     workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+	}
+}
+
 func NewManagedCluster() *clusterv1.ManagedCluster {
 	return &clusterv1.ManagedCluster{
 		ObjectMeta: metav1.ObjectMeta{
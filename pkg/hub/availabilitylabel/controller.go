@@ -0,0 +1,108 @@
+package availabilitylabel
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+// AvailableLabel mirrors the available condition of a managed cluster, so available
+// clusters can be selected with a label selector, conditions are not selectable. The
+// label is "true" while the available condition is true and "false" otherwise. It lives
+// under the reserved label domain, the webhook only lets the users listed with
+// --reserved-label-allowed-users modify it, the hub controller identity among them.
+const AvailableLabel = "cluster.open-cluster-management.io/available"
+
+// availabilityLabelController keeps the available label of a managed cluster in sync
+// with its available condition, so a label selector on the available label lists
+// exactly the available clusters.
+type availabilityLabelController struct {
+	clusterClient clientset.Interface
+	clusterLister clusterlisterv1.ManagedClusterLister
+	eventRecorder events.Recorder
+}
+
+// NewAvailabilityLabelController creates a controller on the hub that mirrors the
+// available condition of ManagedClusters into the available label.
+func NewAvailabilityLabelController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &availabilityLabelController{
+		clusterClient: clusterClient,
+		clusterLister: clusterInformer.Lister(),
+		eventRecorder: recorder.WithComponentSuffix("availability-label-controller"),
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithSync(c.sync).
+		ToController("AvailabilityLabelController", recorder)
+}
+
+func (c *availabilityLabelController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Reconciling availability label of ManagedCluster %s", managedClusterName)
+
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	availableCondition := meta.FindStatusCondition(managedCluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable)
+	observedValue, observed := managedCluster.Labels[AvailableLabel]
+
+	// the available condition is not set, the cluster has not reported its availability,
+	// remove a stale label so the cluster is not selected on outdated information
+	if availableCondition == nil {
+		if !observed {
+			return nil
+		}
+		managedCluster = managedCluster.DeepCopy()
+		delete(managedCluster.Labels, AvailableLabel)
+		if _, err := c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, managedCluster, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		c.eventRecorder.Eventf("ManagedClusterAvailableLabelRemoved",
+			"available label of managed cluster %s is removed, the cluster has no available condition", managedClusterName)
+		return nil
+	}
+
+	// an unknown availability does not select either, only a condition with status true
+	// marks the cluster available
+	desiredValue := "false"
+	if availableCondition.Status == metav1.ConditionTrue {
+		desiredValue = "true"
+	}
+	if observed && observedValue == desiredValue {
+		return nil
+	}
+
+	managedCluster = managedCluster.DeepCopy()
+	if managedCluster.Labels == nil {
+		managedCluster.Labels = map[string]string{}
+	}
+	managedCluster.Labels[AvailableLabel] = desiredValue
+	if _, err := c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, managedCluster, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	c.eventRecorder.Eventf("ManagedClusterAvailableLabelUpdated",
+		"available label of managed cluster %s is set to %q following its available condition", managedClusterName, desiredValue)
+	return nil
+}
@@ -0,0 +1,127 @@
+package availabilitylabel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clienttesting "k8s.io/client-go/testing"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSyncAvailabilityLabel(t *testing.T) {
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:    "label an available cluster",
+			cluster: testinghelpers.NewAvailableManagedCluster(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				if managedCluster.Labels[AvailableLabel] != "true" {
+					t.Errorf("expected the available label to be \"true\" but got: %v", managedCluster.Labels)
+				}
+			},
+		},
+		{
+			name:    "label a cluster with an unknown availability",
+			cluster: newUnavailableCluster(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				if managedCluster.Labels[AvailableLabel] != "false" {
+					t.Errorf("expected the available label to be \"false\" but got: %v", managedCluster.Labels)
+				}
+			},
+		},
+		{
+			name: "flip the label when the cluster becomes unavailable",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := newUnavailableCluster()
+				cluster.Labels = map[string]string{AvailableLabel: "true"}
+				return cluster
+			}(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				if managedCluster.Labels[AvailableLabel] != "false" {
+					t.Errorf("expected the available label to be \"false\" but got: %v", managedCluster.Labels)
+				}
+			},
+		},
+		{
+			name: "the label is in sync",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := testinghelpers.NewAvailableManagedCluster()
+				cluster.Labels = map[string]string{AvailableLabel: "true"}
+				return cluster
+			}(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "remove a stale label from a cluster without the available condition",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := testinghelpers.NewAcceptedManagedCluster()
+				cluster.Labels = map[string]string{AvailableLabel: "true"}
+				return cluster
+			}(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				if _, ok := managedCluster.Labels[AvailableLabel]; ok {
+					t.Errorf("expected the available label to be removed but got: %v", managedCluster.Labels)
+				}
+			},
+		},
+		{
+			name:    "a cluster without the available condition and without the label",
+			cluster: testinghelpers.NewAcceptedManagedCluster(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			informerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 5*time.Minute)
+			informerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster)
+
+			ctrl := availabilityLabelController{
+				clusterClient: clusterClient,
+				clusterLister: informerFactory.Cluster().V1().ManagedClusters().Lister(),
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, c.cluster.Name))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func newUnavailableCluster() *clusterv1.ManagedCluster {
+	transitionTime := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	cluster.Status.Conditions = append(cluster.Status.Conditions, testinghelpers.NewManagedClusterCondition(
+		clusterv1.ManagedClusterConditionAvailable,
+		"Unknown",
+		"ManagedClusterLeaseUpdateStopped",
+		"Registration agent stopped updating its lease.",
+		&transitionTime,
+	))
+	return cluster
+}
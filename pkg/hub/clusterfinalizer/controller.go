@@ -0,0 +1,127 @@
+package clusterfinalizer
+
+import (
+	"context"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	v1 "open-cluster-management.io/api/cluster/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// cleanupCompleteAnnotation signals that the external cleanup for a ManagedCluster is
+	// done and the cleanup finalizer can be removed.
+	cleanupCompleteAnnotation = "cluster.open-cluster-management.io/cleanup-complete"
+)
+
+// DefaultCleanupFinalizer is the default finalizer placed on accepted ManagedClusters so
+// external cleanup can run before the object is removed.
+const DefaultCleanupFinalizer = "cluster.open-cluster-management.io/external-cleanup"
+
+// clusterFinalizerController manages a cleanup finalizer on ManagedClusters. The finalizer
+// is added to accepted clusters and removed once the cleanup-complete annotation is
+// observed. Only the configured finalizer is managed, so the cleanup ordering of other
+// finalizers, e.g. the api-resource-cleanup finalizer, is not affected.
+type clusterFinalizerController struct {
+	clusterClient clientset.Interface
+	clusterLister clusterv1listers.ManagedClusterLister
+	finalizer     string
+	eventRecorder events.Recorder
+}
+
+// NewClusterFinalizerController creates a controller on the hub that manages the given
+// cleanup finalizer on ManagedClusters.
+func NewClusterFinalizerController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	finalizer string,
+	recorder events.Recorder) factory.Controller {
+	c := &clusterFinalizerController{
+		clusterClient: clusterClient,
+		clusterLister: clusterInformer.Lister(),
+		finalizer:     finalizer,
+		eventRecorder: recorder.WithComponentSuffix("cluster-finalizer-controller"),
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ClusterFinalizerController", recorder)
+}
+
+func (c *clusterFinalizerController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	if len(c.finalizer) == 0 {
+		return nil
+	}
+
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Reconciling cleanup finalizer of ManagedCluster %s", managedClusterName)
+
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	hasFinalizer := false
+	for i := range managedCluster.Finalizers {
+		if managedCluster.Finalizers[i] == c.finalizer {
+			hasFinalizer = true
+			break
+		}
+	}
+
+	// the external cleanup reported done, remove the cleanup finalizer
+	if _, cleanupComplete := managedCluster.Annotations[cleanupCompleteAnnotation]; cleanupComplete {
+		if !hasFinalizer {
+			return nil
+		}
+		managedCluster = managedCluster.DeepCopy()
+		copiedFinalizers := []string{}
+		for i := range managedCluster.Finalizers {
+			if managedCluster.Finalizers[i] == c.finalizer {
+				continue
+			}
+			copiedFinalizers = append(copiedFinalizers, managedCluster.Finalizers[i])
+		}
+		managedCluster.Finalizers = copiedFinalizers
+		if _, err := c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, managedCluster, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		c.eventRecorder.Eventf("ManagedClusterCleanupFinalizerRemoved",
+			"finalizer %s is removed from managed cluster %s, its cleanup is complete", c.finalizer, managedClusterName)
+		return nil
+	}
+
+	// the finalizer is only added to accepted clusters that are not deleting
+	if hasFinalizer || !managedCluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if !meta.IsStatusConditionTrue(managedCluster.Status.Conditions, v1.ManagedClusterConditionHubAccepted) {
+		return nil
+	}
+
+	managedCluster = managedCluster.DeepCopy()
+	managedCluster.Finalizers = append(managedCluster.Finalizers, c.finalizer)
+	if _, err := c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, managedCluster, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	c.eventRecorder.Eventf("ManagedClusterCleanupFinalizerAdded",
+		"finalizer %s is added to managed cluster %s", c.finalizer, managedClusterName)
+	return nil
+}
@@ -0,0 +1,108 @@
+package clusterfinalizer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestSyncClusterFinalizer(t *testing.T) {
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name: "cluster not found",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:    "add finalizer to an accepted cluster",
+			cluster: testinghelpers.NewAcceptedManagedCluster(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				testinghelpers.AssertFinalizers(t, managedCluster, []string{"cluster.open-cluster-management.io/api-resource-cleanup", DefaultCleanupFinalizer})
+			},
+		},
+		{
+			name:    "do not add finalizer to a cluster that is not accepted",
+			cluster: testinghelpers.NewManagedCluster(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:    "do not add finalizer to a deleting cluster",
+			cluster: testinghelpers.NewDeletingManagedCluster(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:    "keep finalizer while cleanup is not complete",
+			cluster: newClusterWithFinalizer(testinghelpers.NewAcceptedManagedCluster(), false),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:    "remove finalizer once cleanup is complete",
+			cluster: newClusterWithFinalizer(testinghelpers.NewAcceptedManagedCluster(), true),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				testinghelpers.AssertFinalizers(t, managedCluster, []string{"cluster.open-cluster-management.io/api-resource-cleanup"})
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			startingObjects := []runtime.Object{}
+			if c.cluster != nil {
+				startingObjects = append(startingObjects, c.cluster)
+			}
+			clusterClient := clusterfake.NewSimpleClientset(startingObjects...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if c.cluster != nil {
+				clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster)
+			}
+
+			ctrl := clusterFinalizerController{
+				clusterClient: clusterClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				finalizer:     DefaultCleanupFinalizer,
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func newClusterWithFinalizer(cluster *clusterv1.ManagedCluster, cleanupComplete bool) *clusterv1.ManagedCluster {
+	cluster.Finalizers = append(cluster.Finalizers, DefaultCleanupFinalizer)
+	if cleanupComplete {
+		cluster.Annotations = map[string]string{
+			cleanupCompleteAnnotation: "true",
+		}
+	}
+	return cluster
+}
@@ -0,0 +1,125 @@
+package clusternamespace
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	"open-cluster-management.io/registration/pkg/hub/health"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	clusterSetLabel = "cluster.open-cluster-management.io/clusterset"
+
+	// labelAnnotationPrefix prefixes the annotations that mirror ManagedCluster labels
+	// onto the cluster namespace. Only annotations with this prefix are managed by the
+	// controller; all other annotations on the namespace are left untouched.
+	labelAnnotationPrefix = "managedcluster-label.open-cluster-management.io/"
+)
+
+// DefaultSyncedLabelKeys is the default set of ManagedCluster label keys mirrored
+// to the cluster namespace as annotations.
+var DefaultSyncedLabelKeys = []string{clusterSetLabel}
+
+// clusterNamespaceController mirrors selected ManagedCluster labels onto the
+// per-cluster namespace as prefixed annotations.
+type clusterNamespaceController struct {
+	kubeClient      kubernetes.Interface
+	clusterLister   clusterv1listers.ManagedClusterLister
+	namespaceLister corev1listers.NamespaceLister
+	labelKeys       []string
+}
+
+// NewClusterNamespaceController creates a controller on the hub that keeps the
+// annotations of the per-cluster namespace in sync with the given ManagedCluster
+// label keys.
+func NewClusterNamespaceController(
+	kubeClient kubernetes.Interface,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	namespaceInformer corev1informers.NamespaceInformer,
+	labelKeys []string,
+	healthRecorder *health.ControllerHealth,
+	recorder events.Recorder) factory.Controller {
+	c := &clusterNamespaceController{
+		kubeClient:      kubeClient,
+		clusterLister:   clusterInformer.Lister(),
+		namespaceLister: namespaceInformer.Lister(),
+		labelKeys:       labelKeys,
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer(), namespaceInformer.Informer()).
+		WithSync(health.MonitorSync(healthRecorder, c.sync)).
+		ToController("ClusterNamespaceController", recorder)
+}
+
+func (c *clusterNamespaceController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Reconciling namespace annotations for ManagedCluster %s", managedClusterName)
+
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	namespace, err := c.namespaceLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// The cluster namespace has not been created yet; the controller will be
+		// triggered again once it shows up.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	desired := map[string]string{}
+	for _, key := range c.labelKeys {
+		if value, ok := managedCluster.Labels[key]; ok {
+			desired[labelAnnotationPrefix+key] = value
+		}
+	}
+
+	annotations := map[string]string{}
+	for key, value := range namespace.Annotations {
+		// Only the prefixed annotations are managed by this controller.
+		if strings.HasPrefix(key, labelAnnotationPrefix) {
+			continue
+		}
+		annotations[key] = value
+	}
+	for key, value := range desired {
+		annotations[key] = value
+	}
+
+	if reflect.DeepEqual(namespace.Annotations, annotations) {
+		return nil
+	}
+	if len(namespace.Annotations) == 0 && len(annotations) == 0 {
+		return nil
+	}
+
+	namespace = namespace.DeepCopy()
+	namespace.Annotations = annotations
+	_, err = c.kubeClient.CoreV1().Namespaces().Update(ctx, namespace, metav1.UpdateOptions{})
+	return err
+}
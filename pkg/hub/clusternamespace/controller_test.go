@@ -0,0 +1,165 @@
+package clusternamespace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestSyncClusterNamespace(t *testing.T) {
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		namespace       *corev1.Namespace
+		labelKeys       []string
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:      "cluster not found",
+			namespace: newNamespace(nil),
+			labelKeys: []string{"region"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:      "namespace not created yet",
+			cluster:   newManagedClusterWithLabels(map[string]string{"region": "us-east-1"}),
+			labelKeys: []string{"region"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:      "add annotation for a new label",
+			cluster:   newManagedClusterWithLabels(map[string]string{"region": "us-east-1"}),
+			namespace: newNamespace(nil),
+			labelKeys: []string{"region"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				namespace := actions[0].(clienttesting.UpdateActionImpl).Object.(*corev1.Namespace)
+				assertAnnotations(t, namespace, map[string]string{
+					labelAnnotationPrefix + "region": "us-east-1",
+				})
+			},
+		},
+		{
+			name:    "update annotation for a modified label",
+			cluster: newManagedClusterWithLabels(map[string]string{"region": "us-west-2"}),
+			namespace: newNamespace(map[string]string{
+				labelAnnotationPrefix + "region": "us-east-1",
+			}),
+			labelKeys: []string{"region"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				namespace := actions[0].(clienttesting.UpdateActionImpl).Object.(*corev1.Namespace)
+				assertAnnotations(t, namespace, map[string]string{
+					labelAnnotationPrefix + "region": "us-west-2",
+				})
+			},
+		},
+		{
+			name:    "remove annotation for a removed label",
+			cluster: newManagedClusterWithLabels(nil),
+			namespace: newNamespace(map[string]string{
+				labelAnnotationPrefix + "region": "us-east-1",
+				"unmanaged":                      "annotation",
+			}),
+			labelKeys: []string{"region"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				namespace := actions[0].(clienttesting.UpdateActionImpl).Object.(*corev1.Namespace)
+				assertAnnotations(t, namespace, map[string]string{
+					"unmanaged": "annotation",
+				})
+			},
+		},
+		{
+			name:    "ignore labels that are not configured",
+			cluster: newManagedClusterWithLabels(map[string]string{"region": "us-east-1", "env": "dev"}),
+			namespace: newNamespace(map[string]string{
+				labelAnnotationPrefix + "region": "us-east-1",
+			}),
+			labelKeys: []string{"region"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterObjects := []runtime.Object{}
+			if c.cluster != nil {
+				clusterObjects = append(clusterObjects, c.cluster)
+			}
+			kubeObjects := []runtime.Object{}
+			if c.namespace != nil {
+				kubeObjects = append(kubeObjects, c.namespace)
+			}
+
+			clusterClient := clusterfake.NewSimpleClientset(clusterObjects...)
+			kubeClient := kubefake.NewSimpleClientset(kubeObjects...)
+
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			if c.cluster != nil {
+				clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster)
+			}
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+			if c.namespace != nil {
+				kubeInformerFactory.Core().V1().Namespaces().Informer().GetStore().Add(c.namespace)
+			}
+
+			ctrl := clusterNamespaceController{
+				kubeClient:      kubeClient,
+				clusterLister:   clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				namespaceLister: kubeInformerFactory.Core().V1().Namespaces().Lister(),
+				labelKeys:       c.labelKeys,
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func newManagedClusterWithLabels(labels map[string]string) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewManagedCluster()
+	cluster.Labels = labels
+	return cluster
+}
+
+func newNamespace(annotations map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        testinghelpers.TestManagedClusterName,
+			Annotations: annotations,
+		},
+	}
+}
+
+func assertAnnotations(t *testing.T, namespace *corev1.Namespace, expected map[string]string) {
+	if len(namespace.Annotations) != len(expected) {
+		t.Errorf("expected annotations %v but got: %v", expected, namespace.Annotations)
+	}
+	for key, value := range expected {
+		if namespace.Annotations[key] != value {
+			t.Errorf("expected annotations %v but got: %v", expected, namespace.Annotations)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package clusterrole
+
+import (
+	"context"
+	"sort"
+
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	rbacv1informers "k8s.io/client-go/informers/rbac/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// fleetReaderClusterRole is the name of the generated clusterrole granting read access to
+// the registration objects of all managed clusters.
+const fleetReaderClusterRole = "open-cluster-management:managedclusters:fleet-reader"
+
+// AggregationLabel is the label set with the value "true" on the generated fleet reader
+// clusterrole, so operators can aggregate it into their own clusterroles through an
+// aggregation rule selecting this label. It is exposed as a command line flag.
+var AggregationLabel = "rbac.open-cluster-management.io/aggregate-to-fleet-reader"
+
+// clusterRoleAggregationController maintains a generated clusterrole reflecting the
+// current set of cluster namespaces, so a single grant of that role gives read access to
+// the registration objects of the whole fleet as clusters come and go.
+type clusterRoleAggregationController struct {
+	kubeClient    kubernetes.Interface
+	clusterLister clusterv1listers.ManagedClusterLister
+}
+
+// NewClusterRoleAggregationController creates a controller on the hub that keeps the
+// fleet reader clusterrole in sync with the existing managed clusters.
+func NewClusterRoleAggregationController(
+	kubeClient kubernetes.Interface,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	clusterRoleInformer rbacv1informers.ClusterRoleInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &clusterRoleAggregationController{
+		kubeClient:    kubeClient,
+		clusterLister: clusterInformer.Lister(),
+	}
+	return factory.New().
+		WithFilteredEventsInformers(
+			func(obj interface{}) bool {
+				metaObj := obj.(metav1.Object)
+				return metaObj.GetName() == fleetReaderClusterRole
+			}, clusterRoleInformer.Informer()).
+		WithInformers(clusterInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ClusterRoleAggregationController", recorder)
+}
+
+func (c *clusterRoleAggregationController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusters, err := c.clusterLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	// without any managed cluster the fleet reader clusterrole grants nothing, clean it up
+	if len(managedClusters) == 0 {
+		err := c.kubeClient.RbacV1().ClusterRoles().Delete(ctx, fleetReaderClusterRole, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	clusterNames := make([]string, 0, len(managedClusters))
+	for _, managedCluster := range managedClusters {
+		clusterNames = append(clusterNames, managedCluster.Name)
+	}
+	sort.Strings(clusterNames)
+
+	_, _, err = resourceapply.ApplyClusterRole(ctx, c.kubeClient.RbacV1(), syncCtx.Recorder(), fleetReaderRole(clusterNames))
+	return err
+}
+
+// fleetReaderRole returns the fleet reader clusterrole for the given cluster names. The
+// namespace rule is restricted to the cluster namespaces by resource names; the rules on
+// the cluster scoped and namespaced registration resources are fleet-wide by nature.
+func fleetReaderRole(clusterNames []string) *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fleetReaderClusterRole,
+			Labels: map[string]string{AggregationLabel: "true"},
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"cluster.open-cluster-management.io"},
+				Resources: []string{"managedclusters"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+			{
+				APIGroups: []string{"addon.open-cluster-management.io"},
+				Resources: []string{"managedclusteraddons"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+			{
+				APIGroups:     []string{""},
+				Resources:     []string{"namespaces"},
+				ResourceNames: clusterNames,
+				Verbs:         []string{"get"},
+			},
+		},
+	}
+}
@@ -0,0 +1,131 @@
+package clusterrole
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestSyncClusterRoleAggregation(t *testing.T) {
+	cases := []struct {
+		name            string
+		clusters        []runtime.Object
+		clusterroles    []runtime.Object
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:     "create the fleet reader clusterrole",
+			clusters: []runtime.Object{testinghelpers.NewManagedCluster()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "create")
+				clusterRole := (actions[1].(clienttesting.CreateActionImpl).Object).(*rbacv1.ClusterRole)
+				if clusterRole.Labels[AggregationLabel] != "true" {
+					t.Errorf("expected the aggregation label on the fleet reader clusterrole, but got %v", clusterRole.Labels)
+				}
+				assertNamespaceResourceNames(t, clusterRole, []string{testinghelpers.TestManagedClusterName})
+			},
+		},
+		{
+			name:     "update the fleet reader clusterrole when a cluster is added",
+			clusters: []runtime.Object{testinghelpers.NewManagedCluster(), newManagedCluster("cluster2")},
+			clusterroles: []runtime.Object{
+				fleetReaderRole([]string{testinghelpers.TestManagedClusterName}),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "update")
+				clusterRole := (actions[1].(clienttesting.UpdateActionImpl).Object).(*rbacv1.ClusterRole)
+				assertNamespaceResourceNames(t, clusterRole, []string{"cluster2", testinghelpers.TestManagedClusterName})
+			},
+		},
+		{
+			name:     "update the fleet reader clusterrole when a cluster is removed",
+			clusters: []runtime.Object{testinghelpers.NewManagedCluster()},
+			clusterroles: []runtime.Object{
+				fleetReaderRole([]string{"cluster2", testinghelpers.TestManagedClusterName}),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "update")
+				clusterRole := (actions[1].(clienttesting.UpdateActionImpl).Object).(*rbacv1.ClusterRole)
+				assertNamespaceResourceNames(t, clusterRole, []string{testinghelpers.TestManagedClusterName})
+			},
+		},
+		{
+			name:     "leave an up to date fleet reader clusterrole alone",
+			clusters: []runtime.Object{testinghelpers.NewManagedCluster()},
+			clusterroles: []runtime.Object{
+				fleetReaderRole([]string{testinghelpers.TestManagedClusterName}),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get")
+			},
+		},
+		{
+			name: "delete the fleet reader clusterrole without clusters",
+			clusterroles: []runtime.Object{
+				fleetReaderRole([]string{testinghelpers.TestManagedClusterName}),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "delete")
+				if actions[0].(clienttesting.DeleteActionImpl).Name != fleetReaderClusterRole {
+					t.Errorf("expected the fleet reader clusterrole to be deleted, but failed")
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(c.clusterroles...)
+
+			clusterClient := clusterfake.NewSimpleClientset(c.clusters...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			for _, cluster := range c.clusters {
+				clusterStore.Add(cluster)
+			}
+
+			ctrl := &clusterRoleAggregationController{
+				kubeClient:    kubeClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+			}
+
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "clusterroleaggregation"))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func assertNamespaceResourceNames(t *testing.T, clusterRole *rbacv1.ClusterRole, expected []string) {
+	for _, rule := range clusterRole.Rules {
+		for _, resource := range rule.Resources {
+			if resource != "namespaces" {
+				continue
+			}
+			if !reflect.DeepEqual(rule.ResourceNames, expected) {
+				t.Errorf("expected namespace resource names %v, but got %v", expected, rule.ResourceNames)
+			}
+			return
+		}
+	}
+	t.Errorf("no namespace rule found in clusterrole %q", clusterRole.Name)
+}
+
+func newManagedCluster(name string) runtime.Object {
+	cluster := testinghelpers.NewManagedCluster()
+	cluster.Name = name
+	return cluster
+}
@@ -2,10 +2,12 @@ package csr
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -22,25 +24,65 @@ import (
 	certificateslisters "k8s.io/client-go/listers/certificates/v1"
 	"k8s.io/klog/v2"
 
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/hub/health"
 	"open-cluster-management.io/registration/pkg/hub/user"
 )
 
 const (
 	spokeClusterNameLabel = "open-cluster-management.io/cluster-name"
+
+	// clientCertSignerAnnotation records the signer name that issued the current client
+	// certificate of a managed cluster.
+	clientCertSignerAnnotation = "register.open-cluster-management.io/client-cert-signer-name"
+
+	// clientCertCAFingerprintAnnotation records the hex encoded SHA-256 fingerprint of the
+	// CA certificate that issued the current client certificate of a managed cluster.
+	clientCertCAFingerprintAnnotation = "register.open-cluster-management.io/client-cert-ca-fingerprint"
+
+	// clientCertExpiryAnnotation records the RFC3339 formatted expiration time of the
+	// current client certificate of a managed cluster.
+	clientCertExpiryAnnotation = "register.open-cluster-management.io/client-cert-expiry"
+
+	// holdApprovalAnnotation on a ManagedCluster stops the controller from approving any
+	// csr of that cluster while it is set to "true", for example while the cluster is
+	// quarantined. Clearing the annotation resumes the normal approval.
+	holdApprovalAnnotation = "register.open-cluster-management.io/hold-approval"
 )
 
+// ExpectedCSRUsages, when set, restricts auto approval to csrs whose requested key usages
+// exactly match this set, e.g. digital signature, key encipherment and client auth. A csr
+// with extra or missing usages is left pending for manual review. It is exposed as a
+// command line flag.
+var ExpectedCSRUsages []string
+
+// RequiredCSROrganizations, when set, restricts auto approval to csrs whose certificate
+// request carries every listed organization in addition to the standard ones, so only
+// agents configured with these groups can onboard. A csr missing a required organization
+// is left pending for manual review. It is exposed as a command line flag.
+var RequiredCSROrganizations []string
+
+// CSRMaxPendingAge, when positive, makes the controller deny a managed cluster csr it
+// declines to approve once the csr has been pending longer than this age, instead of
+// leaving it pending forever, so the agent retries with a fresh request. A cluster whose
+// approval is held is exempt, the hold expresses an explicit wait. It is exposed as a
+// command line flag.
+var CSRMaxPendingAge time.Duration
+
 // csrApprovingController auto approve the renewal CertificateSigningRequests for an accepted spoke cluster on the hub.
 type csrApprovingController struct {
 	kubeClient    kubernetes.Interface
+	clusterClient clientset.Interface
 	csrLister     certificateslisters.CertificateSigningRequestLister
 	eventRecorder events.Recorder
 }
 
 // NewCSRApprovingController creates a new csr approving controller
-func NewCSRApprovingController(kubeClient kubernetes.Interface, csrInformer certificatesinformers.CertificateSigningRequestInformer, recorder events.Recorder) factory.Controller {
+func NewCSRApprovingController(kubeClient kubernetes.Interface, clusterClient clientset.Interface, csrInformer certificatesinformers.CertificateSigningRequestInformer, healthRecorder *health.ControllerHealth, recorder events.Recorder) factory.Controller {
 	c := &csrApprovingController{
 		kubeClient:    kubeClient,
+		clusterClient: clusterClient,
 		csrLister:     csrInformer.Lister(),
 		eventRecorder: recorder.WithComponentSuffix("csr-approving-controller"),
 	}
@@ -49,7 +91,7 @@ func NewCSRApprovingController(kubeClient kubernetes.Interface, csrInformer cert
 			accessor, _ := meta.Accessor(obj)
 			return accessor.GetName()
 		}, csrInformer.Informer()).
-		WithSync(c.sync).
+		WithSync(health.MonitorSync(healthRecorder, c.sync)).
 		ToController("CSRApprovingController", recorder)
 }
 
@@ -65,9 +107,12 @@ func (c *csrApprovingController) sync(ctx context.Context, syncCtx factory.SyncC
 	}
 
 	csr = csr.DeepCopy()
-	// Current csr is in terminal state, do nothing.
+	// Current csr is in terminal state, record the issued certificate and do nothing else.
 	if helpers.IsCSRInTerminalState(&csr.Status) {
-		return nil
+		if !isSpokeClusterClientCertRenewal(csr) {
+			return nil
+		}
+		return c.recordIssuedCertificate(ctx, csr)
 	}
 
 	// Check whether current csr is a renewal spoker cluster csr.
@@ -77,12 +122,57 @@ func (c *csrApprovingController) sync(ctx context.Context, syncCtx factory.SyncC
 		return nil
 	}
 
+	// Leave the csr pending while approval of the cluster is held by an operator. The
+	// hold is checked before any of the auto-deny paths below, a held cluster expresses
+	// an explicit wait and its csr must never be auto denied.
+	held, err := c.approvalHeld(ctx, csr)
+	if err != nil {
+		return err
+	}
+	if held {
+		klog.V(4).Infof("Managed cluster csr %q is not approved because approval of the cluster is held", csr.Name)
+		c.eventRecorder.Eventf("ManagedClusterCSRApprovalHeld",
+			"spoke cluster csr %q is left pending, approval is held by the %s annotation of the managed cluster", csr.Name, holdApprovalAnnotation)
+		return nil
+	}
+
+	// Leave the csr pending if its requested key usages do not match the expected set, a
+	// compromised agent must not obtain a certificate with broader usages than needed.
+	if !expectedUsagesMatch(csr) {
+		if denied, err := c.denyStalePendingCSR(ctx, csr, "the requested usages do not match the expected usages"); denied || err != nil {
+			return err
+		}
+		klog.Warningf("Managed cluster csr %q is not approved, the requested usages %v do not match the expected usages %v",
+			csr.Name, csr.Spec.Usages, ExpectedCSRUsages)
+		c.eventRecorder.Eventf("ManagedClusterCSRUsagesMismatched",
+			"spoke cluster csr %q is left pending, the requested usages %v do not match the expected usages %v",
+			csr.Name, csr.Spec.Usages, ExpectedCSRUsages)
+		return nil
+	}
+
+	// Leave the csr pending if its certificate request misses a required organization,
+	// only agents configured with the required groups may onboard.
+	if !requiredOrganizationsPresent(csr) {
+		if denied, err := c.denyStalePendingCSR(ctx, csr, "the certificate request does not carry all required organizations"); denied || err != nil {
+			return err
+		}
+		klog.Warningf("Managed cluster csr %q is not approved, its certificate request does not carry all required organizations %v",
+			csr.Name, RequiredCSROrganizations)
+		c.eventRecorder.Eventf("ManagedClusterCSROrganizationsMissing",
+			"spoke cluster csr %q is left pending, its certificate request does not carry all required organizations %v",
+			csr.Name, RequiredCSROrganizations)
+		return nil
+	}
+
 	// Authorize whether the current spoke agent has been authorized to renew its csr.
 	allowed, err := c.authorize(ctx, csr)
 	if err != nil {
 		return err
 	}
 	if !allowed {
+		if denied, err := c.denyStalePendingCSR(ctx, csr, "the agent is not authorized to renew its client certificate"); denied || err != nil {
+			return err
+		}
 		//TODO find a way to avoid looking at this CSR again.
 		klog.V(4).Infof("Managed cluster csr %q cannont be auto approved due to subject access review was not approved", csr.Name)
 		return nil
@@ -103,6 +193,180 @@ func (c *csrApprovingController) sync(ctx context.Context, syncCtx factory.SyncC
 	return nil
 }
 
+// recordIssuedCertificate records the signer name, the SHA-256 fingerprint of the issuing
+// CA and the expiration time of the certificate issued for an approved spoke cluster csr in
+// the annotations of the ManagedCluster, so it can be audited which CA the current
+// credential of a cluster chains to and when it expires. The fingerprint is only recorded
+// if the issued certificate chain includes the CA certificate.
+func (c *csrApprovingController) recordIssuedCertificate(ctx context.Context, csr *certificatesv1.CertificateSigningRequest) error {
+	if len(csr.Status.Certificate) == 0 {
+		return nil
+	}
+
+	approved := false
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type == certificatesv1.CertificateApproved {
+			approved = true
+			break
+		}
+	}
+	if !approved {
+		return nil
+	}
+
+	annotations := map[string]string{
+		clientCertSignerAnnotation: csr.Spec.SignerName,
+	}
+	if fingerprint := issuingCAFingerprint(csr.Status.Certificate); len(fingerprint) > 0 {
+		annotations[clientCertCAFingerprintAnnotation] = fingerprint
+	}
+	if expiry := clientCertExpiry(csr.Status.Certificate); len(expiry) > 0 {
+		annotations[clientCertExpiryAnnotation] = expiry
+	}
+
+	clusterName := csr.Labels[spokeClusterNameLabel]
+	managedCluster, err := c.clusterClient.ClusterV1().ManagedClusters().Get(ctx, clusterName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	managedCluster = managedCluster.DeepCopy()
+	if managedCluster.Annotations == nil {
+		managedCluster.Annotations = map[string]string{}
+	}
+	updated := false
+	for key, value := range annotations {
+		if managedCluster.Annotations[key] != value {
+			managedCluster.Annotations[key] = value
+			updated = true
+		}
+	}
+	if !updated {
+		return nil
+	}
+
+	if _, err := c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, managedCluster, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	c.eventRecorder.Eventf("ManagedClusterClientCertRecorded",
+		"signer name, issuing CA fingerprint and expiration time of the client certificate of managed cluster %q are recorded", clusterName)
+	return nil
+}
+
+// denyStalePendingCSR denies a pending managed cluster csr the controller declined to
+// approve once it is older than the configured maximum pending age, recording the given
+// reason, so stuck csrs do not clutter the list and mask real problems. It reports
+// whether the csr was denied.
+func (c *csrApprovingController) denyStalePendingCSR(ctx context.Context, csr *certificatesv1.CertificateSigningRequest, reason string) (bool, error) {
+	if CSRMaxPendingAge <= 0 {
+		return false, nil
+	}
+	if time.Since(csr.CreationTimestamp.Time) <= CSRMaxPendingAge {
+		return false, nil
+	}
+
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+		Type:    certificatesv1.CertificateDenied,
+		Status:  corev1.ConditionTrue,
+		Reason:  "AutoDeniedByHubCSRApprovingController",
+		Message: fmt.Sprintf("Denying Managed cluster agent certificate pending longer than %s: %s.", CSRMaxPendingAge, reason),
+	})
+	if _, err := c.kubeClient.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{}); err != nil {
+		return false, err
+	}
+	c.eventRecorder.Eventf("ManagedClusterCSRAutoDenied",
+		"spoke cluster csr %q is auto denied after pending longer than %s: %s", csr.Name, CSRMaxPendingAge, reason)
+	return true, nil
+}
+
+// clientCertExpiry returns the RFC3339 formatted expiration time of the issued client
+// certificate, the first certificate in the given PEM encoded chain. An empty string is
+// returned if the chain cannot be parsed.
+func clientCertExpiry(certData []byte) string {
+	block, _ := pem.Decode(certData)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return ""
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return ""
+	}
+	return cert.NotAfter.UTC().Format(time.RFC3339)
+}
+
+// expectedUsagesMatch returns true if the requested key usages of the given csr exactly
+// match the configured expected usages. Without any configuration all usages are accepted.
+func expectedUsagesMatch(csr *certificatesv1.CertificateSigningRequest) bool {
+	if len(ExpectedCSRUsages) == 0 {
+		return true
+	}
+	requestedUsages := sets.NewString()
+	for _, usage := range csr.Spec.Usages {
+		requestedUsages.Insert(string(usage))
+	}
+	return requestedUsages.Equal(sets.NewString(ExpectedCSRUsages...))
+}
+
+// requiredOrganizationsPresent returns true if the certificate request of the given csr
+// carries every configured required organization. Without any configuration all csrs
+// pass; an unparseable request does not.
+func requiredOrganizationsPresent(csr *certificatesv1.CertificateSigningRequest) bool {
+	if len(RequiredCSROrganizations) == 0 {
+		return true
+	}
+
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return false
+	}
+	x509cr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return false
+	}
+
+	return sets.NewString(x509cr.Subject.Organization...).HasAll(RequiredCSROrganizations...)
+}
+
+// approvalHeld returns true if approval of the csrs of the managed cluster the given csr
+// belongs to is held by the hold-approval annotation.
+func (c *csrApprovingController) approvalHeld(ctx context.Context, csr *certificatesv1.CertificateSigningRequest) (bool, error) {
+	clusterName := csr.Labels[spokeClusterNameLabel]
+	managedCluster, err := c.clusterClient.ClusterV1().ManagedClusters().Get(ctx, clusterName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// the cluster does not exist yet during bootstrap, approval cannot be held
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return managedCluster.Annotations[holdApprovalAnnotation] == "true", nil
+}
+
+// issuingCAFingerprint returns the hex encoded SHA-256 fingerprint of the issuing CA
+// certificate in the given PEM encoded certificate chain. An empty string is returned if
+// the chain does not include the issuing CA certificate.
+func issuingCAFingerprint(certData []byte) string {
+	certs := []*x509.Certificate{}
+	for block, rest := pem.Decode(certData); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return ""
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) < 2 {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(certs[len(certs)-1].Raw))
+}
+
 // Using SubjectAccessReview API to check whether a spoke agent has been authorized to renew its csr,
 // a spoke agent is authorized after its spoke cluster is accepted by hub cluster admin.
 func (c *csrApprovingController) authorize(ctx context.Context, csr *certificatesv1.CertificateSigningRequest) (bool, error) {
@@ -132,9 +396,49 @@ func (c *csrApprovingController) authorize(ctx context.Context, csr *certificate
 	return sar.Status.Allowed, nil
 }
 
+// ClusterNameExtractor extracts the name of the managed cluster a client certificate
+// request belongs to from the parsed certificate request. An extractor also validates the
+// subject fields of its encoding scheme and reports false for a request it does not
+// recognize.
+type ClusterNameExtractor interface {
+	ClusterName(x509cr *x509.CertificateRequest) (string, bool)
+}
+
+// DefaultClusterNameExtractor extracts the cluster name from the per cluster organization
+// of the request subject, the scheme the spoke agent uses. It can be replaced before the
+// controller starts to support signers that encode the cluster name differently, for
+// example in a certificate extension.
+var DefaultClusterNameExtractor ClusterNameExtractor = organizationClusterNameExtractor{}
+
+// organizationClusterNameExtractor reads the cluster name from the per cluster
+// organization "system:open-cluster-management:<cluster name>" of the request subject and
+// requires the common name to identify an agent of that cluster.
+type organizationClusterNameExtractor struct{}
+
+func (organizationClusterNameExtractor) ClusterName(x509cr *x509.CertificateRequest) (string, bool) {
+	requestingOrgs := sets.NewString(x509cr.Subject.Organization...)
+	if requestingOrgs.Has(user.ManagedClustersGroup) { // optional common group for backward-compatibility
+		requestingOrgs.Delete(user.ManagedClustersGroup)
+	}
+	if requestingOrgs.Len() != 1 {
+		return "", false
+	}
+
+	perClusterOrg, _ := requestingOrgs.PopAny()
+	if !strings.HasPrefix(perClusterOrg, user.SubjectPrefix) {
+		return "", false
+	}
+
+	if !strings.HasPrefix(x509cr.Subject.CommonName, perClusterOrg) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(perClusterOrg, user.SubjectPrefix), true
+}
+
 // To check a renewal managed cluster csr, we check
 // 1. if the signer name in csr request is valid.
-// 2. if organization field and commonName field in csr request is valid.
+// 2. if the cluster name extracted from the csr request matches the cluster name label.
 // 3. if user name in csr is the same as commonName field in csr request.
 func isSpokeClusterClientCertRenewal(csr *certificatesv1.CertificateSigningRequest) bool {
 	spokeClusterName, existed := csr.Labels[spokeClusterNameLabel]
@@ -158,20 +462,8 @@ func isSpokeClusterClientCertRenewal(csr *certificatesv1.CertificateSigningReque
 		return false
 	}
 
-	requestingOrgs := sets.NewString(x509cr.Subject.Organization...)
-	if requestingOrgs.Has(user.ManagedClustersGroup) { // optional common group for backward-compatibility
-		requestingOrgs.Delete(user.ManagedClustersGroup)
-	}
-	if requestingOrgs.Len() != 1 {
-		return false
-	}
-
-	expectedPerClusterOrg := fmt.Sprintf("%s%s", user.SubjectPrefix, spokeClusterName)
-	if !requestingOrgs.Has(expectedPerClusterOrg) {
-		return false
-	}
-
-	if !strings.HasPrefix(x509cr.Subject.CommonName, expectedPerClusterOrg) {
+	clusterName, recognized := DefaultClusterNameExtractor.ClusterName(x509cr)
+	if !recognized || clusterName != spokeClusterName {
 		return false
 	}
 
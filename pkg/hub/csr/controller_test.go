@@ -2,9 +2,20 @@ package csr
 
 import (
 	"context"
+	cryptorand "crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
 	"testing"
 	"time"
 
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 	"open-cluster-management.io/registration/pkg/hub/user"
 
@@ -13,11 +24,13 @@ import (
 	authorizationv1 "k8s.io/api/authorization/v1"
 	certificatesv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
+	certutil "k8s.io/client-go/util/cert"
 )
 
 var (
@@ -145,7 +158,7 @@ func TestSync(t *testing.T) {
 				csrStore.Add(csr)
 			}
 
-			ctrl := &csrApprovingController{kubeClient, informerFactory.Certificates().V1().CertificateSigningRequests().Lister(), eventstesting.NewTestingEventRecorder(t)}
+			ctrl := &csrApprovingController{kubeClient, clusterfake.NewSimpleClientset(), informerFactory.Certificates().V1().CertificateSigningRequests().Lister(), eventstesting.NewTestingEventRecorder(t)}
 			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, validCSR.Name))
 			if syncErr != nil {
 				t.Errorf("unexpected err: %v", syncErr)
@@ -156,6 +169,484 @@ func TestSync(t *testing.T) {
 	}
 }
 
+func TestSyncHoldApproval(t *testing.T) {
+	cases := []struct {
+		name               string
+		clusterAnnotations map[string]string
+		validateActions    func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:               "leave the csr pending while approval is held",
+			clusterAnnotations: map[string]string{holdApprovalAnnotation: "true"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:               "resume the approval after the hold annotation is cleared",
+			clusterAnnotations: map[string]string{},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "create", "update")
+				testinghelpers.AssertSubjectAccessReviewObj(t, actions[0].(clienttesting.CreateActionImpl).Object)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			csr := testinghelpers.NewCSR(validCSR)
+
+			cluster := testinghelpers.NewManagedCluster()
+			cluster.Name = "managedcluster1"
+			cluster.Annotations = c.clusterAnnotations
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+
+			kubeClient := kubefake.NewSimpleClientset(csr)
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: true,
+						},
+					}, nil
+				},
+			)
+			informerFactory := informers.NewSharedInformerFactory(kubeClient, 3*time.Minute)
+			informerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore().Add(csr)
+
+			ctrl := &csrApprovingController{kubeClient, clusterClient, informerFactory.Certificates().V1().CertificateSigningRequests().Lister(), eventstesting.NewTestingEventRecorder(t)}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, validCSR.Name))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func TestSyncExpectedUsages(t *testing.T) {
+	cases := []struct {
+		name            string
+		expectedUsages  []string
+		requestedUsages []certificatesv1.KeyUsage
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:            "approve a csr whose usages match the expected set",
+			expectedUsages:  []string{"digital signature", "key encipherment", "client auth"},
+			requestedUsages: []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageKeyEncipherment, certificatesv1.UsageClientAuth},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "create", "update")
+				testinghelpers.AssertSubjectAccessReviewObj(t, actions[0].(clienttesting.CreateActionImpl).Object)
+			},
+		},
+		{
+			name:            "leave a csr with missing usages pending",
+			expectedUsages:  []string{"digital signature", "key encipherment", "client auth"},
+			requestedUsages: []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageClientAuth},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:            "leave a csr with extra usages pending",
+			expectedUsages:  []string{"digital signature", "key encipherment", "client auth"},
+			requestedUsages: []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageKeyEncipherment, certificatesv1.UsageClientAuth, certificatesv1.UsageServerAuth},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:            "accept any usages without a configured expected set",
+			requestedUsages: []certificatesv1.KeyUsage{certificatesv1.UsageServerAuth},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "create", "update")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() { ExpectedCSRUsages = nil }()
+			ExpectedCSRUsages = c.expectedUsages
+
+			csr := testinghelpers.NewCSR(validCSR)
+			csr.Spec.Usages = c.requestedUsages
+
+			kubeClient := kubefake.NewSimpleClientset(csr)
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: true,
+						},
+					}, nil
+				},
+			)
+			informerFactory := informers.NewSharedInformerFactory(kubeClient, 3*time.Minute)
+			informerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore().Add(csr)
+
+			ctrl := &csrApprovingController{kubeClient, clusterfake.NewSimpleClientset(), informerFactory.Certificates().V1().CertificateSigningRequests().Lister(), eventstesting.NewTestingEventRecorder(t)}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, validCSR.Name))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func TestSyncRequiredOrganizations(t *testing.T) {
+	cases := []struct {
+		name            string
+		requiredOrgs    []string
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:         "approve a csr carrying the required organization",
+			requiredOrgs: []string{user.ManagedClustersGroup},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "create", "update")
+				testinghelpers.AssertSubjectAccessReviewObj(t, actions[0].(clienttesting.CreateActionImpl).Object)
+			},
+		},
+		{
+			name:         "leave a csr missing a required organization pending",
+			requiredOrgs: []string{"mycorp:spoke-agents"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "accept any organizations without a configured required set",
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "create", "update")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() { RequiredCSROrganizations = nil }()
+			RequiredCSROrganizations = c.requiredOrgs
+
+			csr := testinghelpers.NewCSR(validCSR)
+
+			kubeClient := kubefake.NewSimpleClientset(csr)
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: true,
+						},
+					}, nil
+				},
+			)
+			informerFactory := informers.NewSharedInformerFactory(kubeClient, 3*time.Minute)
+			informerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore().Add(csr)
+
+			ctrl := &csrApprovingController{kubeClient, clusterfake.NewSimpleClientset(), informerFactory.Certificates().V1().CertificateSigningRequests().Lister(), eventstesting.NewTestingEventRecorder(t)}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, validCSR.Name))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func TestSyncMaxPendingAge(t *testing.T) {
+	cases := []struct {
+		name               string
+		maxPendingAge      time.Duration
+		csrAge             time.Duration
+		authorized         bool
+		expectedUsages     []string
+		requestedUsages    []certificatesv1.KeyUsage
+		requiredOrgs       []string
+		clusterAnnotations map[string]string
+		validateActions    func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:          "deny an unauthorized csr pending past the threshold",
+			maxPendingAge: time.Hour,
+			csrAge:        2 * time.Hour,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				expectedCondition := certificatesv1.CertificateSigningRequestCondition{
+					Type:    certificatesv1.CertificateDenied,
+					Status:  corev1.ConditionTrue,
+					Reason:  "AutoDeniedByHubCSRApprovingController",
+					Message: "Denying Managed cluster agent certificate pending longer than 1h0m0s: the agent is not authorized to renew its client certificate.",
+				}
+				testinghelpers.AssertActions(t, actions, "create", "update")
+				actual := actions[1].(clienttesting.UpdateActionImpl).Object
+				testinghelpers.AssertCSRCondition(t, actual.(*certificatesv1.CertificateSigningRequest).Status.Conditions, expectedCondition)
+			},
+		},
+		{
+			name:          "leave an unauthorized csr within the threshold pending",
+			maxPendingAge: time.Hour,
+			csrAge:        time.Minute,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "create")
+			},
+		},
+		{
+			name:   "leave an old unauthorized csr pending without a configured threshold",
+			csrAge: 2 * time.Hour,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "create")
+			},
+		},
+		{
+			name:          "deny a csr missing a required organization pending past the threshold",
+			maxPendingAge: time.Hour,
+			csrAge:        2 * time.Hour,
+			authorized:    true,
+			requiredOrgs:  []string{"mycorp:spoke-agents"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				expectedCondition := certificatesv1.CertificateSigningRequestCondition{
+					Type:    certificatesv1.CertificateDenied,
+					Status:  corev1.ConditionTrue,
+					Reason:  "AutoDeniedByHubCSRApprovingController",
+					Message: "Denying Managed cluster agent certificate pending longer than 1h0m0s: the certificate request does not carry all required organizations.",
+				}
+				testinghelpers.AssertActions(t, actions, "update")
+				actual := actions[0].(clienttesting.UpdateActionImpl).Object
+				testinghelpers.AssertCSRCondition(t, actual.(*certificatesv1.CertificateSigningRequest).Status.Conditions, expectedCondition)
+			},
+		},
+		{
+			name:               "leave a held cluster's stale csr with mismatching usages pending",
+			maxPendingAge:      time.Hour,
+			csrAge:             2 * time.Hour,
+			expectedUsages:     []string{"digital signature", "key encipherment", "client auth"},
+			requestedUsages:    []certificatesv1.KeyUsage{certificatesv1.UsageServerAuth},
+			clusterAnnotations: map[string]string{holdApprovalAnnotation: "true"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:               "leave a held cluster's stale csr missing a required organization pending",
+			maxPendingAge:      time.Hour,
+			csrAge:             2 * time.Hour,
+			requiredOrgs:       []string{"mycorp:spoke-agents"},
+			clusterAnnotations: map[string]string{holdApprovalAnnotation: "true"},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:          "an authorized csr past the threshold is still approved",
+			maxPendingAge: time.Hour,
+			csrAge:        2 * time.Hour,
+			authorized:    true,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "create", "update")
+				expectedCondition := certificatesv1.CertificateSigningRequestCondition{
+					Type:    certificatesv1.CertificateApproved,
+					Status:  corev1.ConditionTrue,
+					Reason:  "AutoApprovedByHubCSRApprovingController",
+					Message: "Auto approving Managed cluster agent certificate after SubjectAccessReview.",
+				}
+				actual := actions[1].(clienttesting.UpdateActionImpl).Object
+				testinghelpers.AssertCSRCondition(t, actual.(*certificatesv1.CertificateSigningRequest).Status.Conditions, expectedCondition)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() { CSRMaxPendingAge = 0 }()
+			CSRMaxPendingAge = c.maxPendingAge
+			defer func() { ExpectedCSRUsages = nil }()
+			ExpectedCSRUsages = c.expectedUsages
+			defer func() { RequiredCSROrganizations = nil }()
+			RequiredCSROrganizations = c.requiredOrgs
+
+			csr := testinghelpers.NewCSR(validCSR)
+			csr.CreationTimestamp = metav1.NewTime(time.Now().Add(-c.csrAge))
+			if c.requestedUsages != nil {
+				csr.Spec.Usages = c.requestedUsages
+			}
+
+			clusterClient := clusterfake.NewSimpleClientset()
+			if c.clusterAnnotations != nil {
+				cluster := testinghelpers.NewManagedCluster()
+				cluster.Name = "managedcluster1"
+				cluster.Annotations = c.clusterAnnotations
+				clusterClient = clusterfake.NewSimpleClientset(cluster)
+			}
+
+			kubeClient := kubefake.NewSimpleClientset(csr)
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: c.authorized,
+						},
+					}, nil
+				},
+			)
+			informerFactory := informers.NewSharedInformerFactory(kubeClient, 3*time.Minute)
+			informerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore().Add(csr)
+
+			ctrl := &csrApprovingController{kubeClient, clusterClient, informerFactory.Certificates().V1().CertificateSigningRequests().Lister(), eventstesting.NewTestingEventRecorder(t)}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, validCSR.Name))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, kubeClient.Actions())
+		})
+	}
+}
+
+func TestRecordIssuedCertificate(t *testing.T) {
+	chain, caCert := newIssuedCertChain()
+	caFingerprint := fmt.Sprintf("%x", sha256.Sum256(caCert.Raw))
+	leafOnly := []byte{}
+	certExpiry := ""
+	if block, _ := pem.Decode(chain); block != nil {
+		leafOnly = pem.EncodeToMemory(block)
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certExpiry = cert.NotAfter.UTC().Format(time.RFC3339)
+		}
+	}
+
+	cases := []struct {
+		name               string
+		certificate        []byte
+		clusterAnnotations map[string]string
+		validateActions    func(t *testing.T, clusterActions []clienttesting.Action)
+	}{
+		{
+			name:        "record the signer name and CA fingerprint of an issued certificate",
+			certificate: chain,
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, clusterActions, "get", "update")
+				managedCluster := clusterActions[1].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				if actual := managedCluster.Annotations[clientCertSignerAnnotation]; actual != certificatesv1.KubeAPIServerClientSignerName {
+					t.Errorf("expected signer annotation %q but got: %q", certificatesv1.KubeAPIServerClientSignerName, actual)
+				}
+				if actual := managedCluster.Annotations[clientCertCAFingerprintAnnotation]; actual != caFingerprint {
+					t.Errorf("expected CA fingerprint annotation %q but got: %q", caFingerprint, actual)
+				}
+				if actual := managedCluster.Annotations[clientCertExpiryAnnotation]; actual != certExpiry {
+					t.Errorf("expected expiry annotation %q but got: %q", certExpiry, actual)
+				}
+			},
+		},
+		{
+			name:        "annotations are in sync",
+			certificate: chain,
+			clusterAnnotations: map[string]string{
+				clientCertSignerAnnotation:        certificatesv1.KubeAPIServerClientSignerName,
+				clientCertCAFingerprintAnnotation: caFingerprint,
+				clientCertExpiryAnnotation:        certExpiry,
+			},
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, clusterActions, "get")
+			},
+		},
+		{
+			name:        "issued certificate without the CA certificate",
+			certificate: leafOnly,
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, clusterActions, "get", "update")
+				managedCluster := clusterActions[1].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				if actual := managedCluster.Annotations[clientCertSignerAnnotation]; actual != certificatesv1.KubeAPIServerClientSignerName {
+					t.Errorf("expected signer annotation %q but got: %q", certificatesv1.KubeAPIServerClientSignerName, actual)
+				}
+				if _, ok := managedCluster.Annotations[clientCertCAFingerprintAnnotation]; ok {
+					t.Errorf("expected no CA fingerprint annotation but got: %q", managedCluster.Annotations[clientCertCAFingerprintAnnotation])
+				}
+			},
+		},
+		{
+			name: "approved csr without issued certificate",
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			csr := testinghelpers.NewApprovedCSR(validCSR)
+			csr.Status.Certificate = c.certificate
+
+			cluster := testinghelpers.NewManagedCluster()
+			cluster.Name = "managedcluster1"
+			cluster.Annotations = c.clusterAnnotations
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+
+			kubeClient := kubefake.NewSimpleClientset(csr)
+			informerFactory := informers.NewSharedInformerFactory(kubeClient, 3*time.Minute)
+			informerFactory.Certificates().V1().CertificateSigningRequests().Informer().GetStore().Add(csr)
+
+			ctrl := &csrApprovingController{kubeClient, clusterClient, informerFactory.Certificates().V1().CertificateSigningRequests().Lister(), eventstesting.NewTestingEventRecorder(t)}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, validCSR.Name))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func newIssuedCertChain() ([]byte, *x509.Certificate) {
+	caKey, err := rsa.GenerateKey(cryptorand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	caCert, err := certutil.NewSelfSignedCACert(certutil.Config{CommonName: "open-cluster-management.io"}, caKey)
+	if err != nil {
+		panic(err)
+	}
+
+	key, err := rsa.GenerateKey(cryptorand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	certDERBytes, err := x509.CreateCertificate(
+		cryptorand.Reader,
+		&x509.Certificate{
+			Subject:      pkix.Name{CommonName: validCSR.CN},
+			SerialNumber: big.NewInt(1),
+			NotBefore:    caCert.NotBefore,
+			NotAfter:     time.Now().Add(time.Hour).UTC(),
+			KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		},
+		caCert,
+		key.Public(),
+		caKey,
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDERBytes})
+	chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw})...)
+	return chain, caCert
+}
+
 func TestIsSpokeClusterClientCertRenewal(t *testing.T) {
 	invalidSignerName := "invalidsigner"
 
@@ -257,3 +748,106 @@ func TestIsSpokeClusterClientCertRenewal(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultClusterNameExtractor(t *testing.T) {
+	cases := []struct {
+		name                string
+		subject             pkix.Name
+		expectedClusterName string
+		expectedRecognized  bool
+	}{
+		{
+			name: "a subject without organizations",
+			subject: pkix.Name{
+				CommonName: user.SubjectPrefix + "managedcluster1:spokeagent1",
+			},
+		},
+		{
+			name: "a subject with a foreign organization",
+			subject: pkix.Name{
+				CommonName:   user.SubjectPrefix + "managedcluster1:spokeagent1",
+				Organization: []string{"test"},
+			},
+		},
+		{
+			name: "a common name of another cluster",
+			subject: pkix.Name{
+				CommonName:   user.SubjectPrefix + "managedcluster2:spokeagent1",
+				Organization: []string{user.SubjectPrefix + "managedcluster1"},
+			},
+		},
+		{
+			name: "a subject with the per cluster organization",
+			subject: pkix.Name{
+				CommonName:   user.SubjectPrefix + "managedcluster1:spokeagent1",
+				Organization: []string{user.SubjectPrefix + "managedcluster1"},
+			},
+			expectedClusterName: "managedcluster1",
+			expectedRecognized:  true,
+		},
+		{
+			name: "a subject with the common group",
+			subject: pkix.Name{
+				CommonName:   user.SubjectPrefix + "managedcluster1:spokeagent1",
+				Organization: []string{user.SubjectPrefix + "managedcluster1", user.ManagedClustersGroup},
+			},
+			expectedClusterName: "managedcluster1",
+			expectedRecognized:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterName, recognized := DefaultClusterNameExtractor.ClusterName(&x509.CertificateRequest{Subject: c.subject})
+			if recognized != c.expectedRecognized {
+				t.Errorf("expected recognized to be %t, but failed", c.expectedRecognized)
+			}
+			if clusterName != c.expectedClusterName {
+				t.Errorf("expected cluster name %q but got: %q", c.expectedClusterName, clusterName)
+			}
+		})
+	}
+}
+
+// commonNameClusterNameExtractor extracts the cluster name from the second segment of the
+// request common name instead of the per cluster organization.
+type commonNameClusterNameExtractor struct{}
+
+func (commonNameClusterNameExtractor) ClusterName(x509cr *x509.CertificateRequest) (string, bool) {
+	if !strings.HasPrefix(x509cr.Subject.CommonName, user.SubjectPrefix) {
+		return "", false
+	}
+	segments := strings.Split(strings.TrimPrefix(x509cr.Subject.CommonName, user.SubjectPrefix), ":")
+	return segments[0], true
+}
+
+func TestCustomClusterNameExtractor(t *testing.T) {
+	// a csr without the per cluster organization the default extractor requires
+	csrWithoutOrgs := testinghelpers.CSRHolder{
+		Name:         validCSR.Name,
+		Labels:       validCSR.Labels,
+		SignerName:   validCSR.SignerName,
+		CN:           validCSR.CN,
+		Username:     validCSR.Username,
+		ReqBlockType: validCSR.ReqBlockType,
+	}
+
+	if isSpokeClusterClientCertRenewal(testinghelpers.NewCSR(csrWithoutOrgs)) {
+		t.Errorf("expected the default extractor not to recognize a csr without the per cluster organization")
+	}
+
+	defaultExtractor := DefaultClusterNameExtractor
+	defer func() { DefaultClusterNameExtractor = defaultExtractor }()
+	DefaultClusterNameExtractor = commonNameClusterNameExtractor{}
+
+	if !isSpokeClusterClientCertRenewal(testinghelpers.NewCSR(csrWithoutOrgs)) {
+		t.Errorf("expected the custom extractor to recognize the csr by its common name")
+	}
+
+	// the extracted cluster name must still match the cluster name label
+	csrOfAnotherCluster := csrWithoutOrgs
+	csrOfAnotherCluster.Labels = map[string]string{"open-cluster-management.io/cluster-name": "managedcluster2"}
+	if isSpokeClusterClientCertRenewal(testinghelpers.NewCSR(csrOfAnotherCluster)) {
+		t.Errorf("expected the csr not to be recognized with a mismatching cluster name label")
+	}
+}
@@ -0,0 +1,101 @@
+package csr
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+var (
+	// ClientCertExpiryWindow is the window before client certificate expiration within
+	// which a managed cluster is flagged as expiring soon, to drive proactive rotation
+	// campaigns. A zero window disables the check. It is exposed as a command line flag.
+	ClientCertExpiryWindow time.Duration
+
+	// PrintClientCertExpiryReport, when set, makes the controller log a report listing
+	// the clusters whose client certificates expire within the window on every resync.
+	// It is exposed as a command line flag.
+	PrintClientCertExpiryReport bool
+)
+
+// clientCertExpiryController flags managed clusters whose client certificates expire within
+// the configured window, based on the expiry annotation recorded when the certificate was
+// issued.
+type clientCertExpiryController struct {
+	clusterLister clusterv1listers.ManagedClusterLister
+}
+
+// NewClientCertExpiryController creates a client certificate expiry controller on hub cluster.
+func NewClientCertExpiryController(
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	resyncInterval time.Duration,
+	recorder events.Recorder) factory.Controller {
+	c := &clientCertExpiryController{
+		clusterLister: clusterInformer.Lister(),
+	}
+	return factory.New().
+		WithInformers(clusterInformer.Informer()).
+		WithSync(c.sync).
+		ResyncEvery(resyncInterval).
+		ToController("ClientCertExpiryController", recorder)
+}
+
+func (c *clientCertExpiryController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	if ClientCertExpiryWindow <= 0 {
+		return nil
+	}
+
+	clusters, err := c.clusterLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	expiringClusters := clustersExpiringWithin(clusters, ClientCertExpiryWindow, time.Now())
+	expiring := sets.NewString(expiringClusters...)
+	for _, cluster := range clusters {
+		if _, ok := cluster.Annotations[clientCertExpiryAnnotation]; !ok {
+			continue
+		}
+		observeClusterClientCertExpiring(cluster.Name, expiring.Has(cluster.Name))
+	}
+
+	if PrintClientCertExpiryReport && len(expiringClusters) > 0 {
+		klog.Infof("Client certificates of the following managed clusters expire within %s: %s",
+			ClientCertExpiryWindow, strings.Join(expiringClusters, ", "))
+	}
+	return nil
+}
+
+// clustersExpiringWithin returns the sorted names of the clusters whose recorded client
+// certificate expiry falls within the given window from now. Clusters without the expiry
+// annotation or with an unparsable expiry are skipped.
+func clustersExpiringWithin(clusters []*clusterv1.ManagedCluster, window time.Duration, now time.Time) []string {
+	expiringClusters := []string{}
+	for _, cluster := range clusters {
+		value, ok := cluster.Annotations[clientCertExpiryAnnotation]
+		if !ok {
+			continue
+		}
+		expiry, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			klog.V(4).Infof("The expiry annotation %q of managed cluster %q is not parsable: %v", value, cluster.Name, err)
+			continue
+		}
+		if expiry.Before(now.Add(window)) {
+			expiringClusters = append(expiringClusters, cluster.Name)
+		}
+	}
+	sort.Strings(expiringClusters)
+	return expiringClusters
+}
@@ -0,0 +1,119 @@
+package csr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+	registrationmetrics "open-cluster-management.io/registration/pkg/metrics"
+
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+func TestClustersExpiringWithin(t *testing.T) {
+	now := time.Now()
+
+	cases := []struct {
+		name             string
+		clusters         []*clusterv1.ManagedCluster
+		window           time.Duration
+		expectedClusters []string
+	}{
+		{
+			name: "clusters within the window are reported and others are not",
+			clusters: []*clusterv1.ManagedCluster{
+				newClusterWithCertExpiry("cluster1", now.Add(24*time.Hour).Format(time.RFC3339)),
+				newClusterWithCertExpiry("cluster2", now.Add(30*24*time.Hour).Format(time.RFC3339)),
+				newClusterWithCertExpiry("cluster3", now.Add(-time.Hour).Format(time.RFC3339)),
+			},
+			window:           7 * 24 * time.Hour,
+			expectedClusters: []string{"cluster1", "cluster3"},
+		},
+		{
+			name: "clusters without a recorded expiry are skipped",
+			clusters: []*clusterv1.ManagedCluster{
+				testinghelpers.NewManagedCluster(),
+				newClusterWithCertExpiry("cluster1", now.Add(time.Hour).Format(time.RFC3339)),
+			},
+			window:           7 * 24 * time.Hour,
+			expectedClusters: []string{"cluster1"},
+		},
+		{
+			name: "clusters with an unparsable expiry are skipped",
+			clusters: []*clusterv1.ManagedCluster{
+				newClusterWithCertExpiry("cluster1", "not-a-time"),
+				newClusterWithCertExpiry("cluster2", now.Add(time.Hour).Format(time.RFC3339)),
+			},
+			window:           7 * 24 * time.Hour,
+			expectedClusters: []string{"cluster2"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := clustersExpiringWithin(c.clusters, c.window, now)
+			if !reflect.DeepEqual(actual, c.expectedClusters) {
+				t.Errorf("expected expiring clusters %v but got: %v", c.expectedClusters, actual)
+			}
+		})
+	}
+}
+
+func TestClientCertExpiringMetric(t *testing.T) {
+	// complete the deferred metrics registration with the default prefix
+	registrationmetrics.SetPrefix("")
+
+	defer func() { ClientCertExpiryWindow = 0 }()
+	ClientCertExpiryWindow = 7 * 24 * time.Hour
+
+	now := time.Now()
+	expiringCluster := newClusterWithCertExpiry("cluster1", now.Add(24*time.Hour).Format(time.RFC3339))
+	freshCluster := newClusterWithCertExpiry("cluster2", now.Add(30*24*time.Hour).Format(time.RFC3339))
+
+	clusterClient := clusterfake.NewSimpleClientset(expiringCluster, freshCluster)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(expiringCluster)
+	clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(freshCluster)
+
+	ctrl := &clientCertExpiryController{
+		clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+	}
+	if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	actualValues := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "registration_cluster_client_cert_expiring" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "managed_cluster" {
+					actualValues[label.GetValue()] = metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	expectedValues := map[string]float64{"cluster1": 1, "cluster2": 0}
+	if !reflect.DeepEqual(actualValues, expectedValues) {
+		t.Errorf("expected gauge values %v but got: %v", expectedValues, actualValues)
+	}
+}
+
+func newClusterWithCertExpiry(name, expiry string) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewManagedCluster()
+	cluster.Name = name
+	cluster.Annotations = map[string]string{clientCertExpiryAnnotation: expiry}
+	return cluster
+}
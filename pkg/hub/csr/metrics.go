@@ -0,0 +1,40 @@
+package csr
+
+import (
+	"k8s.io/component-base/metrics"
+
+	registrationmetrics "open-cluster-management.io/registration/pkg/metrics"
+)
+
+// clusterClientCertExpiring exports whether the recorded client certificate of a managed
+// cluster expires within the configured expiry window. The gauge carries one series per
+// cluster with a recorded expiry, 1 when the certificate expires within the window and 0
+// otherwise.
+var clusterClientCertExpiring *metrics.GaugeVec
+
+func init() {
+	registrationmetrics.Register(func(prefix string) metrics.Registerable {
+		clusterClientCertExpiring = metrics.NewGaugeVec(
+			&metrics.GaugeOpts{
+				Name: registrationmetrics.Prefixed(prefix, "registration_cluster_client_cert_expiring"),
+				Help: "Whether the client certificate of a managed cluster expires within the configured expiry window.",
+			},
+			[]string{"managed_cluster"},
+		)
+		return clusterClientCertExpiring
+	})
+}
+
+// observeClusterClientCertExpiring records whether the client certificate of a managed
+// cluster expires within the configured window. It does nothing before the metrics
+// registration completed.
+func observeClusterClientCertExpiring(clusterName string, expiring bool) {
+	if clusterClientCertExpiring == nil {
+		return
+	}
+	value := 0.0
+	if expiring {
+		value = 1.0
+	}
+	clusterClientCertExpiring.WithLabelValues(clusterName).Set(value)
+}
@@ -0,0 +1,135 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// Aggregator collects the named health checkers of the hub controllers and serves a
+// single healthz endpoint reflecting all of them, so the liveness of the controller
+// process covers its sub-controllers instead of just the process being up. The verbose
+// form of the endpoint (/healthz?verbose) lists the state of each registered checker.
+type Aggregator struct {
+	lock     sync.Mutex
+	checkers []healthz.HealthChecker
+}
+
+// NewAggregator creates an empty health aggregator.
+func NewAggregator() *Aggregator {
+	return &Aggregator{}
+}
+
+// Register adds a named checker for a controller and returns its health handle. The
+// checker reports failure while any of the given informer caches is not synced, while the
+// last recorded reconcile of the controller failed, or while the controller has not
+// reconciled successfully within the given threshold. A zero threshold disables the
+// reconcile recency check; it suits event driven controllers that legitimately stay idle.
+func (a *Aggregator) Register(name string, reconcileThreshold time.Duration, cachesSynced ...cache.InformerSynced) *ControllerHealth {
+	h := &ControllerHealth{
+		name:         name,
+		threshold:    reconcileThreshold,
+		cachesSynced: cachesSynced,
+		registered:   time.Now(),
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.checkers = append(a.checkers, h)
+	return h
+}
+
+// Handler returns an http handler serving /healthz and one sub path per registered
+// checker, in the standard apiserver healthz format.
+func (a *Aggregator) Handler() http.Handler {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	mux := http.NewServeMux()
+	healthz.InstallHandler(mux, a.checkers...)
+	return mux
+}
+
+// Serve serves the healthz endpoint on the given address until the context is done.
+func (a *Aggregator) Serve(ctx context.Context, addr string) {
+	server := &http.Server{Addr: addr, Handler: a.Handler()}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		klog.Errorf("Unable to serve the health endpoint on %q: %v", addr, err)
+	}
+}
+
+// ControllerHealth is the health checker of a single hub controller.
+type ControllerHealth struct {
+	name         string
+	threshold    time.Duration
+	cachesSynced []cache.InformerSynced
+	registered   time.Time
+
+	lock        sync.Mutex
+	lastError   error
+	lastSuccess time.Time
+}
+
+// Name returns the name of the controller the checker reflects.
+func (h *ControllerHealth) Name() string {
+	return h.name
+}
+
+// ReconcileDone records the result of a reconcile of the controller.
+func (h *ControllerHealth) ReconcileDone(err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.lastError = err
+	if err == nil {
+		h.lastSuccess = time.Now()
+	}
+}
+
+// Check reports an error while the controller is unhealthy.
+func (h *ControllerHealth) Check(_ *http.Request) error {
+	for _, synced := range h.cachesSynced {
+		if !synced() {
+			return fmt.Errorf("informers of controller %s are not synced", h.name)
+		}
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.lastError != nil {
+		return fmt.Errorf("the last reconcile of controller %s failed: %v", h.name, h.lastError)
+	}
+	if h.threshold <= 0 {
+		return nil
+	}
+	lastSuccess := h.lastSuccess
+	if lastSuccess.IsZero() {
+		lastSuccess = h.registered
+	}
+	if time.Since(lastSuccess) > h.threshold {
+		return fmt.Errorf("controller %s has not reconciled successfully within %v", h.name, h.threshold)
+	}
+	return nil
+}
+
+// MonitorSync wraps a controller sync func so the result of each reconcile is recorded
+// with the given controller health. A nil health leaves the sync func unchanged.
+func MonitorSync(health *ControllerHealth, sync factory.SyncFunc) factory.SyncFunc {
+	if health == nil {
+		return sync
+	}
+	return func(ctx context.Context, syncCtx factory.SyncContext) error {
+		err := sync(ctx, syncCtx)
+		health.ReconcileDone(err)
+		return err
+	}
+}
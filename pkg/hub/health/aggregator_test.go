@@ -0,0 +1,114 @@
+package health
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestControllerHealthCheck(t *testing.T) {
+	cases := []struct {
+		name          string
+		setup         func(a *Aggregator) *ControllerHealth
+		expectedError string
+	}{
+		{
+			name: "a controller with synced informers and a recent successful reconcile is healthy",
+			setup: func(a *Aggregator) *ControllerHealth {
+				h := a.Register("TestController", time.Minute, func() bool { return true })
+				h.ReconcileDone(nil)
+				return h
+			},
+		},
+		{
+			name: "a controller with unsynced informers is unhealthy",
+			setup: func(a *Aggregator) *ControllerHealth {
+				return a.Register("TestController", 0, func() bool { return false })
+			},
+			expectedError: "informers of controller TestController are not synced",
+		},
+		{
+			name: "a controller whose last reconcile failed is unhealthy",
+			setup: func(a *Aggregator) *ControllerHealth {
+				h := a.Register("TestController", 0)
+				h.ReconcileDone(fmt.Errorf("apply failed"))
+				return h
+			},
+			expectedError: "the last reconcile of controller TestController failed: apply failed",
+		},
+		{
+			name: "a controller recovers once a reconcile succeeds again",
+			setup: func(a *Aggregator) *ControllerHealth {
+				h := a.Register("TestController", time.Minute)
+				h.ReconcileDone(fmt.Errorf("apply failed"))
+				h.ReconcileDone(nil)
+				return h
+			},
+		},
+		{
+			name: "a stuck controller without a successful reconcile within the threshold is unhealthy",
+			setup: func(a *Aggregator) *ControllerHealth {
+				h := a.Register("TestController", time.Minute)
+				h.registered = time.Now().Add(-time.Hour)
+				return h
+			},
+			expectedError: "controller TestController has not reconciled successfully within 1m0s",
+		},
+		{
+			name: "a zero threshold disables the reconcile recency check",
+			setup: func(a *Aggregator) *ControllerHealth {
+				h := a.Register("TestController", 0)
+				h.registered = time.Now().Add(-time.Hour)
+				return h
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := c.setup(NewAggregator())
+			err := h.Check(nil)
+			switch {
+			case len(c.expectedError) == 0 && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case len(c.expectedError) > 0 && err == nil:
+				t.Errorf("expected error %q, but got none", c.expectedError)
+			case len(c.expectedError) > 0 && err.Error() != c.expectedError:
+				t.Errorf("expected error %q, but got %q", c.expectedError, err.Error())
+			}
+		})
+	}
+}
+
+func TestAggregatorHandler(t *testing.T) {
+	aggregator := NewAggregator()
+	healthyController := aggregator.Register("HealthyController", time.Minute)
+	healthyController.ReconcileDone(nil)
+	stuckController := aggregator.Register("StuckController", time.Minute)
+	stuckController.registered = time.Now().Add(-time.Hour)
+
+	server := httptest.NewServer(aggregator.Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/healthz?verbose")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 500 {
+		t.Errorf("expected status code 500 for a stuck controller, but got %d", resp.StatusCode)
+	}
+
+	body := make([]byte, 4096)
+	n, _ := resp.Body.Read(body)
+	output := string(body[:n])
+	if !strings.Contains(output, "[+]HealthyController ok") {
+		t.Errorf("expected the healthy controller to be listed as ok, but got:\n%s", output)
+	}
+	if !strings.Contains(output, "[-]StuckController failed") {
+		t.Errorf("expected the stuck controller to be listed as failed, but got:\n%s", output)
+	}
+}
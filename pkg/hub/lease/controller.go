@@ -2,6 +2,8 @@ package lease
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
@@ -9,6 +11,7 @@ import (
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/hub/health"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -18,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	coordinformers "k8s.io/client-go/informers/coordination/v1"
 	"k8s.io/client-go/kubernetes"
 	coordlisters "k8s.io/client-go/listers/coordination/v1"
@@ -26,9 +30,54 @@ import (
 
 const leaseDurationTimes = 5
 
+// Reasons of the unknown availability condition set by the lease controller. They are
+// stable identifiers, alerting may key off them to distinguish why the heartbeat of a
+// managed cluster is not trusted.
+const (
+	// reasonLeaseUpdateStopped identifies a cluster whose agent renewed the lease
+	// before but stopped doing so, typically the agent went down.
+	reasonLeaseUpdateStopped = "ManagedClusterLeaseUpdateStopped"
+	// reasonLeaseNeverRenewed identifies a cluster whose agent never renewed the lease
+	// after the hub created it, typically the agent never came up after registration.
+	reasonLeaseNeverRenewed = "ManagedClusterLeaseNeverRenewed"
+	// reasonLeaseClockSkewed identifies a cluster whose lease renew time is ahead of
+	// the hub clock beyond the grace period, such a heartbeat is rejected since a
+	// skewed agent clock would keep the lease fresh forever.
+	reasonLeaseClockSkewed = "ManagedClusterLeaseClockSkewed"
+	// reasonClockSkewDetected identifies a cluster whose lease renew time is further in
+	// the future than the configured MaxFutureClockSkew allows, which indicates a badly
+	// misconfigured clock on the spoke.
+	reasonClockSkewDetected = "ClockSkewDetected"
+	// reasonForcedUnavailable identifies a cluster an operator forced unavailable with
+	// the force-unavailable annotation, e.g. for a maintenance window.
+	reasonForcedUnavailable = "ManagedClusterForcedUnavailable"
+)
+
+// ForceUnavailableAnnotation forces the availability of a managed cluster to false
+// regardless of its lease state while it is set to "true", so operators can drain
+// workloads during a maintenance window. Removing the annotation resumes the lease
+// based evaluation.
+const ForceUnavailableAnnotation = "cluster.open-cluster-management.io/force-unavailable"
+
+// startupPassWorkers bounds the concurrency of the lease evaluation pass performed once
+// on controller startup.
+const startupPassWorkers = 5
+
 var (
 	// LeaseDurationSeconds is lease update time interval
 	LeaseDurationSeconds = 60
+
+	// InitialGracePeriod is the period after a cluster is accepted during which the
+	// controller does not set the availability of the cluster to unknown, giving the
+	// agent time to start renewing the lease without flapping the availability right
+	// after acceptance. A zero period disables the grace. It is exposed as a command
+	// line flag.
+	InitialGracePeriod time.Duration
+
+	// MaxFutureClockSkew is the maximum amount a lease renew time may be ahead of the
+	// hub clock before the lease is rejected as invalid, tighter than the grace period
+	// based skew check. A zero bound disables it. It is exposed as a command line flag.
+	MaxFutureClockSkew time.Duration
 )
 
 // leaseController checks the lease of managed clusters on hub cluster to determine whether a managed cluster is available.
@@ -37,6 +86,12 @@ type leaseController struct {
 	clusterClient clientset.Interface
 	clusterLister clusterv1listers.ManagedClusterLister
 	leaseLister   coordlisters.LeaseLister
+	// sink consumes the availability transitions made by this controller, nil disables
+	// the publishing
+	sink AvailabilitySink
+	// predicate may veto the availability of a cluster whose lease is fresh based on
+	// additional signals, nil keeps the availability lease-only
+	predicate AvailabilityPredicate
 }
 
 // NewClusterLeaseController creates a cluster lease controller on hub cluster.
@@ -46,17 +101,25 @@ func NewClusterLeaseController(
 	clusterInformer clusterv1informer.ManagedClusterInformer,
 	leaseInformer coordinformers.LeaseInformer,
 	resyncInterval time.Duration,
+	sink AvailabilitySink,
+	predicate AvailabilityPredicate,
+	healthRecorder *health.ControllerHealth,
 	recorder events.Recorder) factory.Controller {
 	c := &leaseController{
 		kubeClient:    kubeClient,
 		clusterClient: clusterClient,
 		clusterLister: clusterInformer.Lister(),
 		leaseLister:   leaseInformer.Lister(),
+		sink:          sink,
+		predicate:     predicate,
 	}
 	return factory.New().
 		WithInformers(clusterInformer.Informer(), leaseInformer.Informer()).
-		WithSync(c.sync).
+		WithSync(health.MonitorSync(healthRecorder, c.sync)).
 		ResyncEvery(resyncInterval).
+		// flag clusters whose lease went stale while the hub was down promptly on startup,
+		// instead of waiting for the first periodical resync
+		WithPostStartHooks(c.startupPass).
 		ToController("ManagedClusterLeaseController", recorder)
 }
 
@@ -67,66 +130,241 @@ func (c *leaseController) sync(ctx context.Context, syncCtx factory.SyncContext)
 		return nil
 	}
 	for _, cluster := range clusters {
-		// cluster is not accepted, skip it.
-		if !meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionHubAccepted) {
-			continue
+		if err := c.syncCluster(ctx, syncCtx, cluster); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		// get the lease of a cluster, if the lease is not found, create it
-		leaseName := "managed-cluster-lease"
-		observedLease, err := c.leaseLister.Leases(cluster.Name).Get(leaseName)
-		switch {
-		case errors.IsNotFound(err):
-			if !cluster.DeletionTimestamp.IsZero() {
-				// the cluster is deleting, do nothing
-				break
-			}
-			lease := &coordv1.Lease{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      leaseName,
-					Namespace: cluster.Name,
-					Labels:    map[string]string{"open-cluster-management.io/cluster-name": cluster.Name},
-				},
-				Spec: coordv1.LeaseSpec{
-					HolderIdentity: pointer.StringPtr(leaseName),
-					RenewTime:      &metav1.MicroTime{Time: time.Now()},
-				},
-			}
-			if _, err := c.kubeClient.CoordinationV1().Leases(cluster.Name).Create(ctx, lease, metav1.CreateOptions{}); err != nil {
-				return err
-			}
-			continue
-		case err != nil:
-			return err
-		case err == nil:
-			gracePeriod := time.Duration(leaseDurationTimes*cluster.Spec.LeaseDurationSeconds) * time.Second
-			// FIX: #183 avoid gracePeriod is zero, will non-stop update ManagedClusterLeaseUpdateStopped condition.
-			if gracePeriod == 0 {
-				gracePeriod = time.Duration(leaseDurationTimes*LeaseDurationSeconds) * time.Second
-			}
-			// the lease is constantly updated, do nothing
-			now := time.Now()
-			if now.Before(observedLease.Spec.RenewTime.Add(gracePeriod)) {
-				continue
+// startupPass evaluates the lease freshness of all clusters once after the controller
+// starts, so clusters that went down while the hub was offline are flagged promptly
+// instead of staying available until the first periodical resync.
+func (c *leaseController) startupPass(ctx context.Context, syncCtx factory.SyncContext) error {
+	clusters, err := c.clusterLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	clusterCh := make(chan *clusterv1.ManagedCluster)
+	errCh := make(chan error, startupPassWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < startupPassWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var errs []error
+			for cluster := range clusterCh {
+				if err := c.syncCluster(ctx, syncCtx, cluster); err != nil {
+					errs = append(errs, err)
+				}
 			}
-		}
+			errCh <- utilerrors.NewAggregate(errs)
+		}()
+	}
+	for _, cluster := range clusters {
+		clusterCh <- cluster
+	}
+	close(clusterCh)
+	wg.Wait()
+	close(errCh)
 
-		// the lease is not constantly updated, update it to unknown
-		conditionUpdateFn := helpers.UpdateManagedClusterConditionFn(metav1.Condition{
-			Type:    clusterv1.ManagedClusterConditionAvailable,
-			Status:  metav1.ConditionUnknown,
-			Reason:  "ManagedClusterLeaseUpdateStopped",
-			Message: "Registration agent stopped updating its lease.",
-		})
-		_, updated, err := helpers.UpdateManagedClusterStatus(ctx, c.clusterClient, cluster.Name, conditionUpdateFn)
+	errs := []error{}
+	for err := range errCh {
 		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// syncCluster checks the lease of an accepted cluster on hub to determine whether the managed cluster is available.
+func (c *leaseController) syncCluster(ctx context.Context, syncCtx factory.SyncContext, cluster *clusterv1.ManagedCluster) error {
+	// cluster is not accepted, skip it.
+	if !meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionHubAccepted) {
+		return nil
+	}
+
+	// an operator may force the cluster unavailable, the annotation overrides the
+	// lease based evaluation while it is set
+	if cluster.Annotations[ForceUnavailableAnnotation] == "true" {
+		return c.forceUnavailable(ctx, syncCtx, cluster)
+	}
+
+	// export the effective lease duration of the observed cluster
+	leaseDurationSeconds := cluster.Spec.LeaseDurationSeconds
+	if leaseDurationSeconds == 0 {
+		leaseDurationSeconds = int32(LeaseDurationSeconds)
+	}
+	observeClusterLeaseDuration(cluster.Name, leaseDurationSeconds)
+
+	// get the lease of a cluster, if the lease is not found, create it
+	leaseName := "managed-cluster-lease"
+	reason := reasonLeaseUpdateStopped
+	message := "Registration agent stopped updating its lease."
+	observedLease, err := c.leaseLister.Leases(cluster.Name).Get(leaseName)
+	switch {
+	case errors.IsNotFound(err):
+		if !cluster.DeletionTimestamp.IsZero() {
+			// the cluster is deleting, do nothing
+			break
+		}
+		lease := &coordv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      leaseName,
+				Namespace: cluster.Name,
+				Labels:    map[string]string{"open-cluster-management.io/cluster-name": cluster.Name},
+			},
+			Spec: coordv1.LeaseSpec{
+				HolderIdentity: pointer.StringPtr(leaseName),
+				RenewTime:      &metav1.MicroTime{Time: time.Now()},
+			},
+		}
+		if _, err := c.kubeClient.CoordinationV1().Leases(cluster.Name).Create(ctx, lease, metav1.CreateOptions{}); err != nil {
 			return err
 		}
-		if updated {
-			syncCtx.Recorder().Eventf("ManagedClusterAvailableConditionUpdated",
-				"update managed cluster %q available condition to unknown, due to its lease is not updated constantly",
-				cluster.Name)
+		return nil
+	case err != nil:
+		return err
+	case err == nil:
+		gracePeriod := time.Duration(leaseDurationTimes*cluster.Spec.LeaseDurationSeconds) * time.Second
+		// FIX: #183 avoid gracePeriod is zero, will non-stop update ManagedClusterLeaseUpdateStopped condition.
+		if gracePeriod == 0 {
+			gracePeriod = time.Duration(leaseDurationTimes*LeaseDurationSeconds) * time.Second
+		}
+		now := time.Now()
+		switch {
+		case MaxFutureClockSkew > 0 && observedLease.Spec.RenewTime.After(now.Add(MaxFutureClockSkew)):
+			// a renew time beyond the configured future skew bound is implausible even
+			// within the grace period, the clock of the spoke is badly misconfigured
+			reason = reasonClockSkewDetected
+			message = fmt.Sprintf("The lease renew time %s is more than %s ahead of the hub clock, the clock of the registration agent is skewed.",
+				observedLease.Spec.RenewTime.UTC().Format(time.RFC3339), MaxFutureClockSkew)
+		case observedLease.Spec.RenewTime.After(now.Add(gracePeriod)):
+			// a renew time ahead of the hub clock beyond the grace period is rejected,
+			// it would keep the lease fresh forever
+			reason = reasonLeaseClockSkewed
+			message = fmt.Sprintf("The lease renew time %s is ahead of the hub clock beyond the grace period, the clock of the registration agent is skewed.",
+				observedLease.Spec.RenewTime.UTC().Format(time.RFC3339))
+		case now.Before(observedLease.Spec.RenewTime.Add(gracePeriod)):
+			// the lease is constantly updated, only the injected predicate may still
+			// veto the availability based on additional signals. The lease the hub
+			// itself creates is fresh too; only a renew time the agent moved past the
+			// lease creation time proves the agent reported.
+			if observedLease.Spec.RenewTime.After(observedLease.CreationTimestamp.Time) {
+				observeTimeToFirstLease(cluster.Name, cluster.CreationTimestamp.Time, observedLease.Spec.RenewTime.Time)
+			}
+			return c.applyPredicate(ctx, syncCtx, cluster)
+		case !observedLease.Spec.RenewTime.After(observedLease.CreationTimestamp.Time):
+			// the renew time never moved past the creation time set by the hub
+			reason = reasonLeaseNeverRenewed
+			message = "Registration agent never updated the lease created by the hub."
 		}
 	}
+
+	// a freshly accepted cluster gets an initial grace period to start renewing its
+	// lease before it is marked unavailable
+	if InitialGracePeriod > 0 {
+		acceptedCondition := meta.FindStatusCondition(cluster.Status.Conditions, clusterv1.ManagedClusterConditionHubAccepted)
+		if acceptedCondition != nil && time.Since(acceptedCondition.LastTransitionTime.Time) < InitialGracePeriod {
+			return nil
+		}
+	}
+
+	// the lease is not constantly updated, update it to unknown
+	conditionUpdateFn := helpers.UpdateManagedClusterConditionFn(metav1.Condition{
+		Type:    clusterv1.ManagedClusterConditionAvailable,
+		Status:  metav1.ConditionUnknown,
+		Reason:  reason,
+		Message: message,
+	})
+	_, updated, err := helpers.UpdateManagedClusterStatus(ctx, c.clusterClient, cluster.Name, conditionUpdateFn)
+	if err != nil {
+		return err
+	}
+	if updated {
+		syncCtx.Recorder().Eventf("ManagedClusterAvailableConditionUpdated",
+			"update managed cluster %q available condition to unknown, due to its lease is not updated constantly",
+			cluster.Name)
+		c.publishTransition(cluster, string(metav1.ConditionUnknown), reason)
+	}
+	return nil
+}
+
+// applyPredicate consults the injected availability predicate for a cluster whose lease is
+// fresh. A veto marks the cluster unavailable with the reason and message returned by the
+// predicate; without a predicate the availability stays lease-only.
+func (c *leaseController) applyPredicate(ctx context.Context, syncCtx factory.SyncContext, cluster *clusterv1.ManagedCluster) error {
+	if c.predicate == nil {
+		return nil
+	}
+
+	veto, reason, message, err := c.predicate.Evaluate(cluster)
+	if err != nil {
+		return err
+	}
+	if !veto {
+		return nil
+	}
+
+	conditionUpdateFn := helpers.UpdateManagedClusterConditionFn(metav1.Condition{
+		Type:    clusterv1.ManagedClusterConditionAvailable,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	})
+	_, updated, err := helpers.UpdateManagedClusterStatus(ctx, c.clusterClient, cluster.Name, conditionUpdateFn)
+	if err != nil {
+		return err
+	}
+	if updated {
+		syncCtx.Recorder().Eventf("ManagedClusterAvailableConditionUpdated",
+			"update managed cluster %q available condition to false, its availability is vetoed despite a fresh lease: %s",
+			cluster.Name, message)
+		c.publishTransition(cluster, string(metav1.ConditionFalse), reason)
+	}
+	return nil
+}
+
+// forceUnavailable marks a cluster unavailable while its force-unavailable annotation
+// is set. Once the annotation is removed the lease based evaluation resumes and the
+// agent restores the availability with its next status update.
+func (c *leaseController) forceUnavailable(ctx context.Context, syncCtx factory.SyncContext, cluster *clusterv1.ManagedCluster) error {
+	conditionUpdateFn := helpers.UpdateManagedClusterConditionFn(metav1.Condition{
+		Type:    clusterv1.ManagedClusterConditionAvailable,
+		Status:  metav1.ConditionFalse,
+		Reason:  reasonForcedUnavailable,
+		Message: fmt.Sprintf("The cluster is forced unavailable by the %s annotation.", ForceUnavailableAnnotation),
+	})
+	_, updated, err := helpers.UpdateManagedClusterStatus(ctx, c.clusterClient, cluster.Name, conditionUpdateFn)
+	if err != nil {
+		return err
+	}
+	if updated {
+		syncCtx.Recorder().Eventf("ManagedClusterAvailableConditionUpdated",
+			"update managed cluster %q available condition to false, it is forced unavailable by the %s annotation",
+			cluster.Name, ForceUnavailableAnnotation)
+		c.publishTransition(cluster, string(metav1.ConditionFalse), reasonForcedUnavailable)
+	}
 	return nil
 }
+
+// publishTransition publishes the availability transition this controller made for the
+// given cluster to the configured sink. The old state is taken from the condition the
+// cluster had before the update.
+func (c *leaseController) publishTransition(cluster *clusterv1.ManagedCluster, newState, reason string) {
+	if c.sink == nil {
+		return
+	}
+	oldState := "None"
+	if condition := meta.FindStatusCondition(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable); condition != nil {
+		oldState = string(condition.Status)
+	}
+	c.sink.Publish(AvailabilityTransition{
+		Cluster:   cluster.Name,
+		OldState:  oldState,
+		NewState:  newState,
+		Reason:    reason,
+		Timestamp: time.Now(),
+	})
+}
@@ -11,6 +11,7 @@ import (
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 
+	coordv1 "k8s.io/api/coordination/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubeinformers "k8s.io/client-go/informers"
@@ -64,6 +65,43 @@ func TestSync(t *testing.T) {
 				testinghelpers.AssertManagedClusterCondition(t, actual.(*clusterv1.ManagedCluster).Status.Conditions, expected)
 			},
 		},
+		{
+			name:     "managed cluster never renewed its lease",
+			clusters: []runtime.Object{testinghelpers.NewAvailableManagedCluster()},
+			clusterLeases: []runtime.Object{
+				newNeverRenewedLease("managed-cluster-lease", now.Add(-10*time.Minute)),
+			},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				expected := metav1.Condition{
+					Type:    clusterv1.ManagedClusterConditionAvailable,
+					Status:  metav1.ConditionUnknown,
+					Reason:  "ManagedClusterLeaseNeverRenewed",
+					Message: "Registration agent never updated the lease created by the hub.",
+				}
+				testinghelpers.AssertActions(t, clusterActions, "get", "update")
+				actual := clusterActions[1].(clienttesting.UpdateActionImpl).Object
+				testinghelpers.AssertManagedClusterCondition(t, actual.(*clusterv1.ManagedCluster).Status.Conditions, expected)
+			},
+		},
+		{
+			name:     "managed cluster lease renew time is ahead of the hub clock",
+			clusters: []runtime.Object{testinghelpers.NewAvailableManagedCluster()},
+			clusterLeases: []runtime.Object{
+				testinghelpers.NewManagedClusterLease("managed-cluster-lease", now.Add(time.Hour)),
+			},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				expected := metav1.Condition{
+					Type:   clusterv1.ManagedClusterConditionAvailable,
+					Status: metav1.ConditionUnknown,
+					Reason: "ManagedClusterLeaseClockSkewed",
+					Message: fmt.Sprintf("The lease renew time %s is ahead of the hub clock beyond the grace period, the clock of the registration agent is skewed.",
+						now.Add(time.Hour).UTC().Format(time.RFC3339)),
+				}
+				testinghelpers.AssertActions(t, clusterActions, "get", "update")
+				actual := clusterActions[1].(clienttesting.UpdateActionImpl).Object
+				testinghelpers.AssertManagedClusterCondition(t, actual.(*clusterv1.ManagedCluster).Status.Conditions, expected)
+			},
+		},
 		{
 			name:          "managed cluster is available",
 			clusters:      []runtime.Object{testinghelpers.NewAvailableManagedCluster()},
@@ -72,6 +110,30 @@ func TestSync(t *testing.T) {
 				testinghelpers.AssertNoActions(t, clusterActions)
 			},
 		},
+		{
+			name:          "managed cluster is forced unavailable",
+			clusters:      []runtime.Object{newForcedUnavailableManagedCluster()},
+			clusterLeases: []runtime.Object{testinghelpers.NewManagedClusterLease("managed-cluster-lease", now)},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				expected := metav1.Condition{
+					Type:    clusterv1.ManagedClusterConditionAvailable,
+					Status:  metav1.ConditionFalse,
+					Reason:  "ManagedClusterForcedUnavailable",
+					Message: fmt.Sprintf("The cluster is forced unavailable by the %s annotation.", ForceUnavailableAnnotation),
+				}
+				testinghelpers.AssertActions(t, clusterActions, "get", "update")
+				actual := clusterActions[1].(clienttesting.UpdateActionImpl).Object
+				testinghelpers.AssertManagedClusterCondition(t, actual.(*clusterv1.ManagedCluster).Status.Conditions, expected)
+			},
+		},
+		{
+			name:          "normal evaluation resumes after the force annotation is cleared",
+			clusters:      []runtime.Object{newRestoredManagedCluster()},
+			clusterLeases: []runtime.Object{testinghelpers.NewManagedClusterLease("managed-cluster-lease", now)},
+			validateActions: func(t *testing.T, leaseActions, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
 		{
 			name:     "managed cluster is deleting",
 			clusters: []runtime.Object{newDeletingManagedCluster()},
@@ -120,6 +182,196 @@ func TestSync(t *testing.T) {
 	}
 }
 
+func TestInitialGracePeriod(t *testing.T) {
+	cases := []struct {
+		name            string
+		acceptedTime    time.Time
+		validateActions func(t *testing.T, clusterActions []clienttesting.Action)
+	}{
+		{
+			name:         "a just accepted cluster is not marked unavailable during the grace",
+			acceptedTime: now,
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+		{
+			name:         "the normal rules apply after the grace",
+			acceptedTime: now.Add(-time.Hour),
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, clusterActions, "get", "update")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() { InitialGracePeriod = 0 }()
+			InitialGracePeriod = 10 * time.Minute
+
+			cluster := newAcceptedManagedClusterAt(c.acceptedTime)
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster)
+
+			staleLease := testinghelpers.NewManagedClusterLease("managed-cluster-lease", now.Add(-10*time.Minute))
+			leaseClient := kubefake.NewSimpleClientset(staleLease)
+			leaseInformerFactory := kubeinformers.NewSharedInformerFactory(leaseClient, time.Minute*10)
+			leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore().Add(staleLease)
+
+			ctrl := &leaseController{
+				kubeClient:    leaseClient,
+				clusterClient: clusterClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
+			}
+			if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func TestMaxFutureClockSkew(t *testing.T) {
+	cases := []struct {
+		name            string
+		renewTime       time.Time
+		validateActions func(t *testing.T, clusterActions []clienttesting.Action)
+	}{
+		{
+			name:      "a lease renewed slightly ahead of the hub clock stays fresh",
+			renewTime: now.Add(30 * time.Second),
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+		{
+			name:      "a lease renewed beyond the future skew bound is rejected",
+			renewTime: now.Add(2 * time.Minute),
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, clusterActions, "get", "update")
+				actual := clusterActions[1].(clienttesting.UpdateActionImpl).Object
+				testinghelpers.AssertManagedClusterCondition(t, actual.(*clusterv1.ManagedCluster).Status.Conditions, metav1.Condition{
+					Type:   clusterv1.ManagedClusterConditionAvailable,
+					Status: metav1.ConditionUnknown,
+					Reason: "ClockSkewDetected",
+					Message: fmt.Sprintf("The lease renew time %s is more than %s ahead of the hub clock, the clock of the registration agent is skewed.",
+						now.Add(2*time.Minute).UTC().Format(time.RFC3339), time.Minute),
+				})
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() { MaxFutureClockSkew = 0 }()
+			MaxFutureClockSkew = time.Minute
+
+			cluster := testinghelpers.NewAvailableManagedCluster()
+			// a 60 seconds lease duration gives a 5 minutes grace period, so the 1 minute
+			// future skew bound is the deciding check in these cases
+			cluster.Spec.LeaseDurationSeconds = 60
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster)
+
+			futureLease := testinghelpers.NewManagedClusterLease("managed-cluster-lease", c.renewTime)
+			leaseClient := kubefake.NewSimpleClientset(futureLease)
+			leaseInformerFactory := kubeinformers.NewSharedInformerFactory(leaseClient, time.Minute*10)
+			leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore().Add(futureLease)
+
+			ctrl := &leaseController{
+				kubeClient:    leaseClient,
+				clusterClient: clusterClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
+			}
+			if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func TestStartupPass(t *testing.T) {
+	clusters := []runtime.Object{testinghelpers.NewAvailableManagedCluster()}
+	clusterClient := clusterfake.NewSimpleClientset(clusters...)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+	for _, cluster := range clusters {
+		clusterStore.Add(cluster)
+	}
+
+	staleLease := testinghelpers.NewManagedClusterLease("managed-cluster-lease", now.Add(-5*time.Minute))
+	leaseClient := kubefake.NewSimpleClientset(staleLease)
+	leaseInformerFactory := kubeinformers.NewSharedInformerFactory(leaseClient, time.Minute*10)
+	leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore().Add(staleLease)
+
+	ctrl := &leaseController{
+		kubeClient:    leaseClient,
+		clusterClient: clusterClient,
+		clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
+	}
+	if err := ctrl.startupPass(context.TODO(), testinghelpers.NewFakeSyncContext(t, "")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	expected := metav1.Condition{
+		Type:    clusterv1.ManagedClusterConditionAvailable,
+		Status:  metav1.ConditionUnknown,
+		Reason:  "ManagedClusterLeaseUpdateStopped",
+		Message: "Registration agent stopped updating its lease.",
+	}
+	clusterActions := clusterClient.Actions()
+	testinghelpers.AssertActions(t, clusterActions, "get", "update")
+	actual := clusterActions[1].(clienttesting.UpdateActionImpl).Object
+	testinghelpers.AssertManagedClusterCondition(t, actual.(*clusterv1.ManagedCluster).Status.Conditions, expected)
+}
+
+// newNeverRenewedLease returns a lease whose renew time never moved past the creation
+// time set by the hub.
+func newNeverRenewedLease(name string, creationTime time.Time) *coordv1.Lease {
+	lease := testinghelpers.NewManagedClusterLease(name, creationTime)
+	lease.CreationTimestamp = metav1.NewTime(creationTime)
+	return lease
+}
+
+// newAcceptedManagedClusterAt returns an available managed cluster whose accepted
+// condition transitioned at the given time.
+func newAcceptedManagedClusterAt(acceptedTime time.Time) *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAvailableManagedCluster()
+	for i := range cluster.Status.Conditions {
+		if cluster.Status.Conditions[i].Type == clusterv1.ManagedClusterConditionHubAccepted {
+			cluster.Status.Conditions[i].LastTransitionTime = metav1.NewTime(acceptedTime)
+		}
+	}
+	return cluster
+}
+
+// newForcedUnavailableManagedCluster returns an available managed cluster carrying the
+// force-unavailable annotation.
+func newForcedUnavailableManagedCluster() *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAvailableManagedCluster()
+	cluster.Annotations = map[string]string{ForceUnavailableAnnotation: "true"}
+	return cluster
+}
+
+// newRestoredManagedCluster returns a managed cluster that was forced unavailable before
+// but carries the annotation no longer.
+func newRestoredManagedCluster() *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAvailableManagedCluster()
+	for i := range cluster.Status.Conditions {
+		if cluster.Status.Conditions[i].Type == clusterv1.ManagedClusterConditionAvailable {
+			cluster.Status.Conditions[i].Status = metav1.ConditionFalse
+			cluster.Status.Conditions[i].Reason = "ManagedClusterForcedUnavailable"
+		}
+	}
+	return cluster
+}
+
 func newDeletingManagedCluster() *clusterv1.ManagedCluster {
 	now := metav1.Now()
 	cluster := testinghelpers.NewAcceptedManagedCluster()
@@ -0,0 +1,87 @@
+package lease
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+
+	registrationmetrics "open-cluster-management.io/registration/pkg/metrics"
+)
+
+// clusterLeaseDuration exports the effective lease duration of each managed cluster the
+// lease controller observes. The gauge carries one series per cluster and is intended
+// for modest fleet sizes; for large fleets aggregate or drop the managed_cluster label
+// in the collection pipeline.
+var clusterLeaseDuration *metrics.GaugeVec
+
+func init() {
+	registrationmetrics.Register(func(prefix string) metrics.Registerable {
+		clusterLeaseDuration = metrics.NewGaugeVec(
+			&metrics.GaugeOpts{
+				Name: registrationmetrics.Prefixed(prefix, "registration_cluster_lease_duration_seconds"),
+				Help: "The effective lease duration in seconds of a managed cluster.",
+			},
+			[]string{"managed_cluster"},
+		)
+		return clusterLeaseDuration
+	})
+}
+
+// observeClusterLeaseDuration records the effective lease duration of a managed cluster.
+// It does nothing before the metrics registration completed.
+func observeClusterLeaseDuration(clusterName string, leaseDurationSeconds int32) {
+	if clusterLeaseDuration == nil {
+		return
+	}
+	clusterLeaseDuration.WithLabelValues(clusterName).Set(float64(leaseDurationSeconds))
+}
+
+// timeToFirstLease exports how long managed clusters take from being created to actually
+// reporting, the duration between the creation timestamp of a cluster and its first
+// observed fresh lease.
+var timeToFirstLease *metrics.Histogram
+
+// firstLeaseObserved tracks the clusters whose time to first lease has been recorded, so
+// each cluster is observed only once per controller process.
+var (
+	firstLeaseObservedLock sync.Mutex
+	firstLeaseObserved     = map[string]bool{}
+)
+
+func init() {
+	registrationmetrics.Register(func(prefix string) metrics.Registerable {
+		timeToFirstLease = metrics.NewHistogram(
+			&metrics.HistogramOpts{
+				Name: registrationmetrics.Prefixed(prefix, "registration_time_to_first_lease_seconds"),
+				Help: "The duration in seconds between the creation of a managed cluster and its first observed fresh lease.",
+				// from seconds for clusters joining promptly up to roughly half a day
+				// for clusters pending manual approval
+				Buckets: metrics.ExponentialBuckets(10, 2, 12),
+			},
+		)
+		return timeToFirstLease
+	})
+}
+
+// observeTimeToFirstLease records the duration between the creation of a managed cluster
+// and its first observed fresh lease, taken from the lease renew time. Later fresh leases
+// of the same cluster are not recorded again. It does nothing before the metrics
+// registration completed or without a creation timestamp on the cluster.
+func observeTimeToFirstLease(clusterName string, creationTime, renewTime time.Time) {
+	if timeToFirstLease == nil || creationTime.IsZero() {
+		return
+	}
+	firstLeaseObservedLock.Lock()
+	defer firstLeaseObservedLock.Unlock()
+	if firstLeaseObserved[clusterName] {
+		return
+	}
+	firstLeaseObserved[clusterName] = true
+
+	duration := renewTime.Sub(creationTime)
+	if duration < 0 {
+		duration = 0
+	}
+	timeToFirstLease.Observe(duration.Seconds())
+}
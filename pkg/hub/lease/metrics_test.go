@@ -0,0 +1,165 @@
+package lease
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+	registrationmetrics "open-cluster-management.io/registration/pkg/metrics"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+func TestClusterLeaseDurationMetric(t *testing.T) {
+	// complete the deferred metrics registration with the default prefix
+	registrationmetrics.SetPrefix("")
+
+	cluster := testinghelpers.NewAvailableManagedCluster()
+	cluster.Spec.LeaseDurationSeconds = 90
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster)
+
+	lease := testinghelpers.NewManagedClusterLease("managed-cluster-lease", now)
+	leaseClient := kubefake.NewSimpleClientset(lease)
+	leaseInformerFactory := kubeinformers.NewSharedInformerFactory(leaseClient, time.Minute*10)
+	leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore().Add(lease)
+
+	ctrl := &leaseController{
+		kubeClient:    leaseClient,
+		clusterClient: clusterClient,
+		clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
+	}
+	if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	found := false
+	for _, family := range families {
+		if family.GetName() != "registration_cluster_lease_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() != "managed_cluster" || label.GetValue() != testinghelpers.TestManagedClusterName {
+					continue
+				}
+				found = true
+				if actual := metric.GetGauge().GetValue(); actual != 90 {
+					t.Errorf("expected gauge value 90 but got: %v", actual)
+				}
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected a gauge for managed cluster %q to be exported", testinghelpers.TestManagedClusterName)
+	}
+}
+
+func TestTimeToFirstLeaseMetric(t *testing.T) {
+	// complete the deferred metrics registration with the default prefix
+	registrationmetrics.SetPrefix("")
+
+	// the cluster was created five minutes before its lease was first renewed
+	cluster := testinghelpers.NewAvailableManagedCluster()
+	cluster.CreationTimestamp = metav1.NewTime(now.Add(-5 * time.Minute))
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster)
+
+	lease := testinghelpers.NewManagedClusterLease("managed-cluster-lease", now)
+	leaseClient := kubefake.NewSimpleClientset(lease)
+	leaseInformerFactory := kubeinformers.NewSharedInformerFactory(leaseClient, time.Minute*10)
+	leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore().Add(lease)
+
+	ctrl := &leaseController{
+		kubeClient:    leaseClient,
+		clusterClient: clusterClient,
+		clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
+	}
+
+	// sync twice, the cluster must be recorded only once
+	for i := 0; i < 2; i++ {
+		if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "")); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	found := false
+	for _, family := range families {
+		if family.GetName() != "registration_time_to_first_lease_seconds" {
+			continue
+		}
+		found = true
+		for _, metric := range family.GetMetric() {
+			if actual := metric.GetHistogram().GetSampleCount(); actual != 1 {
+				t.Errorf("expected one observation but got: %v", actual)
+			}
+			if actual := metric.GetHistogram().GetSampleSum(); actual != 300 {
+				t.Errorf("expected an observation of 300 seconds but got: %v", actual)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected the time to first lease histogram to be exported")
+	}
+}
+
+func TestTimeToFirstLeaseMetricNeverRenewedLease(t *testing.T) {
+	// complete the deferred metrics registration with the default prefix
+	registrationmetrics.SetPrefix("")
+
+	// use a dedicated cluster name, the time to first lease of a cluster is
+	// recorded at most once per process
+	clusterName := "never-renewed-cluster"
+	cluster := testinghelpers.NewAvailableManagedCluster()
+	cluster.Name = clusterName
+	cluster.CreationTimestamp = metav1.NewTime(now.Add(-5 * time.Minute))
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster)
+
+	// the hub created the lease itself, the renew time never moved past the
+	// lease creation time
+	lease := testinghelpers.NewManagedClusterLease("managed-cluster-lease", now)
+	lease.Namespace = clusterName
+	lease.CreationTimestamp = metav1.NewTime(now)
+	leaseClient := kubefake.NewSimpleClientset(lease)
+	leaseInformerFactory := kubeinformers.NewSharedInformerFactory(leaseClient, time.Minute*10)
+	leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore().Add(lease)
+
+	ctrl := &leaseController{
+		kubeClient:    leaseClient,
+		clusterClient: clusterClient,
+		clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
+	}
+	if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	firstLeaseObservedLock.Lock()
+	observed := firstLeaseObserved[clusterName]
+	firstLeaseObservedLock.Unlock()
+	if observed {
+		t.Errorf("expected no time to first lease observation for a lease the agent never renewed")
+	}
+}
@@ -0,0 +1,82 @@
+package lease
+
+import (
+	"fmt"
+	"strings"
+
+	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// CriticalAddOns lists the names of the add-ons whose availability a managed cluster must
+// report for the cluster itself to stay available, despite a fresh lease. An empty list
+// keeps the availability lease-only. It is exposed as a command line flag.
+var CriticalAddOns []string
+
+// addOnAvailableConditionType is the condition type reporting the availability of a
+// ManagedClusterAddOn.
+const addOnAvailableConditionType = "Available"
+
+// reasonCriticalAddOnNotAvailable identifies a cluster that renews its lease but does not
+// report all of its critical add-ons as available.
+const reasonCriticalAddOnNotAvailable = "ManagedClusterCriticalAddOnNotAvailable"
+
+// AvailabilityPredicate vetoes the availability of a managed cluster based on signals
+// beyond the lease freshness. It is only consulted for clusters whose lease is fresh; a
+// veto marks the cluster unavailable with the returned reason and message.
+type AvailabilityPredicate interface {
+	// Evaluate returns veto true if the given cluster must not be considered available.
+	Evaluate(cluster *clusterv1.ManagedCluster) (veto bool, reason, message string, err error)
+}
+
+// addOnAvailabilityPredicate vetoes the availability of a cluster that does not report
+// all of the configured critical add-ons as available in its cluster namespace.
+type addOnAvailabilityPredicate struct {
+	addOnLister    addonlisterv1alpha1.ManagedClusterAddOnLister
+	criticalAddOns []string
+}
+
+// NewAddOnAvailabilityPredicate returns an availability predicate that vetoes a cluster
+// unless every one of the given add-ons exists in the cluster namespace and reports the
+// available condition true.
+func NewAddOnAvailabilityPredicate(addOnLister addonlisterv1alpha1.ManagedClusterAddOnLister, criticalAddOns []string) AvailabilityPredicate {
+	return &addOnAvailabilityPredicate{
+		addOnLister:    addOnLister,
+		criticalAddOns: criticalAddOns,
+	}
+}
+
+// NewConfiguredAvailabilityPredicate returns the add-on availability predicate configured
+// by CriticalAddOns, or nil if no critical add-on is configured and the availability stays
+// lease-only.
+func NewConfiguredAvailabilityPredicate(addOnLister addonlisterv1alpha1.ManagedClusterAddOnLister) AvailabilityPredicate {
+	if len(CriticalAddOns) == 0 {
+		return nil
+	}
+	return NewAddOnAvailabilityPredicate(addOnLister, CriticalAddOns)
+}
+
+func (p *addOnAvailabilityPredicate) Evaluate(cluster *clusterv1.ManagedCluster) (bool, string, string, error) {
+	notAvailable := []string{}
+	for _, addOnName := range p.criticalAddOns {
+		addOn, err := p.addOnLister.ManagedClusterAddOns(cluster.Name).Get(addOnName)
+		switch {
+		case errors.IsNotFound(err):
+			notAvailable = append(notAvailable, addOnName)
+			continue
+		case err != nil:
+			return false, "", "", err
+		}
+		if !meta.IsStatusConditionTrue(addOn.Status.Conditions, addOnAvailableConditionType) {
+			notAvailable = append(notAvailable, addOnName)
+		}
+	}
+	if len(notAvailable) == 0 {
+		return false, "", "", nil
+	}
+	return true, reasonCriticalAddOnNotAvailable,
+		fmt.Sprintf("The critical add-ons %q are not available on the managed cluster.", strings.Join(notAvailable, ",")), nil
+}
@@ -0,0 +1,133 @@
+package lease
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestAvailabilityPredicate(t *testing.T) {
+	cases := []struct {
+		name            string
+		addOns          []runtime.Object
+		validateActions func(t *testing.T, clusterActions []clienttesting.Action)
+	}{
+		{
+			name: "the predicate forces unavailable despite a fresh lease",
+			addOns: []runtime.Object{
+				newManagedClusterAddOn("critical-addon", metav1.ConditionFalse),
+			},
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				expected := metav1.Condition{
+					Type:    clusterv1.ManagedClusterConditionAvailable,
+					Status:  metav1.ConditionFalse,
+					Reason:  "ManagedClusterCriticalAddOnNotAvailable",
+					Message: "The critical add-ons \"critical-addon\" are not available on the managed cluster.",
+				}
+				testinghelpers.AssertActions(t, clusterActions, "get", "update")
+				actual := clusterActions[1].(clienttesting.UpdateActionImpl).Object
+				testinghelpers.AssertManagedClusterCondition(t, actual.(*clusterv1.ManagedCluster).Status.Conditions, expected)
+			},
+		},
+		{
+			name: "a missing critical add-on forces unavailable",
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, clusterActions, "get", "update")
+			},
+		},
+		{
+			name: "an available critical add-on keeps the cluster available",
+			addOns: []runtime.Object{
+				newManagedClusterAddOn("critical-addon", metav1.ConditionTrue),
+			},
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := testinghelpers.NewAvailableManagedCluster()
+			clusterClient := clusterfake.NewSimpleClientset(cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster)
+
+			freshLease := testinghelpers.NewManagedClusterLease("managed-cluster-lease", now)
+			leaseClient := kubefake.NewSimpleClientset(freshLease)
+			leaseInformerFactory := kubeinformers.NewSharedInformerFactory(leaseClient, time.Minute*10)
+			leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore().Add(freshLease)
+
+			addOnClient := addonfake.NewSimpleClientset(c.addOns...)
+			addOnInformerFactory := addoninformers.NewSharedInformerFactory(addOnClient, time.Minute*10)
+			addOnStore := addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore()
+			for _, addOn := range c.addOns {
+				addOnStore.Add(addOn)
+			}
+
+			ctrl := &leaseController{
+				kubeClient:    leaseClient,
+				clusterClient: clusterClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
+				predicate: NewAddOnAvailabilityPredicate(
+					addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+					[]string{"critical-addon"},
+				),
+			}
+			if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "")); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func TestNewConfiguredAvailabilityPredicate(t *testing.T) {
+	defer func() { CriticalAddOns = nil }()
+
+	CriticalAddOns = nil
+	if predicate := NewConfiguredAvailabilityPredicate(nil); predicate != nil {
+		t.Errorf("expected no predicate without configured critical add-ons, but got %v", predicate)
+	}
+
+	CriticalAddOns = []string{"critical-addon"}
+	if predicate := NewConfiguredAvailabilityPredicate(nil); predicate == nil {
+		t.Error("expected a predicate with configured critical add-ons, but got none")
+	}
+}
+
+// newManagedClusterAddOn returns a ManagedClusterAddOn in the test cluster namespace with
+// the given available condition status.
+func newManagedClusterAddOn(name string, available metav1.ConditionStatus) *addonv1alpha1.ManagedClusterAddOn {
+	return &addonv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testinghelpers.TestManagedClusterName,
+			Name:      name,
+		},
+		Status: addonv1alpha1.ManagedClusterAddOnStatus{
+			Conditions: []metav1.Condition{
+				{
+					Type:    addOnAvailableConditionType,
+					Status:  available,
+					Reason:  "AddOnChecked",
+					Message: "add-on status checked",
+				},
+			},
+		},
+	}
+}
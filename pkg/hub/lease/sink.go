@@ -0,0 +1,114 @@
+package lease
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// AvailabilitySinkFile is the file the availability transitions of managed clusters are
+// written to as JSON lines, "-" writes to stdout. An empty path disables the sink. It is
+// exposed as a command line flag.
+var AvailabilitySinkFile string
+
+// transitionBufferSize bounds the number of availability transitions buffered for a slow
+// sink before further transitions are dropped.
+const transitionBufferSize = 256
+
+// AvailabilityTransition is the structured payload published for an availability
+// transition of a managed cluster.
+type AvailabilityTransition struct {
+	Cluster   string    `json:"cluster"`
+	OldState  string    `json:"oldState"`
+	NewState  string    `json:"newState"`
+	Reason    string    `json:"reason"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AvailabilitySink consumes the availability transitions of managed clusters, e.g. to
+// forward them to an external message queue.
+type AvailabilitySink interface {
+	// Publish is called with each availability transition the lease controller makes.
+	// It must not block, a slow consumer must buffer or drop instead of stalling the
+	// reconciliation.
+	Publish(transition AvailabilityTransition)
+}
+
+// NewConfiguredAvailabilitySink returns the availability sink configured by
+// AvailabilitySinkFile, buffered so a slow writer does not stall the controller, or nil
+// if no sink is configured.
+func NewConfiguredAvailabilitySink() (AvailabilitySink, error) {
+	switch AvailabilitySinkFile {
+	case "":
+		return nil, nil
+	case "-":
+		return NewBufferedAvailabilitySink(NewWriterAvailabilitySink(os.Stdout), transitionBufferSize), nil
+	default:
+		// #nosec G304 -- the path is an operator provided command line flag
+		file, err := os.OpenFile(AvailabilitySinkFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open the availability sink file %q: %w", AvailabilitySinkFile, err)
+		}
+		return NewBufferedAvailabilitySink(NewWriterAvailabilitySink(file), transitionBufferSize), nil
+	}
+}
+
+// bufferedAvailabilitySink decouples the publishing controller from a slow delegate with
+// a bounded buffer. Transitions beyond the buffer capacity are dropped with a warning
+// instead of stalling the reconciliation.
+type bufferedAvailabilitySink struct {
+	delegate    AvailabilitySink
+	transitions chan AvailabilityTransition
+}
+
+// NewBufferedAvailabilitySink wraps the given sink with a bounded buffer of the given
+// capacity that is drained by a background goroutine.
+func NewBufferedAvailabilitySink(delegate AvailabilitySink, capacity int) AvailabilitySink {
+	s := &bufferedAvailabilitySink{
+		delegate:    delegate,
+		transitions: make(chan AvailabilityTransition, capacity),
+	}
+	go func() {
+		for transition := range s.transitions {
+			s.delegate.Publish(transition)
+		}
+	}()
+	return s
+}
+
+func (s *bufferedAvailabilitySink) Publish(transition AvailabilityTransition) {
+	select {
+	case s.transitions <- transition:
+	default:
+		klog.Warningf("The availability transition of managed cluster %q is dropped, the availability sink cannot keep up", transition.Cluster)
+	}
+}
+
+// writerAvailabilitySink writes each availability transition as a JSON line, an example
+// sink implementation for consumers that tail a file or the controller output.
+type writerAvailabilitySink struct {
+	lock   sync.Mutex
+	writer io.Writer
+}
+
+// NewWriterAvailabilitySink returns a sink that writes each transition as a JSON line to
+// the given writer.
+func NewWriterAvailabilitySink(writer io.Writer) AvailabilitySink {
+	return &writerAvailabilitySink{writer: writer}
+}
+
+func (s *writerAvailabilitySink) Publish(transition AvailabilityTransition) {
+	encoded, err := json.Marshal(transition)
+	if err != nil {
+		klog.Warningf("Unable to encode the availability transition of managed cluster %q: %v", transition.Cluster, err)
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	fmt.Fprintln(s.writer, string(encoded))
+}
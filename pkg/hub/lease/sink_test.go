@@ -0,0 +1,136 @@
+package lease
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+// recordingAvailabilitySink collects the published transitions for assertions.
+type recordingAvailabilitySink struct {
+	transitions []AvailabilityTransition
+}
+
+func (s *recordingAvailabilitySink) Publish(transition AvailabilityTransition) {
+	s.transitions = append(s.transitions, transition)
+}
+
+func TestAvailabilityTransitionPublished(t *testing.T) {
+	cluster := testinghelpers.NewAvailableManagedCluster()
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster)
+
+	// the lease stopped being renewed, the controller flips the availability to unknown
+	lease := testinghelpers.NewManagedClusterLease("managed-cluster-lease", now.Add(-5*time.Minute))
+	leaseClient := kubefake.NewSimpleClientset(lease)
+	leaseInformerFactory := kubeinformers.NewSharedInformerFactory(leaseClient, time.Minute*10)
+	leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore().Add(lease)
+
+	sink := &recordingAvailabilitySink{}
+	ctrl := &leaseController{
+		kubeClient:    leaseClient,
+		clusterClient: clusterClient,
+		clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
+		sink:          sink,
+	}
+	if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	if len(sink.transitions) != 1 {
+		t.Fatalf("expected one published transition but got: %d", len(sink.transitions))
+	}
+	transition := sink.transitions[0]
+	if transition.Cluster != testinghelpers.TestManagedClusterName {
+		t.Errorf("expected cluster %q but got: %q", testinghelpers.TestManagedClusterName, transition.Cluster)
+	}
+	if transition.OldState != "True" {
+		t.Errorf("expected old state \"True\" but got: %q", transition.OldState)
+	}
+	if transition.NewState != "Unknown" {
+		t.Errorf("expected new state \"Unknown\" but got: %q", transition.NewState)
+	}
+	if transition.Reason != reasonLeaseUpdateStopped {
+		t.Errorf("expected reason %q but got: %q", reasonLeaseUpdateStopped, transition.Reason)
+	}
+	if transition.Timestamp.IsZero() {
+		t.Errorf("expected a timestamp to be set")
+	}
+
+	// a fresh lease does not publish another transition
+	freshLease := testinghelpers.NewManagedClusterLease("managed-cluster-lease", time.Now())
+	leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore().Update(freshLease)
+	if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "")); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+	if len(sink.transitions) != 1 {
+		t.Errorf("expected no further published transition but got: %d", len(sink.transitions))
+	}
+}
+
+func TestWriterAvailabilitySink(t *testing.T) {
+	timestamp := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	buf := &bytes.Buffer{}
+	sink := NewWriterAvailabilitySink(buf)
+	sink.Publish(AvailabilityTransition{
+		Cluster:   "cluster1",
+		OldState:  "True",
+		NewState:  "Unknown",
+		Reason:    reasonLeaseUpdateStopped,
+		Timestamp: timestamp,
+	})
+
+	expected := `{"cluster":"cluster1","oldState":"True","newState":"Unknown","reason":"ManagedClusterLeaseUpdateStopped","timestamp":"2020-01-01T00:00:00Z"}` + "\n"
+	if actual := buf.String(); actual != expected {
+		t.Errorf("expected %q but got: %q", expected, actual)
+	}
+}
+
+func TestBufferedAvailabilitySink(t *testing.T) {
+	published := make(chan AvailabilityTransition, 1)
+	sink := NewBufferedAvailabilitySink(publishFunc(func(transition AvailabilityTransition) {
+		published <- transition
+	}), 1)
+
+	sink.Publish(AvailabilityTransition{Cluster: "cluster1"})
+	select {
+	case transition := <-published:
+		if transition.Cluster != "cluster1" {
+			t.Errorf("expected cluster %q but got: %q", "cluster1", transition.Cluster)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the buffered transition to be delivered")
+	}
+
+	// a full buffer drops transitions instead of blocking the publisher
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			sink.Publish(AvailabilityTransition{Cluster: fmt.Sprintf("cluster%d", i)})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected publishing to a full buffer not to block")
+	}
+}
+
+// publishFunc adapts a function to the AvailabilitySink interface.
+type publishFunc func(transition AvailabilityTransition)
+
+func (f publishFunc) Publish(transition AvailabilityTransition) {
+	f(transition)
+}
@@ -32,6 +32,30 @@ const (
 //go:embed manifests
 var manifestFiles embed.FS
 
+// ControllerWorkers is the number of sync workers the managed cluster controller runs
+// with. The queue is keyed by cluster name, so a single cluster is never reconciled
+// concurrently; more workers reconcile more clusters in parallel during onboarding. The
+// hub controller runs under leader election, only the leader runs the workers.
+var ControllerWorkers = 1
+
+// initialConditions is the starting set of conditions a newly observed managed cluster
+// gets before any controller has determined the actual state, so dashboards show a
+// deterministic starting state instead of an empty status.
+var initialConditions = []metav1.Condition{
+	{
+		Type:    v1.ManagedClusterConditionHubAccepted,
+		Status:  metav1.ConditionUnknown,
+		Reason:  "AcceptanceNotDetermined",
+		Message: "The acceptance of the managed cluster has not been determined yet",
+	},
+	{
+		Type:    v1.ManagedClusterConditionAvailable,
+		Status:  metav1.ConditionUnknown,
+		Reason:  "AvailabilityNotReported",
+		Message: "The registration agent has not reported the availability of the managed cluster yet",
+	},
+}
+
 var staticFiles = []string{
 	"manifests/managedcluster-clusterrole.yaml",
 	"manifests/managedcluster-clusterrolebinding.yaml",
@@ -45,6 +69,9 @@ type managedClusterController struct {
 	clusterClient clientset.Interface
 	clusterLister listerv1.ManagedClusterLister
 	cache         resourceapply.ResourceCache
+	// rbacMutator may adjust the policy rules of the generated bootstrap RBAC before
+	// it is applied, nil keeps the generated rules
+	rbacMutator   helpers.RBACRulesMutator
 	eventRecorder events.Recorder
 }
 
@@ -53,12 +80,14 @@ func NewManagedClusterController(
 	kubeClient kubernetes.Interface,
 	clusterClient clientset.Interface,
 	clusterInformer informerv1.ManagedClusterInformer,
+	rbacMutator helpers.RBACRulesMutator,
 	recorder events.Recorder) factory.Controller {
 	c := &managedClusterController{
 		kubeClient:    kubeClient,
 		clusterClient: clusterClient,
 		clusterLister: clusterInformer.Lister(),
-		cache:         resourceapply.NewResourceCache(),
+		cache:         newLockedResourceCache(),
+		rbacMutator:   rbacMutator,
 		eventRecorder: recorder.WithComponentSuffix("managed-cluster-controller"),
 	}
 	return factory.New().
@@ -106,6 +135,14 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 		return c.removeManagedClusterFinalizer(ctx, managedCluster)
 	}
 
+	// A cluster seen for the first time has an empty status, give it the defined
+	// starting conditions before anything else happens to it.
+	if len(managedCluster.Status.Conditions) == 0 {
+		if err := c.initializeConditions(ctx, managedClusterName); err != nil {
+			return err
+		}
+	}
+
 	if !managedCluster.Spec.HubAcceptsClient {
 		// Current spoke cluster is not accepted, do nothing.
 		if !meta.IsStatusConditionTrue(managedCluster.Status.Conditions, v1.ManagedClusterConditionHubAccepted) {
@@ -148,7 +185,7 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 		resourceapply.NewKubeClientHolder(c.kubeClient),
 		syncCtx.Recorder(),
 		c.cache,
-		helpers.ManagedClusterAssetFn(manifestFiles, managedClusterName),
+		helpers.ManagedClusterAssetFnWithRBACMutator(manifestFiles, managedClusterName, c.rbacMutator),
 		applyFiles...,
 	)
 	errs := []error{}
@@ -187,6 +224,33 @@ func (c *managedClusterController) sync(ctx context.Context, syncCtx factory.Syn
 	return operatorhelpers.NewMultiLineAggregate(errs)
 }
 
+// initializeConditions adds the initial conditions to the managed cluster. Each
+// condition is only added while it is still absent, a condition another controller set
+// in the meantime is never overwritten.
+func (c *managedClusterController) initializeConditions(ctx context.Context, managedClusterName string) error {
+	_, updated, err := helpers.UpdateManagedClusterStatus(
+		ctx,
+		c.clusterClient,
+		managedClusterName,
+		func(oldStatus *v1.ManagedClusterStatus) error {
+			for _, condition := range initialConditions {
+				if meta.FindStatusCondition(oldStatus.Conditions, condition.Type) != nil {
+					continue
+				}
+				meta.SetStatusCondition(&oldStatus.Conditions, condition)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if updated {
+		c.eventRecorder.Eventf("ManagedClusterConditionsInitialized", "The initial conditions of managed cluster %s are set", managedClusterName)
+	}
+	return nil
+}
+
 func (c *managedClusterController) removeManagedClusterResources(ctx context.Context, managedClusterName string) error {
 	errs := []error{}
 	// Clean up managed cluster manifests
@@ -2,6 +2,9 @@ package managedcluster
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,6 +16,7 @@ import (
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubefake "k8s.io/client-go/kubernetes/fake"
@@ -51,10 +55,47 @@ func TestSyncManagedCluster(t *testing.T) {
 					Reason:  "HubClusterAdminAccepted",
 					Message: "Accepted by hub cluster admin",
 				}
+				// the first update initializes the conditions of the new cluster
+				testinghelpers.AssertActions(t, actions, "get", "update", "get", "update")
+				actual := actions[3].(clienttesting.UpdateActionImpl).Object
+				managedCluster := actual.(*v1.ManagedCluster)
+				testinghelpers.AssertManagedClusterCondition(t, managedCluster.Status.Conditions, expectedCondition)
+			},
+		},
+		{
+			name:            "initialize the conditions of a new spoke cluster",
+			startingObjects: []runtime.Object{newUnacceptedManagedCluster()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
 				testinghelpers.AssertActions(t, actions, "get", "update")
 				actual := actions[1].(clienttesting.UpdateActionImpl).Object
 				managedCluster := actual.(*v1.ManagedCluster)
-				testinghelpers.AssertManagedClusterCondition(t, managedCluster.Status.Conditions, expectedCondition)
+				testinghelpers.AssertManagedClusterCondition(t, managedCluster.Status.Conditions, metav1.Condition{
+					Type:    v1.ManagedClusterConditionHubAccepted,
+					Status:  metav1.ConditionUnknown,
+					Reason:  "AcceptanceNotDetermined",
+					Message: "The acceptance of the managed cluster has not been determined yet",
+				})
+				testinghelpers.AssertManagedClusterCondition(t, managedCluster.Status.Conditions, metav1.Condition{
+					Type:    v1.ManagedClusterConditionAvailable,
+					Status:  metav1.ConditionUnknown,
+					Reason:  "AvailabilityNotReported",
+					Message: "The registration agent has not reported the availability of the managed cluster yet",
+				})
+			},
+		},
+		{
+			name:            "keep conditions set by other controllers",
+			startingObjects: []runtime.Object{newAvailableAcceptingManagedCluster()},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "update")
+				actual := actions[1].(clienttesting.UpdateActionImpl).Object
+				managedCluster := actual.(*v1.ManagedCluster)
+				testinghelpers.AssertManagedClusterCondition(t, managedCluster.Status.Conditions, metav1.Condition{
+					Type:    v1.ManagedClusterConditionAvailable,
+					Status:  metav1.ConditionTrue,
+					Reason:  "ManagedClusterAvailable",
+					Message: "Managed cluster is available",
+				})
 			},
 		},
 		{
@@ -101,7 +142,7 @@ func TestSyncManagedCluster(t *testing.T) {
 				clusterStore.Add(cluster)
 			}
 
-			ctrl := managedClusterController{kubeClient, clusterClient, clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(), resourceapply.NewResourceCache(), eventstesting.NewTestingEventRecorder(t)}
+			ctrl := managedClusterController{kubeClient, clusterClient, clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(), resourceapply.NewResourceCache(), nil, eventstesting.NewTestingEventRecorder(t)}
 			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
 			if syncErr != nil {
 				t.Errorf("unexpected err: %v", syncErr)
@@ -111,3 +152,109 @@ func TestSyncManagedCluster(t *testing.T) {
 		})
 	}
 }
+
+func newUnacceptedManagedCluster() *v1.ManagedCluster {
+	managedCluster := testinghelpers.NewAcceptingManagedCluster()
+	managedCluster.Spec.HubAcceptsClient = false
+	return managedCluster
+}
+
+func newAvailableAcceptingManagedCluster() *v1.ManagedCluster {
+	managedCluster := testinghelpers.NewAcceptingManagedCluster()
+	managedCluster.Status.Conditions = append(managedCluster.Status.Conditions, testinghelpers.NewManagedClusterCondition(
+		v1.ManagedClusterConditionAvailable,
+		"True",
+		"ManagedClusterAvailable",
+		"Managed cluster is available",
+		nil,
+	))
+	return managedCluster
+}
+
+// TestSyncManagedClustersConcurrently drives many clusters through the reconciler from
+// concurrent workers, as configured with multiple controller workers, so the race
+// detector can catch unsynchronized access to the shared apply cache.
+func TestSyncManagedClustersConcurrently(t *testing.T) {
+	clusters := []runtime.Object{}
+	for i := 0; i < 20; i++ {
+		cluster := testinghelpers.NewAcceptedManagedCluster()
+		cluster.Name = fmt.Sprintf("%s-%d", testinghelpers.TestManagedClusterName, i)
+		clusters = append(clusters, cluster)
+	}
+
+	clusterClient := clusterfake.NewSimpleClientset(clusters...)
+	kubeClient := kubefake.NewSimpleClientset()
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+	for _, cluster := range clusters {
+		clusterStore.Add(cluster)
+	}
+
+	ctrl := managedClusterController{kubeClient, clusterClient, clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(), newLockedResourceCache(), nil, eventstesting.NewTestingEventRecorder(t)}
+
+	var wg sync.WaitGroup
+	for _, cluster := range clusters {
+		clusterName := cluster.(*v1.ManagedCluster).Name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, clusterName)); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestSyncBootstrapRBACMutator verifies an injected mutator can augment the generated
+// bootstrap RBAC before it is created.
+func TestSyncBootstrapRBACMutator(t *testing.T) {
+	extraRule := rbacv1.PolicyRule{
+		APIGroups: []string{"example.com"},
+		Resources: []string{"widgets"},
+		Verbs:     []string{"get", "list"},
+	}
+	mutator := func(objectName string, rules []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+		return append(rules, extraRule)
+	}
+
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	clusterClient := clusterfake.NewSimpleClientset(cluster)
+	kubeClient := kubefake.NewSimpleClientset()
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+	clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster)
+
+	ctrl := managedClusterController{kubeClient, clusterClient, clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(), newLockedResourceCache(), mutator, eventstesting.NewTestingEventRecorder(t)}
+	if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	var clusterRole *rbacv1.ClusterRole
+	for _, action := range kubeClient.Actions() {
+		createAction, ok := action.(clienttesting.CreateActionImpl)
+		if !ok {
+			continue
+		}
+		if created, ok := createAction.Object.(*rbacv1.ClusterRole); ok {
+			clusterRole = created
+			break
+		}
+	}
+	if clusterRole == nil {
+		t.Fatal("expected the bootstrap clusterrole to be created, but it was not")
+	}
+
+	found := false
+	for _, rule := range clusterRole.Rules {
+		if reflect.DeepEqual(rule, extraRule) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Errorf("expected the extra rule in the bootstrap clusterrole, but got %v", clusterRole.Rules)
+	}
+	if len(clusterRole.Rules) < 2 {
+		t.Errorf("expected the generated rules to be kept, but got %v", clusterRole.Rules)
+	}
+}
@@ -0,0 +1,33 @@
+package managedcluster
+
+import (
+	"sync"
+
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// lockedResourceCache serializes access to a resourceapply.ResourceCache, which is not
+// safe for concurrent use, so the controller can reconcile multiple clusters with
+// concurrent sync workers sharing one apply cache.
+type lockedResourceCache struct {
+	lock  sync.Mutex
+	cache resourceapply.ResourceCache
+}
+
+func newLockedResourceCache() *lockedResourceCache {
+	return &lockedResourceCache{cache: resourceapply.NewResourceCache()}
+}
+
+func (c *lockedResourceCache) UpdateCachedResourceMetadata(required runtime.Object, actual runtime.Object) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cache.UpdateCachedResourceMetadata(required, actual)
+}
+
+func (c *lockedResourceCache) SafeToSkipApply(required runtime.Object, existing runtime.Object) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.cache.SafeToSkipApply(required, existing)
+}
@@ -0,0 +1,84 @@
+package managedclusterset
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterinformerv1beta1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta1"
+	clusterlisterv1beta1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+)
+
+// EnableBindingPruning guards the pruning of ManagedClusterSetBindings whose cluster set
+// no longer exists. It defaults to off for safety, deleting bindings removes the access
+// the bound namespaces had to the set. It is exposed as a command line flag.
+var EnableBindingPruning = false
+
+// clusterSetBindingPruningController deletes ManagedClusterSetBindings referencing a
+// ManagedClusterSet that no longer exists, so deleted sets do not leave dead bindings
+// behind in the bound namespaces.
+type clusterSetBindingPruningController struct {
+	clusterClient           clientset.Interface
+	clusterSetLister        clusterlisterv1beta1.ManagedClusterSetLister
+	clusterSetBindingLister clusterlisterv1beta1.ManagedClusterSetBindingLister
+	eventRecorder           events.Recorder
+}
+
+// NewClusterSetBindingPruningController creates a controller on the hub that prunes the
+// ManagedClusterSetBindings orphaned by the deletion of their ManagedClusterSet.
+func NewClusterSetBindingPruningController(
+	clusterClient clientset.Interface,
+	clusterSetInformer clusterinformerv1beta1.ManagedClusterSetInformer,
+	clusterSetBindingInformer clusterinformerv1beta1.ManagedClusterSetBindingInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &clusterSetBindingPruningController{
+		clusterClient:           clusterClient,
+		clusterSetLister:        clusterSetInformer.Lister(),
+		clusterSetBindingLister: clusterSetBindingInformer.Lister(),
+		eventRecorder:           recorder.WithComponentSuffix("cluster-set-binding-pruning-controller"),
+	}
+	return factory.New().
+		WithInformers(clusterSetInformer.Informer(), clusterSetBindingInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ClusterSetBindingPruningController", recorder)
+}
+
+func (c *clusterSetBindingPruningController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	if !EnableBindingPruning {
+		return nil
+	}
+
+	bindings, err := c.clusterSetBindingLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	for _, binding := range bindings {
+		_, err := c.clusterSetLister.Get(binding.Spec.ClusterSet)
+		if err == nil {
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		klog.V(4).Infof("Pruning ManagedClusterSetBinding %s/%s, ManagedClusterSet %s no longer exists",
+			binding.Namespace, binding.Name, binding.Spec.ClusterSet)
+		err = c.clusterClient.ClusterV1beta1().ManagedClusterSetBindings(binding.Namespace).Delete(ctx, binding.Name, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		c.eventRecorder.Eventf("ManagedClusterSetBindingPruned",
+			"managed cluster set binding %s/%s is pruned, managed cluster set %s no longer exists",
+			binding.Namespace, binding.Name, binding.Spec.ClusterSet)
+	}
+	return nil
+}
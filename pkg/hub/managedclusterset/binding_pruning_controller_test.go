@@ -0,0 +1,117 @@
+package managedclusterset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSyncClusterSetBindingPruning(t *testing.T) {
+	cases := []struct {
+		name                string
+		pruningEnabled      bool
+		existingClusterSets []*clusterv1beta1.ManagedClusterSet
+		existingBindings    []*clusterv1beta1.ManagedClusterSetBinding
+		validateActions     func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:           "prune the bindings of a deleted set and keep the others",
+			pruningEnabled: true,
+			existingClusterSets: []*clusterv1beta1.ManagedClusterSet{
+				newManagedClusterSet("mcs1", false),
+			},
+			existingBindings: []*clusterv1beta1.ManagedClusterSetBinding{
+				newManagedClusterSetBinding("ns1", "mcs1"),
+				newManagedClusterSetBinding("ns1", "mcs2"),
+				newManagedClusterSetBinding("ns2", "mcs1"),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "delete")
+				deleteAction := actions[0].(clienttesting.DeleteActionImpl)
+				if deleteAction.Namespace != "ns1" || deleteAction.Name != "mcs2" {
+					t.Errorf("expected binding ns1/mcs2 to be deleted but got: %s/%s", deleteAction.Namespace, deleteAction.Name)
+				}
+			},
+		},
+		{
+			name:           "bindings whose set exists are kept",
+			pruningEnabled: true,
+			existingClusterSets: []*clusterv1beta1.ManagedClusterSet{
+				newManagedClusterSet("mcs1", false),
+			},
+			existingBindings: []*clusterv1beta1.ManagedClusterSetBinding{
+				newManagedClusterSetBinding("ns1", "mcs1"),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "orphaned bindings are kept while pruning is disabled",
+			existingBindings: []*clusterv1beta1.ManagedClusterSetBinding{
+				newManagedClusterSetBinding("ns1", "mcs2"),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() { EnableBindingPruning = false }()
+			EnableBindingPruning = c.pruningEnabled
+
+			objects := []runtime.Object{}
+			for _, clusterSet := range c.existingClusterSets {
+				objects = append(objects, clusterSet)
+			}
+			for _, binding := range c.existingBindings {
+				objects = append(objects, binding)
+			}
+			clusterClient := clusterfake.NewSimpleClientset(objects...)
+
+			informerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 5*time.Minute)
+			for _, clusterSet := range c.existingClusterSets {
+				informerFactory.Cluster().V1beta1().ManagedClusterSets().Informer().GetStore().Add(clusterSet)
+			}
+			for _, binding := range c.existingBindings {
+				informerFactory.Cluster().V1beta1().ManagedClusterSetBindings().Informer().GetStore().Add(binding)
+			}
+
+			ctrl := clusterSetBindingPruningController{
+				clusterClient:           clusterClient,
+				clusterSetLister:        informerFactory.Cluster().V1beta1().ManagedClusterSets().Lister(),
+				clusterSetBindingLister: informerFactory.Cluster().V1beta1().ManagedClusterSetBindings().Lister(),
+				eventRecorder:           eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, "key"))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func newManagedClusterSetBinding(namespace, clusterSetName string) *clusterv1beta1.ManagedClusterSetBinding {
+	return &clusterv1beta1.ManagedClusterSetBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      clusterSetName,
+		},
+		Spec: clusterv1beta1.ManagedClusterSetBindingSpec{
+			ClusterSet: clusterSetName,
+		},
+	}
+}
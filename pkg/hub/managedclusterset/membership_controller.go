@@ -0,0 +1,149 @@
+package managedclusterset
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterinformerv1beta1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta1"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterlisterv1beta1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+)
+
+const (
+	// clusterSelectorAnnotation holds the label selector of a selector-type
+	// ManagedClusterSet. Until the ManagedClusterSet API grows a selector field, a set
+	// carrying this annotation selects its members by the label selector in the
+	// annotation value instead of the clusterset label.
+	clusterSelectorAnnotation = "cluster.open-cluster-management.io/cluster-selector"
+
+	// membershipLabelPrefix prefixes the convenience labels pointing from a member
+	// cluster back at the selector-type ManagedClusterSets it matches. Only labels with
+	// this prefix are managed by the membership controller.
+	membershipLabelPrefix = "clusterset.open-cluster-management.io/"
+)
+
+// clusterSetMembershipController keeps a convenience label on ManagedClusters for each
+// selector-type ManagedClusterSet they match, so consumers can find the sets of a cluster
+// without evaluating the selectors themselves. A cluster matching multiple sets carries
+// one membership label per set.
+type clusterSetMembershipController struct {
+	clusterClient    clientset.Interface
+	clusterLister    clusterlisterv1.ManagedClusterLister
+	clusterSetLister clusterlisterv1beta1.ManagedClusterSetLister
+	eventRecorder    events.Recorder
+}
+
+// NewClusterSetMembershipController creates a controller on the hub that reconciles the
+// membership labels of selector-type ManagedClusterSets on ManagedClusters.
+func NewClusterSetMembershipController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	clusterSetInformer clusterinformerv1beta1.ManagedClusterSetInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &clusterSetMembershipController{
+		clusterClient:    clusterClient,
+		clusterLister:    clusterInformer.Lister(),
+		clusterSetLister: clusterSetInformer.Lister(),
+		eventRecorder:    recorder.WithComponentSuffix("cluster-set-membership-controller"),
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		// a clusterset change may impact the membership of any cluster, resync all
+		WithInformers(clusterSetInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ClusterSetMembershipController", recorder)
+}
+
+func (c *clusterSetMembershipController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	queueKey := syncCtx.QueueKey()
+	if queueKey != factory.DefaultQueueKey {
+		return c.syncCluster(ctx, queueKey)
+	}
+
+	managedClusters, err := c.clusterLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, managedCluster := range managedClusters {
+		if err := c.syncCluster(ctx, managedCluster.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *clusterSetMembershipController) syncCluster(ctx context.Context, managedClusterName string) error {
+	klog.V(4).Infof("Reconciling clusterset membership labels of ManagedCluster %s", managedClusterName)
+
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	clusterSets, err := c.clusterSetLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	desired := map[string]string{}
+	for _, clusterSet := range clusterSets {
+		selectorValue, ok := clusterSet.Annotations[clusterSelectorAnnotation]
+		if !ok {
+			continue
+		}
+		selector, err := labels.Parse(selectorValue)
+		if err != nil {
+			klog.Warningf("unable to parse cluster selector of ManagedClusterSet %s: %v", clusterSet.Name, err)
+			continue
+		}
+		if selector.Matches(labels.Set(managedCluster.Labels)) {
+			desired[membershipLabelPrefix+clusterSet.Name] = ""
+		}
+	}
+
+	clusterLabels := map[string]string{}
+	for key, value := range managedCluster.Labels {
+		// only the membership labels are managed by this controller
+		if strings.HasPrefix(key, membershipLabelPrefix) {
+			continue
+		}
+		clusterLabels[key] = value
+	}
+	for key, value := range desired {
+		clusterLabels[key] = value
+	}
+
+	if reflect.DeepEqual(managedCluster.Labels, clusterLabels) {
+		return nil
+	}
+	if len(managedCluster.Labels) == 0 && len(clusterLabels) == 0 {
+		return nil
+	}
+
+	managedCluster = managedCluster.DeepCopy()
+	managedCluster.Labels = clusterLabels
+	if _, err := c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, managedCluster, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	c.eventRecorder.Eventf("ManagedClusterSetMembershipUpdated",
+		"membership labels of managed cluster %s are updated", managedClusterName)
+	return nil
+}
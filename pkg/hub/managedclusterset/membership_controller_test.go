@@ -0,0 +1,168 @@
+package managedclusterset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestSyncClusterSetMembership(t *testing.T) {
+	cases := []struct {
+		name                string
+		clusterLabels       map[string]string
+		existingClusterSets []*clusterv1beta1.ManagedClusterSet
+		validateActions     func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:          "add membership label for a matching selector",
+			clusterLabels: map[string]string{"env": "dev"},
+			existingClusterSets: []*clusterv1beta1.ManagedClusterSet{
+				newSelectorManagedClusterSet("mcs1", "env=dev"),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				assertLabels(t, managedCluster, map[string]string{
+					"env":                          "dev",
+					membershipLabelPrefix + "mcs1": "",
+				})
+			},
+		},
+		{
+			name:          "add membership labels for multiple matching selectors",
+			clusterLabels: map[string]string{"env": "dev", "region": "us-east-1"},
+			existingClusterSets: []*clusterv1beta1.ManagedClusterSet{
+				newSelectorManagedClusterSet("mcs1", "env=dev"),
+				newSelectorManagedClusterSet("mcs2", "region=us-east-1"),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				assertLabels(t, managedCluster, map[string]string{
+					"env":                          "dev",
+					"region":                       "us-east-1",
+					membershipLabelPrefix + "mcs1": "",
+					membershipLabelPrefix + "mcs2": "",
+				})
+			},
+		},
+		{
+			name: "remove membership label when the cluster no longer matches",
+			clusterLabels: map[string]string{
+				"env":                          "prod",
+				membershipLabelPrefix + "mcs1": "",
+			},
+			existingClusterSets: []*clusterv1beta1.ManagedClusterSet{
+				newSelectorManagedClusterSet("mcs1", "env=dev"),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				assertLabels(t, managedCluster, map[string]string{
+					"env": "prod",
+				})
+			},
+		},
+		{
+			name: "remove membership label when the set is removed",
+			clusterLabels: map[string]string{
+				"env":                          "dev",
+				membershipLabelPrefix + "mcs1": "",
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				assertLabels(t, managedCluster, map[string]string{
+					"env": "dev",
+				})
+			},
+		},
+		{
+			name: "membership labels are in sync",
+			clusterLabels: map[string]string{
+				"env":                          "dev",
+				membershipLabelPrefix + "mcs1": "",
+			},
+			existingClusterSets: []*clusterv1beta1.ManagedClusterSet{
+				newSelectorManagedClusterSet("mcs1", "env=dev"),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:          "ignore clustersets without a selector annotation",
+			clusterLabels: map[string]string{"env": "dev"},
+			existingClusterSets: []*clusterv1beta1.ManagedClusterSet{
+				newManagedClusterSet("mcs1", false),
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			managedCluster := testinghelpers.NewManagedCluster()
+			managedCluster.Labels = c.clusterLabels
+
+			objects := []runtime.Object{managedCluster}
+			for _, clusterSet := range c.existingClusterSets {
+				objects = append(objects, clusterSet)
+			}
+			clusterClient := clusterfake.NewSimpleClientset(objects...)
+
+			informerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 5*time.Minute)
+			informerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(managedCluster)
+			for _, clusterSet := range c.existingClusterSets {
+				informerFactory.Cluster().V1beta1().ManagedClusterSets().Informer().GetStore().Add(clusterSet)
+			}
+
+			ctrl := clusterSetMembershipController{
+				clusterClient:    clusterClient,
+				clusterLister:    informerFactory.Cluster().V1().ManagedClusters().Lister(),
+				clusterSetLister: informerFactory.Cluster().V1beta1().ManagedClusterSets().Lister(),
+				eventRecorder:    eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func newSelectorManagedClusterSet(name, selector string) *clusterv1beta1.ManagedClusterSet {
+	return &clusterv1beta1.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				clusterSelectorAnnotation: selector,
+			},
+		},
+	}
+}
+
+func assertLabels(t *testing.T, managedCluster *clusterv1.ManagedCluster, expected map[string]string) {
+	if len(managedCluster.Labels) != len(expected) {
+		t.Errorf("expected labels %v but got: %v", expected, managedCluster.Labels)
+	}
+	for key, value := range expected {
+		if actual, ok := managedCluster.Labels[key]; !ok || actual != value {
+			t.Errorf("expected labels %v but got: %v", expected, managedCluster.Labels)
+		}
+	}
+}
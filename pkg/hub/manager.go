@@ -10,13 +10,20 @@ import (
 	clusterv1informers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	workv1client "open-cluster-management.io/api/client/work/clientset/versioned"
 	workv1informers "open-cluster-management.io/api/client/work/informers/externalversions"
+	"open-cluster-management.io/registration/pkg/helpers"
 	"open-cluster-management.io/registration/pkg/hub/addon"
+	"open-cluster-management.io/registration/pkg/hub/availabilitylabel"
+	"open-cluster-management.io/registration/pkg/hub/clusterfinalizer"
+	"open-cluster-management.io/registration/pkg/hub/clusternamespace"
 	"open-cluster-management.io/registration/pkg/hub/clusterrole"
 	"open-cluster-management.io/registration/pkg/hub/csr"
+	"open-cluster-management.io/registration/pkg/hub/health"
 	"open-cluster-management.io/registration/pkg/hub/lease"
 	"open-cluster-management.io/registration/pkg/hub/managedcluster"
 	"open-cluster-management.io/registration/pkg/hub/managedclusterset"
 	"open-cluster-management.io/registration/pkg/hub/rbacfinalizerdeletion"
+	"open-cluster-management.io/registration/pkg/hub/statusvalidation"
+	"open-cluster-management.io/registration/pkg/hub/taint"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 
@@ -25,8 +32,26 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+// HealthProbeAddress is the address the aggregated health endpoint of the hub controllers
+// binds to, e.g. ":8000". An empty address disables the endpoint. It is exposed as a
+// command line flag.
+var HealthProbeAddress = ""
+
+// OverloadProtectionThreshold is the number of consecutive overload responses (429 or
+// timeout) from the hub apiserver after which the clients of all hub controllers back
+// off globally instead of retrying. Zero disables the protection. The threshold and the
+// backoff bounds are exposed as command line flags.
+var (
+	OverloadProtectionThreshold      = 0
+	OverloadProtectionInitialBackoff = 1 * time.Second
+	OverloadProtectionMaxBackoff     = 32 * time.Second
+)
+
 // RunControllerManager starts the controllers on hub to manage spoke cluster registration.
 func RunControllerManager(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
+	// bound event emission so bursts of controller events do not pressure the apiserver
+	eventRecorder := helpers.NewDefaultRateLimitedRecorder(controllerContext.EventRecorder)
+
 	// If qps in kubconfig is not set, increase the qps and burst to enhance the ability of kube client to handle
 	// requests in concurrent
 	// TODO: Use ClientConnectionOverrides flags to change qps/burst when library-go exposes them in the future
@@ -36,6 +61,13 @@ func RunControllerManager(ctx context.Context, controllerContext *controllercmd.
 		kubeConfig.Burst = 200
 	}
 
+	// all clients below are built from this config, so they share one circuit breaker
+	// and back off together when the apiserver is overloaded
+	if OverloadProtectionThreshold > 0 {
+		breaker := helpers.NewCircuitBreaker(OverloadProtectionThreshold, OverloadProtectionInitialBackoff, OverloadProtectionMaxBackoff)
+		kubeConfig.Wrap(breaker.WrapTransport)
+	}
+
 	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
 		return err
@@ -61,26 +93,59 @@ func RunControllerManager(ctx context.Context, controllerContext *controllercmd.
 	kubeInfomers := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
 	addOnInformers := addoninformers.NewSharedInformerFactory(addOnClient, 10*time.Minute)
 
+	// aggregate the health of the sub-controllers into a single healthz endpoint, so
+	// liveness reflects a stuck controller rather than just the process being up
+	healthAggregator := health.NewAggregator()
+	csrControllerHealth := healthAggregator.Register("CSRApprovingController", 0,
+		kubeInfomers.Certificates().V1().CertificateSigningRequests().Informer().HasSynced)
+	// the lease controller resyncs every 5 minutes, three missed resyncs count as stuck
+	leaseControllerHealth := healthAggregator.Register("ManagedClusterLeaseController", 15*time.Minute,
+		clusterInformers.Cluster().V1().ManagedClusters().Informer().HasSynced,
+		kubeInfomers.Coordination().V1().Leases().Informer().HasSynced)
+	rbacFinalizerControllerHealth := healthAggregator.Register("FinalizeController", 0,
+		kubeInfomers.Rbac().V1().Roles().Informer().HasSynced,
+		kubeInfomers.Rbac().V1().RoleBindings().Informer().HasSynced)
+	clusterNamespaceControllerHealth := healthAggregator.Register("ClusterNamespaceController", 0,
+		clusterInformers.Cluster().V1().ManagedClusters().Informer().HasSynced,
+		kubeInfomers.Core().V1().Namespaces().Informer().HasSynced)
+
 	managedClusterController := managedcluster.NewManagedClusterController(
 		kubeClient,
 		clusterClient,
 		clusterInformers.Cluster().V1().ManagedClusters(),
-		controllerContext.EventRecorder,
+		nil, // keep the generated bootstrap RBAC rules
+		eventRecorder,
 	)
 
 	csrController := csr.NewCSRApprovingController(
 		kubeClient,
+		clusterClient,
 		kubeInfomers.Certificates().V1().CertificateSigningRequests(),
-		controllerContext.EventRecorder,
+		csrControllerHealth,
+		eventRecorder,
+	)
+
+	clientCertExpiryController := csr.NewClientCertExpiryController(
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		5*time.Minute,
+		eventRecorder,
 	)
 
+	availabilitySink, err := lease.NewConfiguredAvailabilitySink()
+	if err != nil {
+		return err
+	}
+
 	leaseController := lease.NewClusterLeaseController(
 		kubeClient,
 		clusterClient,
 		clusterInformers.Cluster().V1().ManagedClusters(),
 		kubeInfomers.Coordination().V1().Leases(),
 		5*time.Minute, //TODO: this interval time should be allowed to change from outside
-		controllerContext.EventRecorder,
+		availabilitySink,
+		lease.NewConfiguredAvailabilityPredicate(addOnInformers.Addon().V1alpha1().ManagedClusterAddOns().Lister()),
+		leaseControllerHealth,
+		eventRecorder,
 	)
 
 	rbacFinalizerController := rbacfinalizerdeletion.NewFinalizeController(
@@ -90,48 +155,118 @@ func RunControllerManager(ctx context.Context, controllerContext *controllercmd.
 		clusterInformers.Cluster().V1().ManagedClusters().Lister(),
 		workInformers.Work().V1().ManifestWorks().Lister(),
 		kubeClient.RbacV1(),
-		controllerContext.EventRecorder,
+		rbacFinalizerControllerHealth,
+		eventRecorder,
 	)
 
 	managedClusterSetController := managedclusterset.NewManagedClusterSetController(
 		clusterClient,
 		clusterInformers.Cluster().V1().ManagedClusters(),
 		clusterInformers.Cluster().V1beta1().ManagedClusterSets(),
-		controllerContext.EventRecorder,
+		eventRecorder,
+	)
+
+	clusterSetBindingPruningController := managedclusterset.NewClusterSetBindingPruningController(
+		clusterClient,
+		clusterInformers.Cluster().V1beta1().ManagedClusterSets(),
+		clusterInformers.Cluster().V1beta1().ManagedClusterSetBindings(),
+		eventRecorder,
+	)
+
+	clusterSetMembershipController := managedclusterset.NewClusterSetMembershipController(
+		clusterClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		clusterInformers.Cluster().V1beta1().ManagedClusterSets(),
+		eventRecorder,
 	)
 
 	clusterroleController := clusterrole.NewManagedClusterClusterroleController(
 		kubeClient,
 		clusterInformers.Cluster().V1().ManagedClusters(),
 		kubeInfomers.Rbac().V1().ClusterRoles(),
-		controllerContext.EventRecorder,
+		eventRecorder,
+	)
+
+	clusterRoleAggregationController := clusterrole.NewClusterRoleAggregationController(
+		kubeClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		kubeInfomers.Rbac().V1().ClusterRoles(),
+		eventRecorder,
+	)
+
+	clusterNamespaceController := clusternamespace.NewClusterNamespaceController(
+		kubeClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		kubeInfomers.Core().V1().Namespaces(),
+		clusternamespace.DefaultSyncedLabelKeys,
+		clusterNamespaceControllerHealth,
+		eventRecorder,
+	)
+
+	clusterFinalizerController := clusterfinalizer.NewClusterFinalizerController(
+		clusterClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		clusterfinalizer.DefaultCleanupFinalizer,
+		eventRecorder,
+	)
+
+	statusValidationController := statusvalidation.NewStatusValidationController(
+		clusterClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		kubeInfomers.Coordination().V1().Leases(),
+		eventRecorder,
+	)
+
+	unreachableTaintController := taint.NewUnreachableTaintController(
+		clusterClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		eventRecorder,
+	)
+
+	availabilityLabelController := availabilitylabel.NewAvailabilityLabelController(
+		clusterClient,
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		eventRecorder,
 	)
 
 	addOnHealthCheckController := addon.NewManagedClusterAddOnHealthCheckController(
 		addOnClient,
 		addOnInformers.Addon().V1alpha1().ManagedClusterAddOns(),
 		clusterInformers.Cluster().V1().ManagedClusters(),
-		controllerContext.EventRecorder,
+		eventRecorder,
 	)
 
 	addOnFeatureDiscoveryController := addon.NewAddOnFeatureDiscoveryController(
 		clusterClient,
 		clusterInformers.Cluster().V1().ManagedClusters(),
 		addOnInformers.Addon().V1alpha1().ManagedClusterAddOns(),
-		controllerContext.EventRecorder,
+		eventRecorder,
 	)
 
+	if len(HealthProbeAddress) > 0 {
+		go healthAggregator.Serve(ctx, HealthProbeAddress)
+	}
+
 	go clusterInformers.Start(ctx.Done())
 	go workInformers.Start(ctx.Done())
 	go kubeInfomers.Start(ctx.Done())
 	go addOnInformers.Start(ctx.Done())
 
-	go managedClusterController.Run(ctx, 1)
+	go managedClusterController.Run(ctx, managedcluster.ControllerWorkers)
 	go csrController.Run(ctx, 1)
+	go clientCertExpiryController.Run(ctx, 1)
 	go leaseController.Run(ctx, 1)
-	go rbacFinalizerController.Run(ctx, 1)
+	go rbacFinalizerController.Run(ctx, rbacfinalizerdeletion.ControllerWorkers)
 	go managedClusterSetController.Run(ctx, 1)
+	go clusterSetBindingPruningController.Run(ctx, 1)
+	go clusterSetMembershipController.Run(ctx, 1)
 	go clusterroleController.Run(ctx, 1)
+	go clusterRoleAggregationController.Run(ctx, 1)
+	go clusterNamespaceController.Run(ctx, 1)
+	go clusterFinalizerController.Run(ctx, 1)
+	go statusValidationController.Run(ctx, 1)
+	go unreachableTaintController.Run(ctx, 1)
+	go availabilityLabelController.Run(ctx, 1)
 	go addOnHealthCheckController.Run(ctx, 1)
 	go addOnFeatureDiscoveryController.Run(ctx, 1)
 
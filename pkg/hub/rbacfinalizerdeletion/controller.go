@@ -8,6 +8,7 @@ import (
 	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	worklister "open-cluster-management.io/api/client/work/listers/work/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/hub/health"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
@@ -31,6 +32,12 @@ const (
 	manifestWorkFinalizer = "cluster.open-cluster-management.io/manifest-work-cleanup"
 )
 
+// ControllerWorkers is the number of sync workers the finalize controller runs with. The
+// queue is keyed by namespace/name, so a single role or rolebinding is never reconciled
+// concurrently and its finalizer removal ordering is kept; more workers unblock the
+// namespaces of more simultaneously removed clusters in parallel.
+var ControllerWorkers = 1
+
 type finalizeController struct {
 	roleLister         rbacv1listers.RoleLister
 	roleBindingLister  rbacv1listers.RoleBindingLister
@@ -50,6 +57,7 @@ func NewFinalizeController(
 	clusterLister clusterv1listers.ManagedClusterLister,
 	manifestWorkLister worklister.ManifestWorkLister,
 	rbacClient rbacv1client.RbacV1Interface,
+	healthRecorder *health.ControllerHealth,
 	eventRecorder events.Recorder,
 ) factory.Controller {
 
@@ -68,7 +76,7 @@ func NewFinalizeController(
 			key, _ := cache.MetaNamespaceKeyFunc(obj)
 			return key
 		}, roleInformer.Informer(), roleBindingInformer.Informer()).
-		WithSync(controller.sync).ToController("FinalizeController", eventRecorder)
+		WithSync(health.MonitorSync(healthRecorder, controller.sync)).ToController("FinalizeController", eventRecorder)
 }
 
 func (m *finalizeController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
@@ -96,6 +104,12 @@ func (m *finalizeController) sync(ctx context.Context, controllerContext factory
 
 	err = m.syncRoleAndRoleBinding(ctx, controllerContext, role, rolebinding, ns, cluster)
 
+	// conflicts are expected when many cluster namespaces finalize at once, requeue with
+	// backoff instead of counting them as sync errors
+	if errors.IsConflict(err) {
+		controllerContext.Queue().AddRateLimited(key)
+		return nil
+	}
 	if err != nil {
 		klog.Errorf("Reconcile role/rolebinding %s fails with err: %v", key, err)
 	}
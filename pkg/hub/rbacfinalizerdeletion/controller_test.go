@@ -3,6 +3,7 @@ package rbacfinalizerdeletion
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -237,3 +238,87 @@ func TestSyncRoleAndRoleBinding(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncManyDeletingClusters(t *testing.T) {
+	clusterCount := 20
+
+	objects := []runtime.Object{}
+	for i := 0; i < clusterCount; i++ {
+		clusterName := fmt.Sprintf("cluster-%d", i)
+		objects = append(objects,
+			testinghelpers.NewNamespace(clusterName, true),
+			testinghelpers.NewRole(clusterName, roleName, []string{manifestWorkFinalizer}, true),
+			testinghelpers.NewRoleBinding(clusterName, roleName, []string{manifestWorkFinalizer}, true),
+		)
+	}
+
+	kubeClient := fakeclient.NewSimpleClientset(objects...)
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+	nsStore := kubeInformerFactory.Core().V1().Namespaces().Informer().GetStore()
+	roleStore := kubeInformerFactory.Rbac().V1().Roles().Informer().GetStore()
+	roleBindingStore := kubeInformerFactory.Rbac().V1().RoleBindings().Informer().GetStore()
+	for _, object := range objects {
+		switch object.(type) {
+		case *corev1.Namespace:
+			nsStore.Add(object)
+		case *rbacv1.Role:
+			roleStore.Add(object)
+		case *rbacv1.RoleBinding:
+			roleBindingStore.Add(object)
+		}
+	}
+
+	clusterClient := fakeclusterclient.NewSimpleClientset()
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+
+	workClient := fakeworkclient.NewSimpleClientset()
+	workInformerFactory := workinformers.NewSharedInformerFactory(workClient, 5*time.Minute)
+
+	ctrl := &finalizeController{
+		roleLister:         kubeInformerFactory.Rbac().V1().Roles().Lister(),
+		roleBindingLister:  kubeInformerFactory.Rbac().V1().RoleBindings().Lister(),
+		namespaceLister:    kubeInformerFactory.Core().V1().Namespaces().Lister(),
+		clusterLister:      clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		manifestWorkLister: workInformerFactory.Work().V1().ManifestWorks().Lister(),
+		rbacClient:         kubeClient.RbacV1(),
+		eventRecorder:      events.NewInMemoryRecorder(""),
+	}
+
+	// the deleting clusters are reconciled by concurrent workers, run this test with
+	// -race to catch shared state in the controller
+	var wg sync.WaitGroup
+	errs := make(chan error, clusterCount)
+	for i := 0; i < clusterCount; i++ {
+		clusterName := fmt.Sprintf("cluster-%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, fmt.Sprintf("%s/%s", clusterName, roleName)))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+
+	for i := 0; i < clusterCount; i++ {
+		clusterName := fmt.Sprintf("cluster-%d", i)
+		role, err := kubeClient.RbacV1().Roles(clusterName).Get(context.TODO(), roleName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if len(role.Finalizers) != 0 {
+			t.Errorf("expected the role finalizers in namespace %s to be removed, but got %v", clusterName, role.Finalizers)
+		}
+		roleBinding, err := kubeClient.RbacV1().RoleBindings(clusterName).Get(context.TODO(), roleName, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if len(roleBinding.Finalizers) != 0 {
+			t.Errorf("expected the rolebinding finalizers in namespace %s to be removed, but got %v", clusterName, roleBinding.Finalizers)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package statusvalidation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coordinformers "k8s.io/client-go/informers/coordination/v1"
+	coordlisters "k8s.io/client-go/listers/coordination/v1"
+	"k8s.io/klog/v2"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/hub/lease"
+)
+
+const leaseDurationTimes = 5
+
+// reasonStatusInconsistent identifies an availability condition that was corrected because
+// it contradicted the lease of the cluster, typically after an out-of-band status edit.
+const reasonStatusInconsistent = "ManagedClusterStatusInconsistent"
+
+// statusValidationController is a safety net against out-of-band edits of the status of a
+// managed cluster. Operators or buggy tooling writing status.conditions directly can
+// produce combinations the controllers then trust, e.g. an available condition set to true
+// while the lease of the cluster is stale. The controller detects such combinations,
+// corrects the availability to unknown and logs a warning; the lease controller takes over
+// again from there.
+type statusValidationController struct {
+	clusterClient clientset.Interface
+	clusterLister clusterv1listers.ManagedClusterLister
+	leaseLister   coordlisters.LeaseLister
+	eventRecorder events.Recorder
+}
+
+// NewStatusValidationController creates a controller on the hub that corrects the
+// availability of ManagedClusters whose manually edited status contradicts their lease.
+func NewStatusValidationController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterv1informer.ManagedClusterInformer,
+	leaseInformer coordinformers.LeaseInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &statusValidationController{
+		clusterClient: clusterClient,
+		clusterLister: clusterInformer.Lister(),
+		leaseLister:   leaseInformer.Lister(),
+		eventRecorder: recorder.WithComponentSuffix("status-validation-controller"),
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithSync(c.sync).
+		ToController("ManagedClusterStatusValidationController", recorder)
+}
+
+func (c *statusValidationController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Validating the status of ManagedCluster %s", managedClusterName)
+
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// an available condition that is not true is handled by the lease controller
+	if !meta.IsStatusConditionTrue(managedCluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable) {
+		return nil
+	}
+
+	// an unaccepted cluster cannot have a trusted heartbeat, its conditions are managed
+	// once it is accepted
+	if !meta.IsStatusConditionTrue(managedCluster.Status.Conditions, clusterv1.ManagedClusterConditionHubAccepted) {
+		return nil
+	}
+
+	message, inconsistent := c.availabilityContradictsLease(managedCluster)
+	if !inconsistent {
+		return nil
+	}
+
+	klog.Warningf("The status of managed cluster %q is inconsistent, correcting its available condition to unknown: %s",
+		managedClusterName, message)
+	conditionUpdateFn := helpers.UpdateManagedClusterConditionFn(metav1.Condition{
+		Type:    clusterv1.ManagedClusterConditionAvailable,
+		Status:  metav1.ConditionUnknown,
+		Reason:  reasonStatusInconsistent,
+		Message: message,
+	})
+	_, updated, err := helpers.UpdateManagedClusterStatus(ctx, c.clusterClient, managedClusterName, conditionUpdateFn)
+	if err != nil {
+		return err
+	}
+	if updated {
+		c.eventRecorder.Eventf("ManagedClusterStatusCorrected",
+			"available condition of managed cluster %q is corrected to unknown, its status was inconsistent: %s",
+			managedClusterName, message)
+	}
+	return nil
+}
+
+// availabilityContradictsLease reports whether the true available condition of the given
+// cluster is impossible given its lease, together with a message describing the
+// contradiction. A fresh lease controller run would never leave the condition true without
+// a constantly renewed lease.
+func (c *statusValidationController) availabilityContradictsLease(cluster *clusterv1.ManagedCluster) (string, bool) {
+	observedLease, err := c.leaseLister.Leases(cluster.Name).Get("managed-cluster-lease")
+	if errors.IsNotFound(err) {
+		return "the cluster is available but no lease of the cluster exists", true
+	}
+	if err != nil {
+		// the lease cannot be checked, do not flag the cluster on incomplete data
+		return "", false
+	}
+
+	leaseDurationSeconds := cluster.Spec.LeaseDurationSeconds
+	if leaseDurationSeconds == 0 {
+		leaseDurationSeconds = int32(lease.LeaseDurationSeconds)
+	}
+	gracePeriod := time.Duration(leaseDurationTimes*leaseDurationSeconds) * time.Second
+	if time.Now().Before(observedLease.Spec.RenewTime.Add(gracePeriod)) {
+		return "", false
+	}
+	return fmt.Sprintf("the cluster is available but its lease was last renewed at %s, beyond the grace period",
+		observedLease.Spec.RenewTime.UTC().Format(time.RFC3339)), true
+}
@@ -0,0 +1,126 @@
+package statusvalidation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var now = time.Now()
+
+func TestSync(t *testing.T) {
+	cases := []struct {
+		name            string
+		clusters        []runtime.Object
+		clusterLeases   []runtime.Object
+		validateActions func(t *testing.T, clusterActions []clienttesting.Action)
+	}{
+		{
+			name:          "sync a deleted cluster",
+			clusters:      []runtime.Object{},
+			clusterLeases: []runtime.Object{},
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+		{
+			name:          "an unaccepted cluster is not validated",
+			clusters:      []runtime.Object{testinghelpers.NewManagedCluster()},
+			clusterLeases: []runtime.Object{},
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+		{
+			name:          "an available cluster with a fresh lease is consistent",
+			clusters:      []runtime.Object{testinghelpers.NewAvailableManagedCluster()},
+			clusterLeases: []runtime.Object{testinghelpers.NewManagedClusterLease("managed-cluster-lease", now)},
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+		{
+			name:          "an available cluster without a lease is corrected",
+			clusters:      []runtime.Object{testinghelpers.NewAvailableManagedCluster()},
+			clusterLeases: []runtime.Object{},
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				expected := metav1.Condition{
+					Type:    clusterv1.ManagedClusterConditionAvailable,
+					Status:  metav1.ConditionUnknown,
+					Reason:  "ManagedClusterStatusInconsistent",
+					Message: "the cluster is available but no lease of the cluster exists",
+				}
+				testinghelpers.AssertActions(t, clusterActions, "get", "update")
+				actual := clusterActions[1].(clienttesting.UpdateActionImpl).Object
+				testinghelpers.AssertManagedClusterCondition(t, actual.(*clusterv1.ManagedCluster).Status.Conditions, expected)
+			},
+		},
+		{
+			name:          "an available cluster with a stale lease is corrected",
+			clusters:      []runtime.Object{testinghelpers.NewAvailableManagedCluster()},
+			clusterLeases: []runtime.Object{testinghelpers.NewManagedClusterLease("managed-cluster-lease", now.Add(-10*time.Minute))},
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				expected := metav1.Condition{
+					Type:   clusterv1.ManagedClusterConditionAvailable,
+					Status: metav1.ConditionUnknown,
+					Reason: "ManagedClusterStatusInconsistent",
+					Message: fmt.Sprintf("the cluster is available but its lease was last renewed at %s, beyond the grace period",
+						now.Add(-10*time.Minute).UTC().Format(time.RFC3339)),
+				}
+				testinghelpers.AssertActions(t, clusterActions, "get", "update")
+				actual := clusterActions[1].(clienttesting.UpdateActionImpl).Object
+				testinghelpers.AssertManagedClusterCondition(t, actual.(*clusterv1.ManagedCluster).Status.Conditions, expected)
+			},
+		},
+		{
+			name:          "an unavailable cluster is left to the lease controller",
+			clusters:      []runtime.Object{testinghelpers.NewUnknownManagedCluster()},
+			clusterLeases: []runtime.Object{},
+			validateActions: func(t *testing.T, clusterActions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, clusterActions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.clusters...)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			for _, cluster := range c.clusters {
+				clusterStore.Add(cluster)
+			}
+
+			leaseClient := kubefake.NewSimpleClientset(c.clusterLeases...)
+			leaseInformerFactory := kubeinformers.NewSharedInformerFactory(leaseClient, time.Minute*10)
+			leaseStore := leaseInformerFactory.Coordination().V1().Leases().Informer().GetStore()
+			for _, lease := range c.clusterLeases {
+				leaseStore.Add(lease)
+			}
+
+			ctrl := &statusValidationController{
+				clusterClient: clusterClient,
+				clusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				leaseLister:   leaseInformerFactory.Coordination().V1().Leases().Lister(),
+				eventRecorder: testinghelpers.NewFakeSyncContext(t, "").Recorder(),
+			}
+			if err := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, testinghelpers.TestManagedClusterName)); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
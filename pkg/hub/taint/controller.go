@@ -0,0 +1,124 @@
+package taint
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"open-cluster-management.io/registration/pkg/helpers"
+)
+
+// unreachableTaintController adds the unreachable taint to a managed cluster when its
+// available condition is no longer true and removes the taint again once the cluster
+// recovers. The timeAdded of the taint is the last transition time of the available
+// condition, so it reflects when the unavailability actually started rather than when
+// the taint was written. The mutating webhook keeps the timeAdded of this taint as is
+// instead of resetting it.
+type unreachableTaintController struct {
+	clusterClient clientset.Interface
+	clusterLister clusterlisterv1.ManagedClusterLister
+	eventRecorder events.Recorder
+}
+
+// NewUnreachableTaintController creates a controller on the hub that manages the
+// unreachable taint of ManagedClusters based on their available condition.
+func NewUnreachableTaintController(
+	clusterClient clientset.Interface,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	recorder events.Recorder) factory.Controller {
+	c := &unreachableTaintController{
+		clusterClient: clusterClient,
+		clusterLister: clusterInformer.Lister(),
+		eventRecorder: recorder.WithComponentSuffix("unreachable-taint-controller"),
+	}
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			return accessor.GetName()
+		}, clusterInformer.Informer()).
+		WithSync(c.sync).
+		ToController("UnreachableTaintController", recorder)
+}
+
+func (c *unreachableTaintController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	managedClusterName := syncCtx.QueueKey()
+	klog.V(4).Infof("Reconciling unreachable taint of ManagedCluster %s", managedClusterName)
+
+	managedCluster, err := c.clusterLister.Get(managedClusterName)
+	if errors.IsNotFound(err) {
+		// Spoke cluster not found, could have been deleted, do nothing.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	availableCondition := meta.FindStatusCondition(managedCluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable)
+	observedTaint := helpers.FindTaintByKey(managedCluster, clusterv1.ManagedClusterTaintUnreachable)
+
+	// the cluster recovered, remove the unreachable taint if it is present
+	if availableCondition != nil && availableCondition.Status == metav1.ConditionTrue {
+		if observedTaint == nil {
+			return nil
+		}
+		managedCluster = managedCluster.DeepCopy()
+		taints := []clusterv1.Taint{}
+		for _, taint := range managedCluster.Spec.Taints {
+			if taint.Key == clusterv1.ManagedClusterTaintUnreachable {
+				continue
+			}
+			taints = append(taints, taint)
+		}
+		managedCluster.Spec.Taints = taints
+		if _, err := c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, managedCluster, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		c.eventRecorder.Eventf("ManagedClusterUnreachableTaintRemoved",
+			"unreachable taint of managed cluster %s is removed, the cluster is available again", managedClusterName)
+		return nil
+	}
+
+	// the available condition is not set yet, the cluster has not reported its
+	// availability, do nothing
+	if availableCondition == nil {
+		return nil
+	}
+
+	// the cluster is unavailable, ensure the unreachable taint carries the time the
+	// unavailability started
+	unreachableTaint := clusterv1.Taint{
+		Key:       clusterv1.ManagedClusterTaintUnreachable,
+		Effect:    clusterv1.TaintEffectNoSelect,
+		TimeAdded: availableCondition.LastTransitionTime,
+	}
+	if observedTaint != nil && observedTaint.Effect == unreachableTaint.Effect &&
+		observedTaint.TimeAdded.Equal(&unreachableTaint.TimeAdded) {
+		return nil
+	}
+
+	managedCluster = managedCluster.DeepCopy()
+	taints := []clusterv1.Taint{}
+	for _, taint := range managedCluster.Spec.Taints {
+		if taint.Key == clusterv1.ManagedClusterTaintUnreachable {
+			continue
+		}
+		taints = append(taints, taint)
+	}
+	managedCluster.Spec.Taints = append(taints, unreachableTaint)
+	if _, err := c.clusterClient.ClusterV1().ManagedClusters().Update(ctx, managedCluster, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	c.eventRecorder.Eventf("ManagedClusterUnreachableTaintAdded",
+		"unreachable taint of managed cluster %s is added, the cluster is unavailable since %v",
+		managedClusterName, availableCondition.LastTransitionTime)
+	return nil
+}
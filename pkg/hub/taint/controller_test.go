@@ -0,0 +1,163 @@
+package taint
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clienttesting "k8s.io/client-go/testing"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+var transitionTime = metav1.NewTime(time.Now().Add(-10 * time.Minute).Truncate(time.Second))
+
+func TestSyncUnreachableTaint(t *testing.T) {
+	cases := []struct {
+		name            string
+		cluster         *clusterv1.ManagedCluster
+		validateActions func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name:    "add the unreachable taint to an unavailable cluster",
+			cluster: newUnavailableCluster(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				taint := findUnreachableTaint(managedCluster)
+				if taint == nil {
+					t.Fatalf("expected unreachable taint but got: %v", managedCluster.Spec.Taints)
+				}
+				if !taint.TimeAdded.Equal(&transitionTime) {
+					t.Errorf("expected timeAdded %v but got: %v", transitionTime, taint.TimeAdded)
+				}
+			},
+		},
+		{
+			name: "update the timeAdded of the unreachable taint on a new unavailability",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := newUnavailableCluster()
+				cluster.Spec.Taints = []clusterv1.Taint{
+					{
+						Key:       clusterv1.ManagedClusterTaintUnreachable,
+						Effect:    clusterv1.TaintEffectNoSelect,
+						TimeAdded: metav1.NewTime(transitionTime.Add(-1 * time.Hour)),
+					},
+				}
+				return cluster
+			}(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				taint := findUnreachableTaint(managedCluster)
+				if taint == nil {
+					t.Fatalf("expected unreachable taint but got: %v", managedCluster.Spec.Taints)
+				}
+				if !taint.TimeAdded.Equal(&transitionTime) {
+					t.Errorf("expected timeAdded %v but got: %v", transitionTime, taint.TimeAdded)
+				}
+			},
+		},
+		{
+			name: "the unreachable taint is in sync",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := newUnavailableCluster()
+				cluster.Spec.Taints = []clusterv1.Taint{
+					{
+						Key:       clusterv1.ManagedClusterTaintUnreachable,
+						Effect:    clusterv1.TaintEffectNoSelect,
+						TimeAdded: transitionTime,
+					},
+				}
+				return cluster
+			}(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name: "remove the unreachable taint from a recovered cluster",
+			cluster: func() *clusterv1.ManagedCluster {
+				cluster := testinghelpers.NewAvailableManagedCluster()
+				cluster.Spec.Taints = []clusterv1.Taint{
+					{Key: "foo", Value: "bar", Effect: clusterv1.TaintEffectNoSelect},
+					{
+						Key:       clusterv1.ManagedClusterTaintUnreachable,
+						Effect:    clusterv1.TaintEffectNoSelect,
+						TimeAdded: transitionTime,
+					},
+				}
+				return cluster
+			}(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				if taint := findUnreachableTaint(managedCluster); taint != nil {
+					t.Errorf("expected no unreachable taint but got: %v", taint)
+				}
+				if len(managedCluster.Spec.Taints) != 1 {
+					t.Errorf("expected the other taints to be kept but got: %v", managedCluster.Spec.Taints)
+				}
+			},
+		},
+		{
+			name:    "an available cluster without the taint",
+			cluster: testinghelpers.NewAvailableManagedCluster(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:    "a cluster without the available condition",
+			cluster: testinghelpers.NewAcceptedManagedCluster(),
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.cluster)
+			informerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, 5*time.Minute)
+			informerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(c.cluster)
+
+			ctrl := unreachableTaintController{
+				clusterClient: clusterClient,
+				clusterLister: informerFactory.Cluster().V1().ManagedClusters().Lister(),
+				eventRecorder: eventstesting.NewTestingEventRecorder(t),
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, c.cluster.Name))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func newUnavailableCluster() *clusterv1.ManagedCluster {
+	cluster := testinghelpers.NewAcceptedManagedCluster()
+	cluster.Status.Conditions = append(cluster.Status.Conditions, testinghelpers.NewManagedClusterCondition(
+		clusterv1.ManagedClusterConditionAvailable,
+		"Unknown",
+		"ManagedClusterLeaseUpdateStopped",
+		"Registration agent stopped updating its lease.",
+		&transitionTime,
+	))
+	return cluster
+}
+
+func findUnreachableTaint(managedCluster *clusterv1.ManagedCluster) *clusterv1.Taint {
+	for i := range managedCluster.Spec.Taints {
+		if managedCluster.Spec.Taints[i].Key == clusterv1.ManagedClusterTaintUnreachable {
+			return &managedCluster.Spec.Taints[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Package metrics registers the Prometheus metrics of the registration components and
+// applies an optional prefix to their names, so deployments with product specific
+// metrics naming conventions can rename all metrics at once.
+package metrics
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	lock sync.Mutex
+
+	// prefix is applied to the name of every metric registered through this package
+	prefix string
+
+	// pending holds the metric factories registered before the prefix was configured
+	pending []func(prefix string) metrics.Registerable
+
+	// configured indicates whether SetPrefix has been called
+	configured bool
+)
+
+// Register registers a metric built by the given factory, which is called with the
+// effective metrics prefix. Registration is deferred until the prefix is configured
+// with SetPrefix, so metrics can be registered from package init functions before the
+// command line flags are parsed.
+func Register(factory func(prefix string) metrics.Registerable) {
+	lock.Lock()
+	defer lock.Unlock()
+	if configured {
+		legacyregistry.MustRegister(factory(prefix))
+		return
+	}
+	pending = append(pending, factory)
+}
+
+// SetPrefix configures the prefix applied to the names of all metrics registered
+// through this package and registers the pending ones. An empty prefix keeps the
+// original metric names. It is called once after the command line flags are parsed.
+func SetPrefix(p string) {
+	lock.Lock()
+	defer lock.Unlock()
+	if configured {
+		return
+	}
+	prefix = p
+	configured = true
+	for _, factory := range pending {
+		legacyregistry.MustRegister(factory(prefix))
+	}
+	pending = nil
+}
+
+// Prefixed returns the given metric name with the prefix applied. Only the metric name
+// changes, help texts and label sets are kept as they are.
+func Prefixed(prefix, name string) string {
+	if len(prefix) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s_%s", prefix, name)
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"testing"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+func TestPrefixed(t *testing.T) {
+	cases := []struct {
+		name         string
+		prefix       string
+		metricName   string
+		expectedName string
+	}{
+		{
+			name:         "without a prefix",
+			metricName:   "registration_test_total",
+			expectedName: "registration_test_total",
+		},
+		{
+			name:         "with a prefix",
+			prefix:       "mycorp",
+			metricName:   "registration_test_total",
+			expectedName: "mycorp_registration_test_total",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := Prefixed(c.prefix, c.metricName); actual != c.expectedName {
+				t.Errorf("expected %q but got: %q", c.expectedName, actual)
+			}
+		})
+	}
+}
+
+func TestPrefixedRegistration(t *testing.T) {
+	expectedHelp := "A test counter."
+	Register(func(prefix string) metrics.Registerable {
+		return metrics.NewCounter(&metrics.CounterOpts{
+			Name: Prefixed(prefix, "registration_pending_test_total"),
+			Help: expectedHelp,
+		})
+	})
+
+	SetPrefix("mycorp")
+
+	// metrics registered after the prefix is configured are registered immediately
+	Register(func(prefix string) metrics.Registerable {
+		return metrics.NewCounter(&metrics.CounterOpts{
+			Name: Prefixed(prefix, "registration_immediate_test_total"),
+			Help: expectedHelp,
+		})
+	})
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	found := map[string]string{}
+	for _, family := range families {
+		found[family.GetName()] = family.GetHelp()
+	}
+
+	// component-base prepends the metric stability level to the help text
+	expectedGatheredHelp := "[ALPHA] " + expectedHelp
+	for _, expectedName := range []string{"mycorp_registration_pending_test_total", "mycorp_registration_immediate_test_total"} {
+		help, ok := found[expectedName]
+		if !ok {
+			t.Errorf("expected metric %q to be registered", expectedName)
+			continue
+		}
+		if help != expectedGatheredHelp {
+			t.Errorf("expected help %q of metric %q but got: %q", expectedGatheredHelp, expectedName, help)
+		}
+	}
+}
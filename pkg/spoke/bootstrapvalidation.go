@@ -0,0 +1,116 @@
+package spoke
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// ValidateBootstrapKubeconfig checks that the bootstrap kubeconfig at the given path is
+// usable before it is shipped to a spoke cluster: the file loads the same way the agent
+// loads it, the CA bundle parses and is not fully expired, a token or a currently valid
+// client certificate is present, and the server answers a request. Each failed check
+// reports an actionable error.
+func ValidateBootstrapKubeconfig(bootstrapKubeconfig string) error {
+	clientConfig, err := clientcmd.BuildConfigFromFlags("", bootstrapKubeconfig)
+	if err != nil {
+		return fmt.Errorf("unable to load bootstrap kubeconfig from file %q: %w", bootstrapKubeconfig, err)
+	}
+
+	if err := validateBootstrapCABundle(clientConfig); err != nil {
+		return err
+	}
+	if err := validateBootstrapCredential(clientConfig); err != nil {
+		return err
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(clientConfig)
+	if err != nil {
+		return err
+	}
+	return validateBootstrapServer(discoveryClient)
+}
+
+// validateBootstrapCABundle checks that the CA bundle of the bootstrap kubeconfig, if
+// any, parses and contains at least one unexpired certificate. A fully expired bundle
+// guarantees the agent cannot validate the hub apiserver certificate.
+func validateBootstrapCABundle(clientConfig *rest.Config) error {
+	caData := clientConfig.CAData
+	if len(caData) == 0 && len(clientConfig.CAFile) > 0 {
+		var err error
+		caData, err = ioutil.ReadFile(clientConfig.CAFile)
+		if err != nil {
+			return fmt.Errorf("unable to read the CA bundle %q of the bootstrap kubeconfig: %w", clientConfig.CAFile, err)
+		}
+	}
+	if len(caData) == 0 {
+		return nil
+	}
+
+	certs, err := certutil.ParseCertsPEM(caData)
+	if err != nil {
+		return fmt.Errorf("the CA bundle of the bootstrap kubeconfig does not parse, replace it with the CA of the hub apiserver: %w", err)
+	}
+	now := time.Now()
+	for _, cert := range certs {
+		if now.Before(cert.NotAfter) {
+			return nil
+		}
+	}
+	return errors.New("all certificates in the CA bundle of the bootstrap kubeconfig are expired, replace it with the current CA of the hub apiserver")
+}
+
+// validateBootstrapCredential checks that the bootstrap kubeconfig carries a credential
+// the agent can present: a token, or a client certificate that is currently valid.
+func validateBootstrapCredential(clientConfig *rest.Config) error {
+	// a token cannot be inspected offline, its presence is all that can be checked
+	if len(clientConfig.BearerToken) > 0 || len(clientConfig.BearerTokenFile) > 0 {
+		return nil
+	}
+
+	certData := clientConfig.CertData
+	if len(certData) == 0 && len(clientConfig.CertFile) > 0 {
+		var err error
+		certData, err = ioutil.ReadFile(clientConfig.CertFile)
+		if err != nil {
+			return fmt.Errorf("unable to read the client certificate %q of the bootstrap kubeconfig: %w", clientConfig.CertFile, err)
+		}
+	}
+	if len(certData) == 0 {
+		return errors.New("the bootstrap kubeconfig carries neither a token nor a client certificate, issue a credential for the bootstrap")
+	}
+	if len(clientConfig.KeyData) == 0 && len(clientConfig.KeyFile) == 0 {
+		return errors.New("the bootstrap kubeconfig carries a client certificate but no private key")
+	}
+
+	certs, err := certutil.ParseCertsPEM(certData)
+	if err != nil {
+		return fmt.Errorf("the client certificate of the bootstrap kubeconfig does not parse: %w", err)
+	}
+	now := time.Now()
+	for _, cert := range certs {
+		if now.Before(cert.NotBefore) {
+			return fmt.Errorf("the client certificate of the bootstrap kubeconfig is not valid until %s, check for clock skew", cert.NotBefore.UTC().Format(time.RFC3339))
+		}
+		if now.After(cert.NotAfter) {
+			return fmt.Errorf("the client certificate of the bootstrap kubeconfig expired at %s, issue a fresh bootstrap kubeconfig", cert.NotAfter.UTC().Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// validateBootstrapServer checks that the server of the bootstrap kubeconfig answers a
+// request, so an unreachable or misconfigured hub is caught before the kubeconfig is
+// shipped.
+func validateBootstrapServer(discoveryClient discovery.DiscoveryInterface) error {
+	if _, err := discoveryClient.ServerVersion(); err != nil {
+		return fmt.Errorf("the server of the bootstrap kubeconfig did not answer, check the server URL and the network path to the hub: %v", err)
+	}
+	return nil
+}
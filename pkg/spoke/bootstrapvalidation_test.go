@@ -0,0 +1,136 @@
+package spoke
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+)
+
+func TestValidateBootstrapCABundle(t *testing.T) {
+	validCert := testinghelpers.NewTestCert("bootstrap-ca", time.Hour)
+	expiredCert := testinghelpers.NewTestCert("bootstrap-ca", -time.Hour)
+
+	cases := []struct {
+		name         string
+		clientConfig *rest.Config
+		expectedErr  string
+	}{
+		{
+			name:         "no CA bundle relies on the system roots",
+			clientConfig: &rest.Config{},
+		},
+		{
+			name:         "a CA bundle with an unexpired certificate",
+			clientConfig: &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAData: validCert.Cert}},
+		},
+		{
+			name:         "a fully expired CA bundle",
+			clientConfig: &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAData: expiredCert.Cert}},
+			expectedErr:  "all certificates in the CA bundle of the bootstrap kubeconfig are expired, replace it with the current CA of the hub apiserver",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testinghelpers.AssertError(t, validateBootstrapCABundle(c.clientConfig), c.expectedErr)
+		})
+	}
+}
+
+func TestValidateBootstrapCredential(t *testing.T) {
+	validCert := testinghelpers.NewTestCert("bootstrap-user", time.Hour)
+	expiredCert := testinghelpers.NewTestCert("bootstrap-user", -time.Hour)
+
+	cases := []struct {
+		name         string
+		clientConfig *rest.Config
+		expectedErr  string
+	}{
+		{
+			name:         "a token based bootstrap kubeconfig",
+			clientConfig: &rest.Config{BearerToken: "token"},
+		},
+		{
+			name: "a valid client certificate",
+			clientConfig: &rest.Config{TLSClientConfig: rest.TLSClientConfig{
+				CertData: validCert.Cert,
+				KeyData:  validCert.Key,
+			}},
+		},
+		{
+			name: "an expired client certificate",
+			clientConfig: &rest.Config{TLSClientConfig: rest.TLSClientConfig{
+				CertData: expiredCert.Cert,
+				KeyData:  expiredCert.Key,
+			}},
+			expectedErr: "the client certificate of the bootstrap kubeconfig expired at",
+		},
+		{
+			name: "a client certificate without a private key",
+			clientConfig: &rest.Config{TLSClientConfig: rest.TLSClientConfig{
+				CertData: validCert.Cert,
+			}},
+			expectedErr: "the bootstrap kubeconfig carries a client certificate but no private key",
+		},
+		{
+			name:         "no credential at all",
+			clientConfig: &rest.Config{},
+			expectedErr:  "the bootstrap kubeconfig carries neither a token nor a client certificate, issue a credential for the bootstrap",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateBootstrapCredential(c.clientConfig)
+			if len(c.expectedErr) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), c.expectedErr) {
+				t.Errorf("expected error starting with %q but got: %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateBootstrapServer(t *testing.T) {
+	t.Run("the server answers", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"major":"1","minor":"21","gitVersion":"v1.21.0"}`))
+		}))
+		defer server.Close()
+
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(&rest.Config{Host: server.URL})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := validateBootstrapServer(discoveryClient); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("the server is unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		serverURL := server.URL
+		server.Close()
+
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(&rest.Config{Host: serverURL})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err = validateBootstrapServer(discoveryClient)
+		if err == nil || !strings.Contains(err.Error(), "did not answer") {
+			t.Errorf("expected an unreachable server error but got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package managedcluster
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1informer "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterv1listers "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// annotations the capacity summary controller maintains on the ManagedCluster
+const (
+	nodeCountAnnotation         = "capacity.open-cluster-management.io/node-count"
+	allocatableCPUAnnotation    = "capacity.open-cluster-management.io/allocatable-cpu"
+	allocatableMemoryAnnotation = "capacity.open-cluster-management.io/allocatable-memory"
+)
+
+// managedClusterCapacitySummaryController periodically reports the node count and the
+// aggregated allocatable cpu/memory of the managed cluster as annotations on the
+// ManagedCluster, so the hub can do fleet capacity planning without scraping the spoke.
+type managedClusterCapacitySummaryController struct {
+	clusterName      string
+	hubClusterClient clientset.Interface
+	hubClusterLister clusterv1listers.ManagedClusterLister
+	spokeKubeClient  kubernetes.Interface
+}
+
+// NewManagedClusterCapacitySummaryController creates a capacity summary controller on
+// managed cluster.
+func NewManagedClusterCapacitySummaryController(
+	clusterName string,
+	hubClusterClient clientset.Interface,
+	hubClusterInformer clusterv1informer.ManagedClusterInformer,
+	spokeKubeClient kubernetes.Interface,
+	resyncInterval time.Duration,
+	recorder events.Recorder) factory.Controller {
+	c := &managedClusterCapacitySummaryController{
+		clusterName:      clusterName,
+		hubClusterClient: hubClusterClient,
+		hubClusterLister: hubClusterInformer.Lister(),
+		spokeKubeClient:  spokeKubeClient,
+	}
+
+	return factory.New().
+		WithInformers(hubClusterInformer.Informer()).
+		WithSync(c.sync).
+		ResyncEvery(resyncInterval).
+		ToController("ManagedClusterCapacitySummaryController", recorder)
+}
+
+// sync lists the nodes on the managed cluster and updates the capacity summary
+// annotations of the ManagedCluster if the summary changed.
+func (c *managedClusterCapacitySummaryController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	nodes, err := c.spokeKubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if errors.IsForbidden(err) {
+		// the agent is not granted the node list permission on this spoke, skip the
+		// report instead of failing the sync
+		klog.V(4).Infof("The agent is not allowed to list the nodes of managed cluster %q, the capacity summary is not reported", c.clusterName)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to list the nodes of managed cluster %q: %w", c.clusterName, err)
+	}
+
+	summary := summarizeCapacity(nodes.Items)
+
+	managedCluster, err := c.hubClusterLister.Get(c.clusterName)
+	if err != nil {
+		return fmt.Errorf("unable to get managed cluster %q from hub: %w", c.clusterName, err)
+	}
+
+	changed := false
+	for key, value := range summary {
+		if managedCluster.Annotations[key] != value {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	managedCluster = managedCluster.DeepCopy()
+	if managedCluster.Annotations == nil {
+		managedCluster.Annotations = map[string]string{}
+	}
+	for key, value := range summary {
+		managedCluster.Annotations[key] = value
+	}
+
+	if _, err := c.hubClusterClient.ClusterV1().ManagedClusters().Update(ctx, managedCluster, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update the capacity summary of managed cluster %q: %w", c.clusterName, err)
+	}
+	syncCtx.Recorder().Eventf("ManagedClusterCapacitySummaryUpdated",
+		"the capacity summary of managed cluster %q has been updated, %d nodes, allocatable cpu %s, allocatable memory %s",
+		c.clusterName, len(nodes.Items), summary[allocatableCPUAnnotation], summary[allocatableMemoryAnnotation])
+	return nil
+}
+
+// summarizeCapacity aggregates the node count and the allocatable cpu/memory of the given
+// nodes. Like the status controller, the allocatable resources of unschedulable nodes are
+// ignored.
+func summarizeCapacity(nodes []corev1.Node) map[string]string {
+	cpu := resource.Quantity{}
+	memory := resource.Quantity{}
+	for _, node := range nodes {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		cpu.Add(*node.Status.Allocatable.Cpu())
+		memory.Add(*node.Status.Allocatable.Memory())
+	}
+
+	return map[string]string{
+		nodeCountAnnotation:         strconv.Itoa(len(nodes)),
+		allocatableCPUAnnotation:    cpu.String(),
+		allocatableMemoryAnnotation: memory.String(),
+	}
+}
@@ -0,0 +1,110 @@
+package managedcluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestCapacitySummarySync(t *testing.T) {
+	unschedulableNode := testinghelpers.NewNode("testnode3", testinghelpers.NewResourceList(32, 64), testinghelpers.NewResourceList(16, 32))
+	unschedulableNode.Spec.Unschedulable = true
+
+	cases := []struct {
+		name               string
+		nodes              []runtime.Object
+		nodeListForbidden  bool
+		clusterAnnotations map[string]string
+		validateActions    func(t *testing.T, actions []clienttesting.Action)
+	}{
+		{
+			name: "report the capacity summary of the nodes",
+			nodes: []runtime.Object{
+				testinghelpers.NewNode("testnode1", testinghelpers.NewResourceList(32, 64), testinghelpers.NewResourceList(16, 32)),
+				testinghelpers.NewNode("testnode2", testinghelpers.NewResourceList(32, 64), testinghelpers.NewResourceList(16, 32)),
+				unschedulableNode,
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "update")
+				managedCluster := actions[0].(clienttesting.UpdateActionImpl).Object.(*clusterv1.ManagedCluster)
+				assertAnnotations(t, managedCluster.Annotations, map[string]string{
+					nodeCountAnnotation:         "3",
+					allocatableCPUAnnotation:    "32",
+					allocatableMemoryAnnotation: "64Mi",
+				})
+			},
+		},
+		{
+			name: "no update when the summary is unchanged",
+			nodes: []runtime.Object{
+				testinghelpers.NewNode("testnode1", testinghelpers.NewResourceList(32, 64), testinghelpers.NewResourceList(16, 32)),
+			},
+			clusterAnnotations: map[string]string{
+				nodeCountAnnotation:         "1",
+				allocatableCPUAnnotation:    "16",
+				allocatableMemoryAnnotation: "32Mi",
+			},
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+		{
+			name:              "skip the report when the node list is forbidden",
+			nodeListForbidden: true,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertNoActions(t, actions)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			managedCluster := testinghelpers.NewManagedCluster()
+			managedCluster.Annotations = c.clusterAnnotations
+
+			kubeClient := kubefake.NewSimpleClientset(c.nodes...)
+			if c.nodeListForbidden {
+				kubeClient.PrependReactor("list", "nodes", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "nodes"}, "", nil)
+				})
+			}
+
+			clusterClient := clusterfake.NewSimpleClientset(managedCluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(clusterClient, time.Minute*10)
+			clusterStore := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore()
+			clusterStore.Add(managedCluster)
+
+			ctrl := &managedClusterCapacitySummaryController{
+				clusterName:      testinghelpers.TestManagedClusterName,
+				hubClusterClient: clusterClient,
+				hubClusterLister: clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+				spokeKubeClient:  kubeClient,
+			}
+			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, ""))
+			if syncErr != nil {
+				t.Errorf("unexpected err: %v", syncErr)
+			}
+
+			c.validateActions(t, clusterClient.Actions())
+		})
+	}
+}
+
+func assertAnnotations(t *testing.T, annotations, expected map[string]string) {
+	for key, value := range expected {
+		if annotations[key] != value {
+			t.Errorf("expected annotation %s=%s but got: %s", key, value, annotations[key])
+		}
+	}
+}
@@ -20,6 +20,11 @@ import (
 // well-known anonymous user
 const anonymous = "system:anonymous"
 
+// hubInitiatedConnectivityLabel marks a ManagedCluster whose agent registered without
+// client configs because the hub reaches the spoke through hub-initiated connectivity
+// instead of an agent-advertised URL.
+const hubInitiatedConnectivityLabel = "cluster.open-cluster-management.io/hub-initiated-connectivity"
+
 var (
 	// CreatingControllerSyncInterval is exposed so that integration tests can crank up the controller sync speed.
 	CreatingControllerSyncInterval = 60 * time.Minute
@@ -27,23 +32,25 @@ var (
 
 // managedClusterCreatingController creates a ManagedCluster on hub cluster during the spoke agent bootstrap phase
 type managedClusterCreatingController struct {
-	clusterName             string
-	spokeExternalServerURLs []string
-	spokeCABundle           []byte
-	hubClusterClient        clientset.Interface
+	clusterName              string
+	spokeExternalServerURLs  []string
+	hubInitiatedConnectivity bool
+	spokeCABundle            []byte
+	hubClusterClient         clientset.Interface
 }
 
 // NewManagedClusterCreatingController creates a new managedClusterCreatingController on the managed cluster.
 func NewManagedClusterCreatingController(
-	clusterName string, spokeExternalServerURLs []string,
+	clusterName string, spokeExternalServerURLs []string, hubInitiatedConnectivity bool,
 	spokeCABundle []byte,
 	hubClusterClient clientset.Interface,
 	recorder events.Recorder) factory.Controller {
 	c := &managedClusterCreatingController{
-		clusterName:             clusterName,
-		spokeExternalServerURLs: spokeExternalServerURLs,
-		spokeCABundle:           spokeCABundle,
-		hubClusterClient:        hubClusterClient,
+		clusterName:              clusterName,
+		spokeExternalServerURLs:  spokeExternalServerURLs,
+		hubInitiatedConnectivity: hubInitiatedConnectivity,
+		spokeCABundle:            spokeCABundle,
+		hubClusterClient:         hubClusterClient,
 	}
 
 	return factory.New().
@@ -72,6 +79,14 @@ func (c *managedClusterCreatingController) sync(ctx context.Context, syncCtx fac
 		},
 	}
 
+	// in hub-initiated connectivity mode the hub reaches the spoke itself, so the cluster
+	// is registered without client configs and marked with a well-known label instead
+	if c.hubInitiatedConnectivity {
+		managedCluster.Labels = map[string]string{
+			hubInitiatedConnectivityLabel: "true",
+		}
+	}
+
 	if len(c.spokeExternalServerURLs) != 0 {
 		managedClusterClientConfigs := []clusterv1.ClientConfig{}
 		for _, serverURL := range c.spokeExternalServerURLs {
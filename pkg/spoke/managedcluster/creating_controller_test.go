@@ -16,9 +16,10 @@ const testSpokeExternalServerUrl = "https://192.168.3.77:32769"
 
 func TestCreateSpokeCluster(t *testing.T) {
 	cases := []struct {
-		name            string
-		startingObjects []runtime.Object
-		validateActions func(t *testing.T, actions []clienttesting.Action)
+		name                     string
+		startingObjects          []runtime.Object
+		hubInitiatedConnectivity bool
+		validateActions          func(t *testing.T, actions []clienttesting.Action)
 	}{
 		{
 			name:            "create a new cluster",
@@ -36,6 +37,22 @@ func TestCreateSpokeCluster(t *testing.T) {
 				testinghelpers.AssertManagedClusterClientConfigs(t, actualClientConfigs, expectedClientConfigs)
 			},
 		},
+		{
+			name:                     "create a new cluster with hub-initiated connectivity",
+			startingObjects:          []runtime.Object{},
+			hubInitiatedConnectivity: true,
+			validateActions: func(t *testing.T, actions []clienttesting.Action) {
+				testinghelpers.AssertActions(t, actions, "get", "create")
+				actual := actions[1].(clienttesting.CreateActionImpl).Object
+				managedCluster := actual.(*clusterv1.ManagedCluster)
+				if len(managedCluster.Spec.ManagedClusterClientConfigs) != 0 {
+					t.Errorf("expected no client configs but got: %v", managedCluster.Spec.ManagedClusterClientConfigs)
+				}
+				if managedCluster.Labels[hubInitiatedConnectivityLabel] != "true" {
+					t.Errorf("expected label %q on managed cluster but got: %v", hubInitiatedConnectivityLabel, managedCluster.Labels)
+				}
+			},
+		},
 		{
 			name:            "create an existed cluster",
 			startingObjects: []runtime.Object{testinghelpers.NewManagedCluster()},
@@ -48,11 +65,16 @@ func TestCreateSpokeCluster(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			clusterClient := clusterfake.NewSimpleClientset(c.startingObjects...)
+			spokeExternalServerURLs := []string{testSpokeExternalServerUrl}
+			if c.hubInitiatedConnectivity {
+				spokeExternalServerURLs = nil
+			}
 			ctrl := managedClusterCreatingController{
-				clusterName:             testinghelpers.TestManagedClusterName,
-				spokeExternalServerURLs: []string{testSpokeExternalServerUrl},
-				spokeCABundle:           []byte("testcabundle"),
-				hubClusterClient:        clusterClient,
+				clusterName:              testinghelpers.TestManagedClusterName,
+				spokeExternalServerURLs:  spokeExternalServerURLs,
+				hubInitiatedConnectivity: c.hubInitiatedConnectivity,
+				spokeCABundle:            []byte("testcabundle"),
+				hubClusterClient:         clusterClient,
 			}
 
 			syncErr := ctrl.sync(context.TODO(), testinghelpers.NewFakeSyncContext(t, ""))
@@ -18,6 +18,7 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/utils/pointer"
 )
 
 const leaseUpdateJitterFactor = 0.25
@@ -28,23 +29,32 @@ type managedClusterLeaseController struct {
 	hubClient                clientset.Interface
 	hubClusterLister         clusterv1listers.ManagedClusterLister
 	lastLeaseDurationSeconds int32
+	leaseRenewInterval       time.Duration
 	leaseUpdater             *leaseUpdater
 }
 
 // NewManagedClusterLeaseController creates a new managed cluster lease controller on the managed cluster.
+// If leaseRenewInterval is greater than zero and less than the lease duration of the managed cluster, the
+// lease is renewed with that interval instead of the cadence computed from the lease duration.
+// If holderIdentity is not empty, it is recorded as the holderIdentity of the renewed lease, so the
+// renewing agent instance can be identified; the hub does not depend on a specific holder value.
 func NewManagedClusterLeaseController(
 	clusterName string,
+	leaseRenewInterval time.Duration,
+	holderIdentity string,
 	hubClient clientset.Interface,
 	hubClusterInformer clusterv1informer.ManagedClusterInformer,
 	recorder events.Recorder) factory.Controller {
 	c := &managedClusterLeaseController{
-		clusterName:      clusterName,
-		hubClusterLister: hubClusterInformer.Lister(),
+		clusterName:        clusterName,
+		hubClusterLister:   hubClusterInformer.Lister(),
+		leaseRenewInterval: leaseRenewInterval,
 		leaseUpdater: &leaseUpdater{
-			hubClient:   hubClient,
-			clusterName: clusterName,
-			leaseName:   "managed-cluster-lease",
-			recorder:    recorder,
+			hubClient:      hubClient,
+			clusterName:    clusterName,
+			leaseName:      "managed-cluster-lease",
+			holderIdentity: holderIdentity,
+			recorder:       recorder,
 		},
 	}
 
@@ -80,20 +90,32 @@ func (c *managedClusterLeaseController) sync(ctx context.Context, syncCtx factor
 	if c.lastLeaseDurationSeconds != observedLeaseDurationSeconds {
 		c.lastLeaseDurationSeconds = observedLeaseDurationSeconds
 		c.leaseUpdater.stop()
-		c.leaseUpdater.start(ctx, time.Duration(c.lastLeaseDurationSeconds)*time.Second)
+		c.leaseUpdater.start(ctx, c.renewInterval())
 	}
 
 	return nil
 }
 
+// renewInterval returns the interval with which the lease of the managed cluster is renewed. The
+// configured lease renew interval is honored only if it is shorter than the lease duration, so a
+// misconfigured override never silences the heartbeat beyond the advertised duration.
+func (c *managedClusterLeaseController) renewInterval() time.Duration {
+	leaseDuration := time.Duration(c.lastLeaseDurationSeconds) * time.Second
+	if c.leaseRenewInterval > 0 && c.leaseRenewInterval < leaseDuration {
+		return c.leaseRenewInterval
+	}
+	return leaseDuration
+}
+
 // leaseUpdater periodically updates the lease of a managed cluster
 type leaseUpdater struct {
-	hubClient   clientset.Interface
-	clusterName string
-	leaseName   string
-	lock        sync.Mutex
-	cancel      context.CancelFunc
-	recorder    events.Recorder
+	hubClient      clientset.Interface
+	clusterName    string
+	leaseName      string
+	holderIdentity string
+	lock           sync.Mutex
+	cancel         context.CancelFunc
+	recorder       events.Recorder
 }
 
 // start a lease update routine to update the lease of a managed cluster periodically.
@@ -129,6 +151,9 @@ func (u *leaseUpdater) update(ctx context.Context) {
 	}
 
 	lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now()}
+	if len(u.holderIdentity) > 0 {
+		lease.Spec.HolderIdentity = pointer.StringPtr(u.holderIdentity)
+	}
 	if _, err = u.hubClient.CoordinationV1().Leases(u.clusterName).Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
 		utilruntime.HandleError(fmt.Errorf("unable to update cluster lease %q on hub cluster: %w", u.leaseName, err))
 	}
@@ -12,6 +12,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 
 	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
@@ -88,3 +89,64 @@ func TestLeaseUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestLeaseHolderIdentity(t *testing.T) {
+	hubClient := kubefake.NewSimpleClientset(testinghelpers.NewManagedClusterLease("managed-cluster-lease", time.Now()))
+
+	leaseUpdater := &leaseUpdater{
+		hubClient:      hubClient,
+		clusterName:    testinghelpers.TestManagedClusterName,
+		leaseName:      "managed-cluster-lease",
+		holderIdentity: "registration-agent-0",
+		recorder:       eventstesting.NewTestingEventRecorder(t),
+	}
+	leaseUpdater.update(context.TODO())
+
+	lease, err := hubClient.CoordinationV1().Leases(testinghelpers.TestManagedClusterName).Get(context.TODO(), "managed-cluster-lease", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != "registration-agent-0" {
+		t.Errorf("expected holderIdentity \"registration-agent-0\" in the renewed lease, but got %v", lease.Spec.HolderIdentity)
+	}
+}
+
+func TestLeaseRenewInterval(t *testing.T) {
+	cases := []struct {
+		name                  string
+		leaseDurationSeconds  int32
+		leaseRenewInterval    time.Duration
+		expectedRenewInterval time.Duration
+	}{
+		{
+			name:                  "renew interval is not set",
+			leaseDurationSeconds:  60,
+			expectedRenewInterval: 60 * time.Second,
+		},
+		{
+			name:                  "renew interval is less than lease duration",
+			leaseDurationSeconds:  60,
+			leaseRenewInterval:    5 * time.Second,
+			expectedRenewInterval: 5 * time.Second,
+		},
+		{
+			name:                  "renew interval is not less than lease duration",
+			leaseDurationSeconds:  60,
+			leaseRenewInterval:    120 * time.Second,
+			expectedRenewInterval: 60 * time.Second,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := &managedClusterLeaseController{
+				clusterName:              testinghelpers.TestManagedClusterName,
+				lastLeaseDurationSeconds: c.leaseDurationSeconds,
+				leaseRenewInterval:       c.leaseRenewInterval,
+			}
+			if actual := ctrl.renewInterval(); actual != c.expectedRenewInterval {
+				t.Errorf("expected renew interval %v but got: %v", c.expectedRenewInterval, actual)
+			}
+		})
+	}
+}
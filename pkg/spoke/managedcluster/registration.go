@@ -28,6 +28,9 @@ func NewClientCertForHubController(
 	agentName string,
 	clientCertSecretNamespace string,
 	clientCertSecretName string,
+	clientCertSecretLabels map[string]string,
+	clientCertSecretAnnotations map[string]string,
+	credentialMirrorDir string,
 	kubeconfigData []byte,
 	spokeCoreClient corev1client.CoreV1Interface,
 	spokeSecretInformer corev1informers.SecretInformer,
@@ -44,6 +47,10 @@ func NewClientCertForHubController(
 			clientcert.AgentNameFile:   []byte(agentName),
 			clientcert.KubeconfigFile:  kubeconfigData,
 		},
+		SecretLabels:                    clientCertSecretLabels,
+		SecretAnnotations:               clientCertSecretAnnotations,
+		SupersededCredentialGracePeriod: clientcert.DefaultSupersededCredentialGracePeriod,
+		CredentialMirrorDir:             credentialMirrorDir,
 	}
 	csrOption := clientcert.CSROption{
 		ObjectMeta: metav1.ObjectMeta{
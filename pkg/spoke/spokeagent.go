@@ -1,12 +1,23 @@
 package spoke
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
 	"path"
+	"strings"
+	"sync"
 	"time"
 
 	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
@@ -18,6 +29,7 @@ import (
 	"open-cluster-management.io/registration/pkg/helpers"
 	"open-cluster-management.io/registration/pkg/spoke/addon"
 	"open-cluster-management.io/registration/pkg/spoke/managedcluster"
+	"open-cluster-management.io/registration/pkg/version"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -25,13 +37,20 @@ import (
 
 	"github.com/spf13/pflag"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -43,66 +62,104 @@ const (
 	spokeAgentNameLength = 5
 	// defaultSpokeComponentNamespace is the default namespace in which the spoke agent is deployed
 	defaultSpokeComponentNamespace = "open-cluster-management-agent"
+	// bootstrapWaitLogInterval is the interval at which the agent logs a progress message
+	// while waiting for the bootstrap csr to be approved
+	bootstrapWaitLogInterval = 30 * time.Second
 )
 
 // AddOnLeaseControllerSyncInterval is exposed so that integration tests can crank up the constroller sync speed.
 // TODO if we register the lease informer to the lease controller, we need to increase this time
 var AddOnLeaseControllerSyncInterval = 30 * time.Second
 
+// hubRegistrationAPIGroup is the API group of the hub registration API whose served
+// versions are checked against supportedHubVersions during bootstrap.
+const hubRegistrationAPIGroup = "cluster.open-cluster-management.io"
+
+// supportedHubVersions lists the versions of the hub registration API this agent is
+// compatible with. The hub must serve at least one of them for the bootstrap to proceed.
+var supportedHubVersions = []string{"v1"}
+
 // SpokeAgentOptions holds configuration for spoke cluster agent
 type SpokeAgentOptions struct {
-	ComponentNamespace       string
-	ClusterName              string
-	AgentName                string
-	BootstrapKubeconfig      string
-	HubKubeconfigSecret      string
-	HubKubeconfigDir         string
-	SpokeExternalServerURLs  []string
-	ClusterHealthCheckPeriod time.Duration
-	MaxCustomClusterClaims   int
-	SpokeKubeconfig          string
+	ComponentNamespace             string
+	ClusterName                    string
+	AgentName                      string
+	BootstrapKubeconfig            string
+	HubKubeconfigSecret            string
+	HubKubeconfigSecretLabels      map[string]string
+	HubKubeconfigSecretAnnotations map[string]string
+	HubKubeconfigDir               string
+	HubCredentialMirrorDir         string
+	SpokeExternalServerURLs        []string
+	HubInitiatedConnectivity       bool
+	ClusterHealthCheckPeriod       time.Duration
+	MaxCustomClusterClaims         int
+	SpokeKubeconfig                string
+	BootstrapWaitTimeout           time.Duration
+	BootstrapPollInterval          time.Duration
+	SkipHubVersionCheck            bool
+	LeaseRenewInterval             time.Duration
+	LeaseHolderIdentity            string
+	HubCAFile                      string
+	UserAgentSuffix                string
+	HubSPKIPins                    []string
+	DiagnosticsDir                 string
+	PreflightExec                  string
+	PreflightExecTimeout           time.Duration
+	VerifyHubKubeconfig            bool
+	VerifyHubKubeconfigTimeout     time.Duration
 }
 
 // NewSpokeAgentOptions returns a SpokeAgentOptions
 func NewSpokeAgentOptions() *SpokeAgentOptions {
 	return &SpokeAgentOptions{
-		HubKubeconfigSecret:      "hub-kubeconfig-secret",
-		HubKubeconfigDir:         "/spoke/hub-kubeconfig",
-		ClusterHealthCheckPeriod: 1 * time.Minute,
-		MaxCustomClusterClaims:   20,
+		HubKubeconfigSecret:        "hub-kubeconfig-secret",
+		HubKubeconfigDir:           "/spoke/hub-kubeconfig",
+		ClusterHealthCheckPeriod:   1 * time.Minute,
+		MaxCustomClusterClaims:     20,
+		BootstrapPollInterval:      1 * time.Second,
+		PreflightExecTimeout:       30 * time.Second,
+		VerifyHubKubeconfigTimeout: time.Minute,
+		// the pod name is published via the downward API, so each agent replica renews
+		// the lease with its own identity by default
+		LeaseHolderIdentity: os.Getenv("POD_NAME"),
 	}
 }
 
 // RunSpokeAgent starts the controllers on spoke agent to register to the hub.
 //
 // There are two deploy mode for the registration agent: 'Default' mode and 'Detached' mode,
-// - In Default mode, the registration agent pod runs on the spoke/managed cluster.
-// - In Detached mode, the registration agent pod may run on a separated cluster from the
-//   spoke/managed cluster, we define this cluster as 'management' cluster.
+//   - In Default mode, the registration agent pod runs on the spoke/managed cluster.
+//   - In Detached mode, the registration agent pod may run on a separated cluster from the
+//     spoke/managed cluster, we define this cluster as 'management' cluster.
 //
 // The spoke agent uses four kubeconfigs for different concerns:
-// - The 'management' kubeconfig: used to communicate with the cluster where the agent pod
-//   runs. In Default mode, it is the managed cluster's kubeconfig; in Detached mode, it is
-//   the management cluster's kubeconfig.
-// - The 'spoke' kubeconfig: used to communicate with the spoke/managed cluster which will
-//   be registered to the hub.
-// - The 'bootstrap' kubeconfig: used to communicate with the hub in order to
-//   submit a CertificateSigningRequest, begin the join flow with the hub, and
-//   to write the 'hub' kubeconfig.
-// - The 'hub' kubeconfig: used to communicate with the hub using a signed
-//   certificate from the hub.
+//   - The 'management' kubeconfig: used to communicate with the cluster where the agent pod
+//     runs. In Default mode, it is the managed cluster's kubeconfig; in Detached mode, it is
+//     the management cluster's kubeconfig.
+//   - The 'spoke' kubeconfig: used to communicate with the spoke/managed cluster which will
+//     be registered to the hub.
+//   - The 'bootstrap' kubeconfig: used to communicate with the hub in order to
+//     submit a CertificateSigningRequest, begin the join flow with the hub, and
+//     to write the 'hub' kubeconfig.
+//   - The 'hub' kubeconfig: used to communicate with the hub using a signed
+//     certificate from the hub.
 //
 // RunSpokeAgent handles the following scenarios:
-//   #1. Bootstrap kubeconfig is valid and there is no valid hub kubeconfig in secret
-//   #2. Both bootstrap kubeconfig and hub kubeconfig are valid
-//   #3. Bootstrap kubeconfig is invalid (e.g. certificate expired) and hub kubeconfig is valid
-//   #4. Neither bootstrap kubeconfig nor hub kubeconfig is valid
+//
+//	#1. Bootstrap kubeconfig is valid and there is no valid hub kubeconfig in secret
+//	#2. Both bootstrap kubeconfig and hub kubeconfig are valid
+//	#3. Bootstrap kubeconfig is invalid (e.g. certificate expired) and hub kubeconfig is valid
+//	#4. Neither bootstrap kubeconfig nor hub kubeconfig is valid
 //
 // A temporary ClientCertForHubController with bootstrap kubeconfig is created
 // and started if the hub kubeconfig does not exist or is invalid and used to
 // create a valid hub kubeconfig. Once the hub kubeconfig is valid, the
 // temporary controller is stopped and the main controllers are started.
 func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
+	// rate limit event emission so a hot resync loop cannot flood the apiserver with events
+	eventRecorder := helpers.NewDefaultRateLimitedRecorder(controllerContext.EventRecorder)
+
 	// create management kube client
 	managementKubeClient, err := kubernetes.NewForConfig(controllerContext.KubeConfig)
 	if err != nil {
@@ -122,7 +179,7 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 	}
 
 	// the hub kubeconfig secret stored in the cluster where the agent pod runs
-	if err := o.Complete(managementKubeClient.CoreV1(), ctx, controllerContext.EventRecorder); err != nil {
+	if err := o.Complete(managementKubeClient.CoreV1(), ctx, eventRecorder); err != nil {
 		klog.Fatal(err)
 	}
 
@@ -132,6 +189,12 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 
 	klog.Infof("Cluster name is %q and agent name is %q", o.ClusterName, o.AgentName)
 
+	// run the configured preflight check before anything hub-bound happens, an
+	// environment failing its local prerequisites must not start to bootstrap
+	if err := o.runPreflightExec(ctx); err != nil {
+		return err
+	}
+
 	// create shared informer factory for spoke cluster
 	spokeKubeInformerFactory := informers.NewSharedInformerFactory(spokeKubeClient, 10*time.Minute)
 
@@ -149,6 +212,10 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 	if err != nil {
 		return fmt.Errorf("unable to load bootstrap kubeconfig from file %q: %w", o.BootstrapKubeconfig, err)
 	}
+	if err := o.completeHubClientConfig(bootstrapClientConfig); err != nil {
+		return err
+	}
+	eventRecorder.Eventf("BootstrapKubeconfigLoaded", "The bootstrap kubeconfig %q is loaded.", o.BootstrapKubeconfig)
 	bootstrapKubeClient, err := kubernetes.NewForConfig(bootstrapClientConfig)
 	if err != nil {
 		return err
@@ -158,12 +225,19 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 		return err
 	}
 
+	// refuse to bootstrap against a hub whose registration API is outside the supported
+	// range, an agent joined to an incompatible hub fails in hard to diagnose ways later
+	if err := o.checkHubVersionCompatibility(bootstrapKubeClient.Discovery()); err != nil {
+		o.writeBootstrapDiagnostics(ctx, bootstrapClientConfig, bootstrapKubeClient, err)
+		return err
+	}
+
 	// start a SpokeClusterCreatingController to make sure there is a spoke cluster on hub cluster
 	spokeClusterCreatingController := managedcluster.NewManagedClusterCreatingController(
-		o.ClusterName, o.SpokeExternalServerURLs,
+		o.ClusterName, o.SpokeExternalServerURLs, o.HubInitiatedConnectivity,
 		spokeClusterCABundle,
 		bootstrapClusterClient,
-		controllerContext.EventRecorder,
+		eventRecorder,
 	)
 	go spokeClusterCreatingController.Run(ctx, 1)
 
@@ -172,7 +246,7 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 		// the hub kubeconfig secret stored in the cluster where the agent pod runs
 		managementKubeClient.CoreV1(),
 		namespacedManagementKubeInformerFactory.Core().V1().Secrets(),
-		controllerContext.EventRecorder,
+		eventRecorder,
 	)
 	go hubKubeconfigSecretController.Run(ctx, 1)
 
@@ -201,13 +275,14 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 		controllerName := fmt.Sprintf("BootstrapClientCertController@cluster:%s", o.ClusterName)
 		clientCertForHubController := managedcluster.NewClientCertForHubController(
 			o.ClusterName, o.AgentName, o.ComponentNamespace, o.HubKubeconfigSecret,
+			o.HubKubeconfigSecretLabels, o.HubKubeconfigSecretAnnotations, o.HubCredentialMirrorDir,
 			kubeconfigData,
 			// store the secret in the cluster where the agent pod runs
 			managementKubeClient.CoreV1(),
 			namespacedManagementKubeInformerFactory.Core().V1().Secrets(),
 			bootstrapKubeClient.CertificatesV1().CertificateSigningRequests(),
 			bootstrapInformerFactory.Certificates().V1().CertificateSigningRequests(),
-			controllerContext.EventRecorder,
+			eventRecorder,
 			controllerName,
 		)
 
@@ -218,10 +293,18 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 
 		go clientCertForHubController.Run(bootstrapCtx, 1)
 
+		// watch the bootstrap kubeconfig source so a rotated bootstrap secret is picked up
+		bootstrapKubeconfigChanged, err := bootstrapKubeconfigChangedCheck(o.BootstrapKubeconfig)
+		if err != nil {
+			stopBootstrap()
+			return err
+		}
+
 		// wait for the hub client config is ready.
 		klog.Info("Waiting for hub client config and managed cluster to be ready")
-		if err := wait.PollImmediateInfinite(1*time.Second, o.hasValidHubClientConfig); err != nil {
+		if err := o.waitForValidHubClientConfig(ctx, bootstrapKubeconfigChanged); err != nil {
 			// TODO need run the bootstrap CSR forever to re-establish the client-cert if it is ever lost.
+			o.writeBootstrapDiagnostics(ctx, bootstrapClientConfig, bootstrapKubeClient, err)
 			stopBootstrap()
 			return err
 		}
@@ -235,12 +318,22 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 	if err != nil {
 		return err
 	}
+	if err := o.completeHubClientConfig(hubClientConfig); err != nil {
+		return err
+	}
 
 	hubKubeClient, err := kubernetes.NewForConfig(hubClientConfig)
 	if err != nil {
 		return err
 	}
 
+	// prove the issued credential works before the bootstrap is declared complete, an
+	// unusable certificate otherwise only surfaces once the controllers start looping
+	if err := o.verifyHubKubeconfig(ctx, hubKubeClient.AuthorizationV1()); err != nil {
+		o.writeBootstrapDiagnostics(ctx, hubClientConfig, hubKubeClient, err)
+		return err
+	}
+
 	hubClusterClient, err := clusterv1client.NewForConfig(hubClientConfig)
 	if err != nil {
 		return err
@@ -263,7 +356,7 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 		}),
 	)
 
-	controllerContext.EventRecorder.Event("HubClientConfigReady", "Client config for hub is ready.")
+	eventRecorder.Event("HubClientConfigReady", "Client config for hub is ready.")
 
 	// create a kubeconfig with references to the key/cert files in the same secret
 	kubeconfig := clientcert.BuildKubeconfig(hubClientConfig, clientcert.TLSCertFile, clientcert.TLSKeyFile)
@@ -276,13 +369,14 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 	controllerName := fmt.Sprintf("ClientCertController@cluster:%s", o.ClusterName)
 	clientCertForHubController := managedcluster.NewClientCertForHubController(
 		o.ClusterName, o.AgentName, o.ComponentNamespace, o.HubKubeconfigSecret,
+		o.HubKubeconfigSecretLabels, o.HubKubeconfigSecretAnnotations, o.HubCredentialMirrorDir,
 		kubeconfigData,
 		// store the secret in the cluster where the agent pod runs
 		managementKubeClient.CoreV1(),
 		namespacedManagementKubeInformerFactory.Core().V1().Secrets(),
 		hubKubeClient.CertificatesV1().CertificateSigningRequests(),
 		hubKubeInformerFactory.Certificates().V1().CertificateSigningRequests(),
-		controllerContext.EventRecorder,
+		eventRecorder,
 		controllerName,
 	)
 
@@ -291,15 +385,17 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 		o.ClusterName,
 		hubClusterClient,
 		hubClusterInformerFactory.Cluster().V1().ManagedClusters(),
-		controllerContext.EventRecorder,
+		eventRecorder,
 	)
 
 	// create ManagedClusterLeaseController to keep the spoke cluster heartbeat
 	managedClusterLeaseController := managedcluster.NewManagedClusterLeaseController(
 		o.ClusterName,
+		o.LeaseRenewInterval,
+		o.LeaseHolderIdentity,
 		hubKubeClient,
 		hubClusterInformerFactory.Cluster().V1().ManagedClusters(),
-		controllerContext.EventRecorder,
+		eventRecorder,
 	)
 
 	// create NewManagedClusterStatusController to update the spoke cluster status
@@ -310,7 +406,7 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 		spokeKubeClient.Discovery(),
 		spokeKubeInformerFactory.Core().V1().Nodes(),
 		o.ClusterHealthCheckPeriod,
-		controllerContext.EventRecorder,
+		eventRecorder,
 	)
 	spokeClusterClient, err := clusterv1client.NewForConfig(spokeClientConfig)
 	if err != nil {
@@ -327,7 +423,21 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 			hubClusterClient,
 			hubClusterInformerFactory.Cluster().V1().ManagedClusters(),
 			spokeClusterInformerFactory.Cluster().V1alpha1().ClusterClaims(),
-			controllerContext.EventRecorder,
+			eventRecorder,
+		)
+	}
+
+	var managedClusterCapacitySummaryController factory.Controller
+	if features.DefaultMutableFeatureGate.Enabled(features.ClusterCapacitySummary) {
+		// create managedClusterCapacitySummaryController to report the node count and the
+		// aggregated allocatable resources of the spoke cluster
+		managedClusterCapacitySummaryController = managedcluster.NewManagedClusterCapacitySummaryController(
+			o.ClusterName,
+			hubClusterClient,
+			hubClusterInformerFactory.Cluster().V1().ManagedClusters(),
+			spokeKubeClient,
+			o.ClusterHealthCheckPeriod,
+			eventRecorder,
 		)
 	}
 
@@ -341,7 +451,7 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 			hubKubeClient.CoordinationV1(),
 			spokeKubeClient.CoordinationV1(),
 			AddOnLeaseControllerSyncInterval, //TODO: this interval time should be allowed to change from outside
-			controllerContext.EventRecorder,
+			eventRecorder,
 		)
 
 		addOnRegistrationController = addon.NewAddOnRegistrationController(
@@ -355,7 +465,7 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 			hubKubeInformerFactory.Certificates().V1().CertificateSigningRequests(),
 			addOnInformerFactory.Addon().V1alpha1().ManagedClusterAddOns(),
 			hubKubeClient.CertificatesV1().CertificateSigningRequests(),
-			controllerContext.EventRecorder,
+			eventRecorder,
 		)
 	}
 
@@ -368,11 +478,15 @@ func (o *SpokeAgentOptions) RunSpokeAgent(ctx context.Context, controllerContext
 
 	go clientCertForHubController.Run(ctx, 1)
 	go managedClusterJoiningController.Run(ctx, 1)
+	eventRecorder.Eventf("ManagedClusterLeaseStarted", "The lease controller is started to keep the heartbeat of managed cluster %q.", o.ClusterName)
 	go managedClusterLeaseController.Run(ctx, 1)
 	go managedClusterHealthCheckController.Run(ctx, 1)
 	if features.DefaultMutableFeatureGate.Enabled(features.ClusterClaim) {
 		go managedClusterClaimController.Run(ctx, 1)
 	}
+	if features.DefaultMutableFeatureGate.Enabled(features.ClusterCapacitySummary) {
+		go managedClusterCapacitySummaryController.Run(ctx, 1)
+	}
 	if features.DefaultMutableFeatureGate.Enabled(features.AddonManagement) {
 		go addOnLeaseController.Run(ctx, 1)
 		go addOnRegistrationController.Run(ctx, 1)
@@ -391,16 +505,50 @@ func (o *SpokeAgentOptions) AddFlags(fs *pflag.FlagSet) {
 		"The path of the kubeconfig file for agent bootstrap.")
 	fs.StringVar(&o.HubKubeconfigSecret, "hub-kubeconfig-secret", o.HubKubeconfigSecret,
 		"The name of secret in component namespace storing kubeconfig for hub.")
+	fs.StringToStringVar(&o.HubKubeconfigSecretLabels, "hub-kubeconfig-secret-labels", o.HubKubeconfigSecretLabels,
+		"A list of key=value labels applied to the hub kubeconfig secret whenever the agent creates or updates it, e.g. to satisfy secret-management policies. Labels set by others are kept.")
+	fs.StringToStringVar(&o.HubKubeconfigSecretAnnotations, "hub-kubeconfig-secret-annotations", o.HubKubeconfigSecretAnnotations,
+		"A list of key=value annotations applied to the hub kubeconfig secret whenever the agent creates or updates it. Annotations set by others are kept.")
 	fs.StringVar(&o.HubKubeconfigDir, "hub-kubeconfig-dir", o.HubKubeconfigDir,
 		"The mount path of hub-kubeconfig-secret in the container.")
+	fs.StringVar(&o.HubCredentialMirrorDir, "hub-credential-mirror-dir", o.HubCredentialMirrorDir,
+		"A directory the current hub client certificate, key and kubeconfig are additionally written to whenever the hub kubeconfig secret is saved, so a co-located sidecar can watch the files. The files are written atomically with mode 0600. If empty, no files are written.")
 	fs.StringVar(&o.SpokeKubeconfig, "spoke-kubeconfig", o.SpokeKubeconfig,
 		"The path of the kubeconfig file for managed/spoke cluster. If this is not set, will use '--kubeconfig' to build client to connect to the managed cluster.")
 	fs.StringArrayVar(&o.SpokeExternalServerURLs, "spoke-external-server-urls", o.SpokeExternalServerURLs,
 		"A list of reachable spoke cluster api server URLs for hub cluster.")
+	fs.BoolVar(&o.HubInitiatedConnectivity, "hub-initiated-connectivity", o.HubInitiatedConnectivity,
+		"Register the managed cluster without client configs and mark it with a well-known label. Use this when the hub reaches the spoke cluster itself instead of through an agent-advertised URL. It must not be used together with spoke-external-server-urls.")
+	fs.DurationVar(&o.BootstrapWaitTimeout, "bootstrap-wait-timeout", o.BootstrapWaitTimeout,
+		"The maximum time to wait for the bootstrap CSR to be approved and the hub client config to become ready before the agent gives up with an error. A zero timeout waits forever, e.g. for environments with manual CSR approval.")
+	fs.DurationVar(&o.BootstrapPollInterval, "bootstrap-poll-interval", o.BootstrapPollInterval,
+		"The interval at which the agent checks whether the hub client config is ready during bootstrap.")
+	fs.BoolVar(&o.SkipHubVersionCheck, "skip-hub-version-check", o.SkipHubVersionCheck,
+		"Bypass the check that the hub serves a supported version of the registration API during bootstrap. Only intended for emergencies, an agent running against an incompatible hub may misbehave.")
 	fs.DurationVar(&o.ClusterHealthCheckPeriod, "cluster-healthcheck-period", o.ClusterHealthCheckPeriod,
 		"The period to check managed cluster kube-apiserver health")
 	fs.IntVar(&o.MaxCustomClusterClaims, "max-custom-cluster-claims", o.MaxCustomClusterClaims,
 		"The max number of custom cluster claims to expose.")
+	fs.DurationVar(&o.LeaseRenewInterval, "lease-renew-interval", o.LeaseRenewInterval,
+		"The interval to renew the lease of the managed cluster on hub. If not set, the lease is renewed with the interval computed from the lease duration of the managed cluster. It must be less than the lease duration to take effect.")
+	fs.StringVar(&o.LeaseHolderIdentity, "lease-holder-identity", o.LeaseHolderIdentity,
+		"The holderIdentity recorded in the renewed lease of the managed cluster, identifying the agent instance that keeps the heartbeat. It defaults to the pod name published in the POD_NAME environment variable via the downward API. If empty, the holderIdentity of the lease is left unchanged.")
+	fs.StringVar(&o.UserAgentSuffix, "user-agent-suffix", o.UserAgentSuffix,
+		"An optional suffix appended to the User-Agent the agent uses for all hub-bound clients, which helps to attribute apiserver load in the hub audit logs.")
+	fs.StringVar(&o.HubCAFile, "hub-ca-file", o.HubCAFile,
+		"The path of an additional CA file trusted by the agent for the hub. The CA data in the file is merged with the CA data in the bootstrap/hub kubeconfig, which allows operators to pre-trust an upcoming CA during the hub CA rotation. The file is re-read on every TLS handshake with the hub, so a changed file is picked up by new connections without restarting the agent.")
+	fs.StringArrayVar(&o.HubSPKIPins, "hub-spki-pin", o.HubSPKIPins,
+		"The hex encoded SHA-256 fingerprint of the subject public key info (SPKI) of the hub apiserver certificate. May be specified multiple times. When set, the agent only connects to a hub whose certificate matches one of the pins, in addition to the normal CA validation.")
+	fs.StringVar(&o.DiagnosticsDir, "diagnostics-dir", o.DiagnosticsDir,
+		"The directory a diagnostics bundle is written to when the bootstrap fails, so the failure can still be analyzed after the agent logs rotated away. The bundle contains no private keys or tokens. If empty, no bundle is written.")
+	fs.StringVar(&o.PreflightExec, "preflight-exec", o.PreflightExec,
+		"The path of a command to run before the bootstrap is attempted, e.g. to verify local prerequisites. The bootstrap is aborted with the stderr of the command on a non-zero exit. If empty, no preflight check is run.")
+	fs.DurationVar(&o.PreflightExecTimeout, "preflight-exec-timeout", o.PreflightExecTimeout,
+		"The maximum time the preflight command may run before it is killed and the bootstrap is aborted.")
+	fs.BoolVar(&o.VerifyHubKubeconfig, "verify-hub-kubeconfig", o.VerifyHubKubeconfig,
+		"Verify the hub kubeconfig with a minimal authenticated call against the hub before the bootstrap is declared complete, so an unusable issued certificate surfaces as a clear bootstrap failure.")
+	fs.DurationVar(&o.VerifyHubKubeconfigTimeout, "verify-hub-kubeconfig-timeout", o.VerifyHubKubeconfigTimeout,
+		"The maximum time the hub kubeconfig verification retries a failing call before the bootstrap is aborted.")
 }
 
 // Validate verifies the inputs.
@@ -413,10 +561,21 @@ func (o *SpokeAgentOptions) Validate() error {
 		return errors.New("cluster name is empty")
 	}
 
+	// the cluster name is published as the value of the cluster name label on the csrs
+	// created by the agent, a name that is no valid label value would make every csr
+	// creation fail on the hub
+	if errs := validation.IsValidLabelValue(o.ClusterName); len(errs) > 0 {
+		return fmt.Errorf("cluster name %q cannot be used as a label value: %s", o.ClusterName, strings.Join(errs, "; "))
+	}
+
 	if o.AgentName == "" {
 		return errors.New("agent name is empty")
 	}
 
+	if o.HubInitiatedConnectivity && len(o.SpokeExternalServerURLs) != 0 {
+		return errors.New("hub-initiated-connectivity must not be used together with spoke-external-server-urls")
+	}
+
 	// if SpokeExternalServerURLs is specified we validate every URL in it, we expect the spoke external server URL is https
 	if len(o.SpokeExternalServerURLs) != 0 {
 		for _, serverURL := range o.SpokeExternalServerURLs {
@@ -430,6 +589,47 @@ func (o *SpokeAgentOptions) Validate() error {
 		return errors.New("cluster healthcheck period must greater than zero")
 	}
 
+	if o.BootstrapPollInterval <= 0 {
+		return errors.New("bootstrap poll interval must greater than zero")
+	}
+
+	if o.BootstrapWaitTimeout < 0 {
+		return errors.New("bootstrap wait timeout must not be negative")
+	}
+
+	if o.LeaseRenewInterval < 0 {
+		return errors.New("lease renew interval must not be negative")
+	}
+
+	if o.PreflightExec != "" && o.PreflightExecTimeout <= 0 {
+		return errors.New("preflight exec timeout must greater than zero")
+	}
+
+	if o.VerifyHubKubeconfig && o.VerifyHubKubeconfigTimeout <= 0 {
+		return errors.New("verify hub kubeconfig timeout must greater than zero")
+	}
+
+	for key, value := range o.HubKubeconfigSecretLabels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("hub-kubeconfig-secret-labels key %q is invalid: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("hub-kubeconfig-secret-labels value %q is invalid: %s", value, strings.Join(errs, "; "))
+		}
+	}
+
+	for key := range o.HubKubeconfigSecretAnnotations {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("hub-kubeconfig-secret-annotations key %q is invalid: %s", key, strings.Join(errs, "; "))
+		}
+	}
+
+	for _, pin := range o.HubSPKIPins {
+		if decoded, err := hex.DecodeString(pin); err != nil || len(decoded) != sha256.Size {
+			return fmt.Errorf("hub-spki-pin %q is not a hex encoded SHA-256 fingerprint", pin)
+		}
+	}
+
 	return nil
 }
 
@@ -467,11 +667,12 @@ func generateAgentName() string {
 }
 
 // hasValidHubClientConfig returns ture if all the conditions below are met:
-//   1. KubeconfigFile exists;
-//   2. TLSKeyFile exists;
-//   3. TLSCertFile exists;
-//   4. Certificate in TLSCertFile is issued for the current cluster/agent;
-//   5. Certificate in TLSCertFile is not expired;
+//  1. KubeconfigFile exists;
+//  2. TLSKeyFile exists;
+//  3. TLSCertFile exists;
+//  4. Certificate in TLSCertFile is issued for the current cluster/agent;
+//  5. Certificate in TLSCertFile is not expired;
+//
 // Normally, KubeconfigFile/TLSKeyFile/TLSCertFile will be created once the bootstrap process
 // completes. Changing the name of the cluster will make the existing hub kubeconfig invalid,
 // because certificate in TLSCertFile is issued to a specific cluster/agent.
@@ -510,6 +711,272 @@ func (o *SpokeAgentOptions) hasValidHubClientConfig() (bool, error) {
 	return clientcert.IsCertificateValid(certData, nil)
 }
 
+// waitForValidHubClientConfig polls until the bootstrap process produced a valid hub
+// client config, typically until the bootstrap csr is approved by the hub. It logs a
+// periodic progress message while waiting, stops when the given context is cancelled on
+// shutdown and returns a clean error once the configured timeout elapses. A zero timeout
+// waits forever, a rotated bootstrap kubeconfig aborts the wait.
+func (o *SpokeAgentOptions) waitForValidHubClientConfig(ctx context.Context, bootstrapKubeconfigChanged wait.ConditionFunc) error {
+	waitCtx := ctx
+	if o.BootstrapWaitTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, o.BootstrapWaitTimeout)
+		defer cancel()
+	}
+
+	lastLogged := time.Now()
+	err := wait.PollImmediateUntil(o.BootstrapPollInterval, func() (bool, error) {
+		// a rotated bootstrap kubeconfig invalidates the clients of the in-progress
+		// CSR flow, restart the agent to bootstrap with the new config
+		if changed, err := bootstrapKubeconfigChanged(); err == nil && changed {
+			return false, fmt.Errorf("the bootstrap kubeconfig %q changed, restart the bootstrap process with the new config", o.BootstrapKubeconfig)
+		}
+		ok, err := o.hasValidHubClientConfig()
+		if !ok && err == nil && time.Since(lastLogged) >= bootstrapWaitLogInterval {
+			klog.Infof("Still waiting for the bootstrap csr of cluster %q to be approved and the hub client config to become ready", o.ClusterName)
+			lastLogged = time.Now()
+		}
+		return ok, err
+	}, waitCtx.Done())
+	// distinguish the elapsed timeout from a shutdown, both close the wait channel
+	if err == wait.ErrWaitTimeout && o.BootstrapWaitTimeout > 0 && ctx.Err() == nil {
+		return fmt.Errorf("the hub client config did not become ready within %s, the bootstrap csr of cluster %q may not be approved", o.BootstrapWaitTimeout, o.ClusterName)
+	}
+	return err
+}
+
+// verifyHubKubeconfig proves the hub kubeconfig works by making a minimal authenticated
+// call against the hub, a SelfSubjectAccessReview for the agent's own ManagedCluster. The
+// authorization answer does not matter, an unusable credential fails the call itself. A
+// failing call is retried until the configured timeout elapses, a certificate that is not
+// yet valid due to clock skew may start to work within the window. Disabled unless the
+// verification is enabled by flag.
+func (o *SpokeAgentOptions) verifyHubKubeconfig(ctx context.Context, authorizationClient authorizationv1client.AuthorizationV1Interface) error {
+	if !o.VerifyHubKubeconfig {
+		return nil
+	}
+
+	verifyCtx, cancel := context.WithTimeout(ctx, o.VerifyHubKubeconfigTimeout)
+	defer cancel()
+
+	var lastErr error
+	err := wait.PollImmediateUntil(o.BootstrapPollInterval, func() (bool, error) {
+		_, lastErr = authorizationClient.SelfSubjectAccessReviews().Create(verifyCtx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Group:    "cluster.open-cluster-management.io",
+					Resource: "managedclusters",
+					Verb:     "get",
+					Name:     o.ClusterName,
+				},
+			},
+		}, metav1.CreateOptions{})
+		if lastErr != nil {
+			klog.Warningf("The hub kubeconfig verification call failed, retrying: %v", lastErr)
+			return false, nil
+		}
+		return true, nil
+	}, verifyCtx.Done())
+	if err == nil {
+		klog.Info("The hub kubeconfig is verified with an authenticated call against the hub")
+		return nil
+	}
+	// distinguish the elapsed timeout from a shutdown, both close the wait channel
+	if err == wait.ErrWaitTimeout && ctx.Err() == nil {
+		return fmt.Errorf("the hub kubeconfig could not be verified within %s: %v", o.VerifyHubKubeconfigTimeout, lastErr)
+	}
+	return err
+}
+
+// bootstrapDiagnosticsSummaryFile and bootstrapDiagnosticsCSRFile are the file names of
+// the diagnostics bundle written on a bootstrap failure.
+const (
+	bootstrapDiagnosticsSummaryFile = "bootstrap-summary.json"
+	bootstrapDiagnosticsCSRFile     = "bootstrap-csrs.json"
+)
+
+// bootstrapConfigSummary is the sanitized subset of the bootstrap client config included
+// in the diagnostics bundle. It deliberately carries no key, certificate or token data.
+type bootstrapConfigSummary struct {
+	Host          string `json:"host"`
+	TLSServerName string `json:"tlsServerName,omitempty"`
+	HasCAData     bool   `json:"hasCAData"`
+	CAFile        string `json:"caFile,omitempty"`
+	Insecure      bool   `json:"insecure"`
+}
+
+// bootstrapDiagnosticsSummary is the content of the summary file of the diagnostics
+// bundle written on a bootstrap failure.
+type bootstrapDiagnosticsSummary struct {
+	Timestamp       metav1.Time            `json:"timestamp"`
+	ClusterName     string                 `json:"clusterName"`
+	AgentName       string                 `json:"agentName"`
+	Error           string                 `json:"error"`
+	BootstrapConfig bootstrapConfigSummary `json:"bootstrapConfig"`
+}
+
+// bootstrapCSRSummary is the status of a single bootstrap csr of this cluster included in
+// the diagnostics bundle. The csr request and certificate data are omitted.
+type bootstrapCSRSummary struct {
+	Name              string                                              `json:"name"`
+	SignerName        string                                              `json:"signerName"`
+	Username          string                                              `json:"username"`
+	CreationTimestamp metav1.Time                                         `json:"creationTimestamp"`
+	Conditions        []certificatesv1.CertificateSigningRequestCondition `json:"conditions,omitempty"`
+}
+
+// writeBootstrapDiagnostics writes a self-contained diagnostics bundle to the configured
+// diagnostics directory after a bootstrap failure: a sanitized summary of the bootstrap
+// config together with the failure and a dump of the csr statuses of this cluster on the
+// hub. Support can collect the bundle long after the agent logs rotated away. The bundle
+// is written best-effort, a failure to write it only logs, the bootstrap error is already
+// being surfaced.
+func (o *SpokeAgentOptions) writeBootstrapDiagnostics(ctx context.Context, bootstrapClientConfig *rest.Config, bootstrapKubeClient kubernetes.Interface, bootstrapErr error) {
+	if o.DiagnosticsDir == "" {
+		return
+	}
+	if err := os.MkdirAll(o.DiagnosticsDir, 0700); err != nil {
+		klog.Errorf("Unable to create the diagnostics directory %q: %v", o.DiagnosticsDir, err)
+		return
+	}
+
+	summary := bootstrapDiagnosticsSummary{
+		Timestamp:   metav1.Now(),
+		ClusterName: o.ClusterName,
+		AgentName:   o.AgentName,
+		Error:       bootstrapErr.Error(),
+		BootstrapConfig: bootstrapConfigSummary{
+			Host:          bootstrapClientConfig.Host,
+			TLSServerName: bootstrapClientConfig.ServerName,
+			HasCAData:     len(bootstrapClientConfig.CAData) > 0,
+			CAFile:        bootstrapClientConfig.CAFile,
+			Insecure:      bootstrapClientConfig.Insecure,
+		},
+	}
+	if err := writeDiagnosticsFile(path.Join(o.DiagnosticsDir, bootstrapDiagnosticsSummaryFile), summary); err != nil {
+		klog.Errorf("Unable to write the bootstrap diagnostics summary: %v", err)
+	}
+
+	csrSummaries := []bootstrapCSRSummary{}
+	csrList, err := bootstrapKubeClient.CertificatesV1().CertificateSigningRequests().List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", clientcert.ClusterNameLabel, o.ClusterName),
+	})
+	if err != nil {
+		klog.Errorf("Unable to list the bootstrap csrs of cluster %q for the diagnostics bundle: %v", o.ClusterName, err)
+	} else {
+		for _, csr := range csrList.Items {
+			csrSummaries = append(csrSummaries, bootstrapCSRSummary{
+				Name:              csr.Name,
+				SignerName:        csr.Spec.SignerName,
+				Username:          csr.Spec.Username,
+				CreationTimestamp: csr.CreationTimestamp,
+				Conditions:        csr.Status.Conditions,
+			})
+		}
+		if err := writeDiagnosticsFile(path.Join(o.DiagnosticsDir, bootstrapDiagnosticsCSRFile), csrSummaries); err != nil {
+			klog.Errorf("Unable to write the bootstrap csr diagnostics: %v", err)
+		}
+	}
+
+	klog.Infof("A bootstrap diagnostics bundle is written to %q", o.DiagnosticsDir)
+}
+
+// writeDiagnosticsFile writes the given diagnostics content as indented json to the given
+// file, readable by the collecting user only.
+func writeDiagnosticsFile(filePath string, content interface{}) error {
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, data, 0600)
+}
+
+// runPreflightExec runs the configured preflight command and aborts the bootstrap on a
+// non-zero exit, surfacing the stderr of the command in the returned error. The command
+// runs with a minimal environment so credentials in the agent environment cannot leak
+// into the plugin, and is killed after the configured timeout.
+func (o *SpokeAgentOptions) runPreflightExec(ctx context.Context) error {
+	if o.PreflightExec == "" {
+		return nil
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, o.PreflightExecTimeout)
+	defer cancel()
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(execCtx, o.PreflightExec)
+	// only pass what the check needs, the agent environment may carry credentials
+	cmd.Env = []string{
+		fmt.Sprintf("PATH=%s", os.Getenv("PATH")),
+		fmt.Sprintf("CLUSTER_NAME=%s", o.ClusterName),
+		fmt.Sprintf("AGENT_NAME=%s", o.AgentName),
+	}
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	if err == nil {
+		klog.Infof("The preflight command %q succeeded", o.PreflightExec)
+		return nil
+	}
+	if execCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("the preflight command %q did not complete within %s", o.PreflightExec, o.PreflightExecTimeout)
+	}
+	return fmt.Errorf("the preflight command %q failed: %v, stderr: %s", o.PreflightExec, err, strings.TrimSpace(stderr.String()))
+}
+
+// checkHubVersionCompatibility verifies via discovery that the hub serves a supported
+// version of the registration API before the agent bootstraps against it. The check can
+// be bypassed with a flag for emergencies, e.g. to keep an agent running against a hub
+// that is being migrated.
+func (o *SpokeAgentOptions) checkHubVersionCompatibility(discoveryClient discovery.DiscoveryInterface) error {
+	if o.SkipHubVersionCheck {
+		klog.Warningf("The hub version compatibility check is bypassed, the agent may misbehave against an incompatible hub")
+		return nil
+	}
+
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		return fmt.Errorf("unable to discover the API groups of the hub: %w", err)
+	}
+
+	supported := sets.NewString(supportedHubVersions...)
+	for _, group := range groups.Groups {
+		if group.Name != hubRegistrationAPIGroup {
+			continue
+		}
+		served := []string{}
+		for _, groupVersion := range group.Versions {
+			if supported.Has(groupVersion.Version) {
+				return nil
+			}
+			served = append(served, groupVersion.Version)
+		}
+		return fmt.Errorf("the hub serves the %s API in versions %q but this agent supports %q, use --skip-hub-version-check to bypass the check",
+			hubRegistrationAPIGroup, strings.Join(served, ","), strings.Join(supportedHubVersions, ","))
+	}
+	return fmt.Errorf("the hub does not serve the %s API, it is not a registration hub or too old, use --skip-hub-version-check to bypass the check",
+		hubRegistrationAPIGroup)
+}
+
+// bootstrapKubeconfigChangedCheck returns a condition func that reports whether the
+// content of the given bootstrap kubeconfig file has changed since this function was
+// called. Rotating the bootstrap secret changes the mounted file content.
+func bootstrapKubeconfigChangedCheck(bootstrapKubeconfigPath string) (wait.ConditionFunc, error) {
+	originalContent, err := ioutil.ReadFile(path.Clean(bootstrapKubeconfigPath))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load bootstrap kubeconfig from file %q: %w", bootstrapKubeconfigPath, err)
+	}
+	return func() (bool, error) {
+		content, err := ioutil.ReadFile(path.Clean(bootstrapKubeconfigPath))
+		if err != nil {
+			// the file might be replaced at this very moment, check again later
+			klog.V(4).Infof("Unable to load bootstrap kubeconfig from file %q", bootstrapKubeconfigPath)
+			return false, nil
+		}
+		return !bytes.Equal(originalContent, content), nil
+	}, nil
+}
+
 // getOrGenerateClusterAgentNames returns cluster name and agent name.
 // Rules for picking up cluster name:
 //   1. Use cluster name from input arguments if 'cluster-name' is specified;
@@ -519,9 +986,9 @@ func (o *SpokeAgentOptions) hasValidHubClientConfig() (bool, error) {
 //   5. Generate a random cluster name then;
 
 // Rules for picking up agent name:
-//   1. Parse agent name from the common name of the certification subject if the certification exists;
-//   2. Fallback to agent name in the mounted secret if it exists;
-//   3. Generate a random agent name then;
+//  1. Parse agent name from the common name of the certification subject if the certification exists;
+//  2. Fallback to agent name in the mounted secret if it exists;
+//  3. Generate a random agent name then;
 func (o *SpokeAgentOptions) getOrGenerateClusterAgentNames() (string, string) {
 	// try to load cluster/agent name from tls certification
 	var clusterNameInCert, agentNameInCert string
@@ -577,6 +1044,219 @@ func (o *SpokeAgentOptions) getOrGenerateClusterAgentNames() (string, string) {
 	return clusterName, agentName
 }
 
+// hubUserAgent returns the User-Agent used by the agent for all hub-bound clients. It
+// includes the agent version and the cluster name, plus the configured suffix if any, so
+// the requests of an agent can be recognized in the hub audit logs.
+func (o *SpokeAgentOptions) hubUserAgent() string {
+	userAgent := fmt.Sprintf("registration-agent/%s cluster/%s", version.Get().GitVersion, o.ClusterName)
+	if len(o.UserAgentSuffix) > 0 {
+		userAgent = userAgent + " " + o.UserAgentSuffix
+	}
+	return userAgent
+}
+
+// completeHubClientConfig fills in the agent defaults of a hub-bound client config: the
+// identifying User-Agent and the custom TLS transport carrying the hub CA bundle and the
+// SPKI pins.
+func (o *SpokeAgentOptions) completeHubClientConfig(clientConfig *rest.Config) error {
+	clientConfig.UserAgent = o.hubUserAgent()
+	return o.applyHubTLSConfig(clientConfig)
+}
+
+// applyHubTLSConfig moves the TLS settings of the given hub client config into a custom
+// transport when --hub-ca-file or --hub-spki-pin is set. With --hub-ca-file the chain
+// verification is taken over by a verifier that re-reads the CA file on every handshake,
+// so a changed file is picked up by new connections without restarting the agent. With
+// --hub-spki-pin the SPKI SHA-256 fingerprint of the certificate presented by the hub
+// apiserver must additionally match one of the configured pins, so a certificate issued
+// by a rogue but trusted CA cannot impersonate the hub.
+func (o *SpokeAgentOptions) applyHubTLSConfig(clientConfig *rest.Config) error {
+	if o.HubCAFile == "" && len(o.HubSPKIPins) == 0 {
+		return nil
+	}
+
+	tlsConfig, err := rest.TLSConfigFor(clientConfig)
+	if err != nil {
+		return err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+
+	var verifiers []func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error
+	if o.HubCAFile != "" {
+		serverName := tlsConfig.ServerName
+		if serverName == "" {
+			hostURL, err := url.Parse(clientConfig.Host)
+			if err != nil {
+				return err
+			}
+			serverName = hostURL.Hostname()
+		}
+		verifier, err := o.newHubCAVerifier(clientConfig, serverName)
+		if err != nil {
+			return err
+		}
+		// the builtin verification cannot reload its root CAs, the verifier
+		// takes over the complete chain and host name validation
+		tlsConfig.InsecureSkipVerify = true
+		verifiers = append(verifiers, verifier.verifyPeerCertificate)
+	}
+	if len(o.HubSPKIPins) > 0 {
+		verifiers = append(verifiers, spkiPinVerifier(o.HubSPKIPins))
+	}
+	tlsConfig.VerifyPeerCertificate = chainPeerCertificateVerifiers(verifiers)
+
+	// the TLS settings moved into the custom transport, a rest config does not accept a
+	// custom transport together with TLS settings
+	clientConfig.Transport = utilnet.SetTransportDefaults(&http.Transport{
+		TLSClientConfig: tlsConfig,
+	})
+	clientConfig.TLSClientConfig = rest.TLSClientConfig{}
+	return nil
+}
+
+// chainPeerCertificateVerifiers combines peer certificate verification functions into a
+// single one that requires all of them to accept the presented certificates.
+func chainPeerCertificateVerifiers(verifiers []func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		for _, verify := range verifiers {
+			if err := verify(rawCerts, verifiedChains); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// spkiPinVerifier returns a TLS peer certificate verification function that accepts a
+// certificate chain only if the SPKI SHA-256 fingerprint of the leaf certificate matches
+// one of the given hex encoded pins.
+func spkiPinVerifier(pins []string) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	pinSet := sets.NewString()
+	for _, pin := range pins {
+		pinSet.Insert(strings.ToLower(pin))
+	}
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("the hub apiserver presented no certificate")
+		}
+		leaf, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		fingerprint := fmt.Sprintf("%x", sha256.Sum256(leaf.RawSubjectPublicKeyInfo))
+		if pinSet.Has(fingerprint) {
+			return nil
+		}
+		return fmt.Errorf("the SPKI fingerprint %q of the hub apiserver certificate matches none of the configured pins", fingerprint)
+	}
+}
+
+// hubCAVerifier verifies the certificate chain presented by the hub apiserver against
+// the CA of the bootstrap/hub kubeconfig merged with the bundle from --hub-ca-file, so
+// the agent trusts both the kubeconfig CA and the additional CA. The additional CA file
+// is re-read on every TLS handshake, so a changed file, e.g. during the hub CA rotation,
+// is picked up by new connections without restarting the agent.
+type hubCAVerifier struct {
+	caFile     string
+	baseCAData []byte
+	serverName string
+
+	lock sync.Mutex
+	// the CA data the cached root pool was built from, so the file content only has to
+	// be parsed again when it actually changed
+	lastCAData []byte
+	roots      *x509.CertPool
+}
+
+// newHubCAVerifier returns a hubCAVerifier for the CA of the given hub client config and
+// the CA file configured with --hub-ca-file. It fails when the CA file cannot be loaded,
+// so a misconfigured flag surfaces when the hub clients are built instead of on the first
+// handshake.
+func (o *SpokeAgentOptions) newHubCAVerifier(clientConfig *rest.Config, serverName string) (*hubCAVerifier, error) {
+	baseCAData := clientConfig.CAData
+	if len(baseCAData) == 0 && len(clientConfig.CAFile) > 0 {
+		var err error
+		baseCAData, err = ioutil.ReadFile(clientConfig.CAFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	verifier := &hubCAVerifier{
+		caFile:     o.HubCAFile,
+		baseCAData: baseCAData,
+		serverName: serverName,
+	}
+	if _, err := verifier.rootCAs(); err != nil {
+		return nil, err
+	}
+	return verifier, nil
+}
+
+// rootCAs returns the root CA pool built from the kubeconfig CA data and a fresh read of
+// the additional CA file. When the file turns unreadable after a successful load, the
+// last successfully loaded pool stays in use, so a transiently missing file does not
+// break the running connections of the agent.
+func (v *hubCAVerifier) rootCAs() (*x509.CertPool, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	additionalCAData, err := ioutil.ReadFile(path.Clean(v.caFile))
+	switch {
+	case err != nil && v.roots == nil:
+		return nil, fmt.Errorf("unable to load additional hub CA file %q: %w", v.caFile, err)
+	case err != nil:
+		return v.roots, nil
+	case v.roots != nil && bytes.Equal(additionalCAData, v.lastCAData):
+		return v.roots, nil
+	}
+
+	roots := x509.NewCertPool()
+	baseLoaded := roots.AppendCertsFromPEM(v.baseCAData)
+	if !roots.AppendCertsFromPEM(additionalCAData) && !baseLoaded {
+		return nil, fmt.Errorf("no CA certificate found in additional hub CA file %q", v.caFile)
+	}
+	v.lastCAData = additionalCAData
+	v.roots = roots
+	return roots, nil
+}
+
+// verifyPeerCertificate validates the presented certificate chain against the merged
+// root CA pool and the expected host name of the hub apiserver. It replaces the builtin
+// chain verification, which cannot reload its root CAs.
+func (v *hubCAVerifier) verifyPeerCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	roots, err := v.rootCAs()
+	if err != nil {
+		return err
+	}
+
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("the hub apiserver presented no certificate")
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, rawCert := range rawCerts {
+		cert, err := x509.ParseCertificate(rawCert)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       v.serverName,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return err
+}
+
 // getSpokeClusterCABundle returns the spoke cluster Kubernetes client CA data when SpokeExternalServerURLs is specified
 func (o *SpokeAgentOptions) getSpokeClusterCABundle(kubeConfig *rest.Config) ([]byte, error) {
 	if len(o.SpokeExternalServerURLs) == 0 {
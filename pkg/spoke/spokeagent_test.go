@@ -3,9 +3,18 @@ package spoke
 import (
 	"bytes"
 	"context"
+	cryptorand "crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,10 +22,14 @@ import (
 	"open-cluster-management.io/registration/pkg/clientcert"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+	certutil "k8s.io/client-go/util/cert"
 )
 
 func TestComplete(t *testing.T) {
@@ -156,6 +169,11 @@ func TestValidate(t *testing.T) {
 			options:     &SpokeAgentOptions{BootstrapKubeconfig: "/spoke/bootstrap/kubeconfig"},
 			expectedErr: "cluster name is empty",
 		},
+		{
+			name:        "cluster name is no valid label value",
+			options:     &SpokeAgentOptions{BootstrapKubeconfig: "/spoke/bootstrap/kubeconfig", ClusterName: "invalid value"},
+			expectedErr: "cluster name \"invalid value\" cannot be used as a label value: a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')",
+		},
 		{
 			name:        "no agent name",
 			options:     &SpokeAgentOptions{BootstrapKubeconfig: "/spoke/bootstrap/kubeconfig", ClusterName: "testcluster"},
@@ -181,6 +199,65 @@ func TestValidate(t *testing.T) {
 			},
 			expectedErr: "cluster healthcheck period must greater than zero",
 		},
+		{
+			name: "invalid bootstrap poll interval",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:      "/spoke/bootstrap/kubeconfig",
+				ClusterName:              "testcluster",
+				AgentName:                "testagent",
+				ClusterHealthCheckPeriod: 1 * time.Minute,
+				BootstrapPollInterval:    0,
+			},
+			expectedErr: "bootstrap poll interval must greater than zero",
+		},
+		{
+			name: "negative bootstrap wait timeout",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:      "/spoke/bootstrap/kubeconfig",
+				ClusterName:              "testcluster",
+				AgentName:                "testagent",
+				ClusterHealthCheckPeriod: 1 * time.Minute,
+				BootstrapPollInterval:    1 * time.Second,
+				BootstrapWaitTimeout:     -1 * time.Second,
+			},
+			expectedErr: "bootstrap wait timeout must not be negative",
+		},
+		{
+			name: "invalid hub kubeconfig secret label key",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:       "/spoke/bootstrap/kubeconfig",
+				ClusterName:               "testcluster",
+				AgentName:                 "testagent",
+				ClusterHealthCheckPeriod:  1 * time.Minute,
+				BootstrapPollInterval:     1 * time.Second,
+				HubKubeconfigSecretLabels: map[string]string{"-invalid": "value"},
+			},
+			expectedErr: "hub-kubeconfig-secret-labels key \"-invalid\" is invalid: name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')",
+		},
+		{
+			name: "invalid hub kubeconfig secret label value",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:       "/spoke/bootstrap/kubeconfig",
+				ClusterName:               "testcluster",
+				AgentName:                 "testagent",
+				ClusterHealthCheckPeriod:  1 * time.Minute,
+				BootstrapPollInterval:     1 * time.Second,
+				HubKubeconfigSecretLabels: map[string]string{"policy.example.com/credential": "invalid value"},
+			},
+			expectedErr: "hub-kubeconfig-secret-labels value \"invalid value\" is invalid: a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')",
+		},
+		{
+			name: "invalid hub kubeconfig secret annotation key",
+			options: &SpokeAgentOptions{
+				BootstrapKubeconfig:            "/spoke/bootstrap/kubeconfig",
+				ClusterName:                    "testcluster",
+				AgentName:                      "testagent",
+				ClusterHealthCheckPeriod:       1 * time.Minute,
+				BootstrapPollInterval:          1 * time.Second,
+				HubKubeconfigSecretAnnotations: map[string]string{"bad//key": "value"},
+			},
+			expectedErr: "hub-kubeconfig-secret-annotations key \"bad//key\" is invalid: a qualified name must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]') with an optional DNS subdomain prefix and '/' (e.g. 'example.com/MyName')",
+		},
 		{
 			name:        "default completed options",
 			options:     defaultCompletedOptions,
@@ -278,6 +355,262 @@ func TestHasValidHubClientConfig(t *testing.T) {
 	}
 }
 
+func TestCheckHubVersionCompatibility(t *testing.T) {
+	cases := []struct {
+		name                string
+		hubGroupVersions    []string
+		skipHubVersionCheck bool
+		expectedErr         string
+	}{
+		{
+			name:             "the hub serves a supported version",
+			hubGroupVersions: []string{"cluster.open-cluster-management.io/v1"},
+		},
+		{
+			name:             "the hub serves an unsupported version",
+			hubGroupVersions: []string{"cluster.open-cluster-management.io/v1alpha1"},
+			expectedErr:      "the hub serves the cluster.open-cluster-management.io API in versions \"v1alpha1\" but this agent supports \"v1\", use --skip-hub-version-check to bypass the check",
+		},
+		{
+			name:             "the hub does not serve the registration API",
+			hubGroupVersions: []string{"apps/v1"},
+			expectedErr:      "the hub does not serve the cluster.open-cluster-management.io API, it is not a registration hub or too old, use --skip-hub-version-check to bypass the check",
+		},
+		{
+			name:                "the check is bypassed",
+			hubGroupVersions:    []string{"cluster.open-cluster-management.io/v1alpha1"},
+			skipHubVersionCheck: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			for _, groupVersion := range c.hubGroupVersions {
+				kubeClient.Resources = append(kubeClient.Resources, &metav1.APIResourceList{GroupVersion: groupVersion})
+			}
+
+			options := &SpokeAgentOptions{SkipHubVersionCheck: c.skipHubVersionCheck}
+			err := options.checkHubVersionCompatibility(kubeClient.Discovery())
+			testinghelpers.AssertError(t, err, c.expectedErr)
+		})
+	}
+}
+
+func TestWaitForValidHubClientConfig(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "testwaitforvalidhubclientconfig")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// the hub client config never becomes ready in the empty dir
+	options := &SpokeAgentOptions{
+		HubKubeconfigDir:      tempDir,
+		BootstrapPollInterval: 10 * time.Millisecond,
+		BootstrapWaitTimeout:  100 * time.Millisecond,
+	}
+	unchanged := func() (bool, error) { return false, nil }
+
+	t.Run("the wait times out with a clean error", func(t *testing.T) {
+		err := options.waitForValidHubClientConfig(context.TODO(), unchanged)
+		expectedErr := fmt.Sprintf("the hub client config did not become ready within %s, the bootstrap csr of cluster %q may not be approved",
+			options.BootstrapWaitTimeout, options.ClusterName)
+		testinghelpers.AssertError(t, err, expectedErr)
+	})
+
+	t.Run("the wait stops on shutdown", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := options.waitForValidHubClientConfig(ctx, unchanged)
+		testinghelpers.AssertError(t, err, "timed out waiting for the condition")
+	})
+
+	t.Run("a changed bootstrap kubeconfig aborts the wait", func(t *testing.T) {
+		changed := func() (bool, error) { return true, nil }
+		err := options.waitForValidHubClientConfig(context.TODO(), changed)
+		expectedErr := fmt.Sprintf("the bootstrap kubeconfig %q changed, restart the bootstrap process with the new config", options.BootstrapKubeconfig)
+		testinghelpers.AssertError(t, err, expectedErr)
+	})
+}
+
+func TestRunPreflightExec(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "testrunpreflightexec")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	passingCheck := path.Join(tempDir, "preflight-pass.sh")
+	if err := ioutil.WriteFile(passingCheck, []byte("#!/bin/sh\nexit 0\n"), 0700); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	failingCheck := path.Join(tempDir, "preflight-fail.sh")
+	if err := ioutil.WriteFile(failingCheck, []byte("#!/bin/sh\necho \"a prerequisite is missing\" >&2\nexit 1\n"), 0700); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		name          string
+		preflightExec string
+		expectedErr   string
+	}{
+		{
+			name: "no preflight command configured",
+		},
+		{
+			name:          "the preflight command passes",
+			preflightExec: passingCheck,
+		},
+		{
+			name:          "the preflight command fails",
+			preflightExec: failingCheck,
+			expectedErr:   fmt.Sprintf("the preflight command %q failed: exit status 1, stderr: a prerequisite is missing", failingCheck),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			options := &SpokeAgentOptions{
+				PreflightExec:        c.preflightExec,
+				PreflightExecTimeout: 30 * time.Second,
+			}
+			testinghelpers.AssertError(t, options.runPreflightExec(context.TODO()), c.expectedErr)
+		})
+	}
+}
+
+func TestVerifyHubKubeconfig(t *testing.T) {
+	cases := []struct {
+		name        string
+		verify      bool
+		callErr     error
+		expectedErr string
+	}{
+		{
+			name:    "the verification is disabled",
+			callErr: fmt.Errorf("x509: certificate signed by unknown authority"),
+		},
+		{
+			name:   "the issued credential works",
+			verify: true,
+		},
+		{
+			name:        "the issued credential fails the verification",
+			verify:      true,
+			callErr:     fmt.Errorf("x509: certificate signed by unknown authority"),
+			expectedErr: "the hub kubeconfig could not be verified within 50ms: x509: certificate signed by unknown authority",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"selfsubjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					if c.callErr != nil {
+						return true, nil, c.callErr
+					}
+					return true, &authorizationv1.SelfSubjectAccessReview{}, nil
+				},
+			)
+
+			options := &SpokeAgentOptions{
+				ClusterName:                testinghelpers.TestManagedClusterName,
+				BootstrapPollInterval:      10 * time.Millisecond,
+				VerifyHubKubeconfig:        c.verify,
+				VerifyHubKubeconfigTimeout: 50 * time.Millisecond,
+			}
+			testinghelpers.AssertError(t, options.verifyHubKubeconfig(context.TODO(), kubeClient.AuthorizationV1()), c.expectedErr)
+		})
+	}
+}
+
+func TestWriteBootstrapDiagnostics(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "testwritebootstrapdiagnostics")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	csr := testinghelpers.NewCSR(testinghelpers.CSRHolder{
+		Name:         "testcluster-bootstrap-csr",
+		Labels:       map[string]string{clientcert.ClusterNameLabel: "testcluster"},
+		SignerName:   "kubernetes.io/kube-apiserver-client",
+		CN:           "system:open-cluster-management:testcluster:testagent",
+		Orgs:         []string{"system:open-cluster-management:testcluster"},
+		Username:     "system:bootstrap:testcluster",
+		ReqBlockType: "CERTIFICATE REQUEST",
+	})
+	kubeClient := kubefake.NewSimpleClientset(csr)
+
+	bootstrapClientConfig := &rest.Config{
+		Host:        "https://hub.example.com:6443",
+		BearerToken: "very-secret-token",
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData:   []byte("ca-bundle-data"),
+			KeyData:  []byte("very-secret-key"),
+			CertData: []byte("client-cert-data"),
+		},
+	}
+
+	options := &SpokeAgentOptions{
+		ClusterName:    "testcluster",
+		AgentName:      "testagent",
+		DiagnosticsDir: tempDir,
+	}
+	options.writeBootstrapDiagnostics(context.TODO(), bootstrapClientConfig, kubeClient,
+		fmt.Errorf("the hub client config did not become ready within 10m0s"))
+
+	summaryData, err := ioutil.ReadFile(path.Join(tempDir, bootstrapDiagnosticsSummaryFile))
+	if err != nil {
+		t.Fatalf("expected the diagnostics summary file, but got error: %v", err)
+	}
+	for _, expected := range []string{"https://hub.example.com:6443", "testcluster", "testagent", "did not become ready"} {
+		if !strings.Contains(string(summaryData), expected) {
+			t.Errorf("expected %q in the diagnostics summary, but got %s", expected, summaryData)
+		}
+	}
+
+	csrData, err := ioutil.ReadFile(path.Join(tempDir, bootstrapDiagnosticsCSRFile))
+	if err != nil {
+		t.Fatalf("expected the csr diagnostics file, but got error: %v", err)
+	}
+	if !strings.Contains(string(csrData), "testcluster-bootstrap-csr") {
+		t.Errorf("expected the bootstrap csr in the csr diagnostics, but got %s", csrData)
+	}
+
+	// no secret material must end up in the bundle
+	for _, secret := range []string{"very-secret-token", "very-secret-key", "client-cert-data", "ca-bundle-data"} {
+		for _, data := range [][]byte{summaryData, csrData} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("expected no secret material in the diagnostics bundle, but found %q", secret)
+			}
+		}
+	}
+
+	t.Run("no diagnostics dir configured", func(t *testing.T) {
+		emptyDir, err := ioutil.TempDir("", "testwritebootstrapdiagnostics")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		defer os.RemoveAll(emptyDir)
+
+		options := &SpokeAgentOptions{ClusterName: "testcluster", AgentName: "testagent"}
+		options.writeBootstrapDiagnostics(context.TODO(), bootstrapClientConfig, kubeClient, fmt.Errorf("bootstrap failed"))
+
+		entries, err := ioutil.ReadDir(emptyDir)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("expected no diagnostics files, but got %d", len(entries))
+		}
+	})
+}
+
 func TestGetOrGenerateClusterAgentNames(t *testing.T) {
 	tempDir, err := ioutil.TempDir("", "testgetorgenerateclusteragentnames")
 	if err != nil {
@@ -326,6 +659,186 @@ func TestGetOrGenerateClusterAgentNames(t *testing.T) {
 	}
 }
 
+func TestCompleteHubClientConfig(t *testing.T) {
+	cases := []struct {
+		name              string
+		userAgentSuffix   string
+		expectedUserAgent string
+	}{
+		{
+			name:              "default user agent",
+			expectedUserAgent: "registration-agent/ cluster/testmanagedcluster",
+		},
+		{
+			name:              "user agent with suffix",
+			userAgentSuffix:   "ci",
+			expectedUserAgent: "registration-agent/ cluster/testmanagedcluster ci",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			options := &SpokeAgentOptions{
+				ClusterName:     testinghelpers.TestManagedClusterName,
+				UserAgentSuffix: c.userAgentSuffix,
+			}
+
+			clientConfig := &rest.Config{}
+			if err := options.completeHubClientConfig(clientConfig); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if clientConfig.UserAgent != c.expectedUserAgent {
+				t.Errorf("expected user agent %q but got: %q", c.expectedUserAgent, clientConfig.UserAgent)
+			}
+		})
+	}
+}
+
+func newTestCA(t *testing.T, commonName string) (*x509.Certificate, *rsa.PrivateKey, []byte) {
+	caKey, err := rsa.GenerateKey(cryptorand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	caCert, err := certutil.NewSelfSignedCACert(certutil.Config{CommonName: commonName}, caKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: certutil.CertificateBlockType, Bytes: caCert.Raw})
+	return caCert, caKey, caPEM
+}
+
+func newTestServerCert(t *testing.T, caCert *x509.Certificate, caKey *rsa.PrivateKey, dnsName string) []byte {
+	key, err := rsa.GenerateKey(cryptorand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	certDERBytes, err := x509.CreateCertificate(
+		cryptorand.Reader,
+		&x509.Certificate{
+			Subject:      pkix.Name{CommonName: dnsName},
+			SerialNumber: big.NewInt(1),
+			DNSNames:     []string{dnsName},
+			NotBefore:    caCert.NotBefore,
+			NotAfter:     time.Now().Add(time.Hour).UTC(),
+			KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		},
+		caCert,
+		key.Public(),
+		caKey,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return certDERBytes
+}
+
+func TestNewHubCAVerifier(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "testnewhubcaverifier")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	nonexistentCAFile := path.Join(tempDir, "nonexistent-ca.crt")
+	options := &SpokeAgentOptions{HubCAFile: nonexistentCAFile}
+	_, err = options.newHubCAVerifier(&rest.Config{}, "hub.example.com")
+	expectedErr := fmt.Sprintf("unable to load additional hub CA file %q: open %s: no such file or directory", nonexistentCAFile, nonexistentCAFile)
+	testinghelpers.AssertError(t, err, expectedErr)
+}
+
+func TestHubCAVerifier(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "testhubcaverifier")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	kubeconfigCACert, kubeconfigCAKey, kubeconfigCAPEM := newTestCA(t, "kubeconfig-ca")
+	rotatedCACert, rotatedCAKey, rotatedCAPEM := newTestCA(t, "rotated-ca")
+	_, _, initialCAPEM := newTestCA(t, "initial-ca")
+
+	kubeconfigServerCert := newTestServerCert(t, kubeconfigCACert, kubeconfigCAKey, "hub.example.com")
+	rotatedServerCert := newTestServerCert(t, rotatedCACert, rotatedCAKey, "hub.example.com")
+
+	caFile := path.Join(tempDir, "hub-ca.crt")
+	testinghelpers.WriteFile(caFile, initialCAPEM)
+
+	options := &SpokeAgentOptions{HubCAFile: caFile}
+	clientConfig := &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAData: kubeconfigCAPEM}}
+	verifier, err := options.newHubCAVerifier(clientConfig, "hub.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the CA of the kubeconfig stays trusted next to the additional CA
+	if err := verifier.verifyPeerCertificate([][]byte{kubeconfigServerCert}, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// a certificate of the rotated CA is not trusted before the CA file changed
+	if err := verifier.verifyPeerCertificate([][]byte{rotatedServerCert}, nil); err == nil {
+		t.Errorf("expected a verification error for a certificate of an untrusted CA")
+	}
+
+	// the changed CA file is picked up without rebuilding the verifier
+	testinghelpers.WriteFile(caFile, rotatedCAPEM)
+	if err := verifier.verifyPeerCertificate([][]byte{rotatedServerCert}, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// the last successfully loaded bundle stays in use when the file disappears
+	if err := os.Remove(caFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := verifier.verifyPeerCertificate([][]byte{rotatedServerCert}, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// the host name of the hub is verified too
+	mismatchedServerCert := newTestServerCert(t, rotatedCACert, rotatedCAKey, "other.example.com")
+	if err := verifier.verifyPeerCertificate([][]byte{mismatchedServerCert}, nil); err == nil {
+		t.Errorf("expected a verification error for a certificate with a mismatching host name")
+	}
+}
+
+func TestSPKIPinVerifier(t *testing.T) {
+	cert := testinghelpers.NewTestCert("hub-apiserver", 60*time.Second)
+	block, _ := pem.Decode(cert.Cert)
+	if block == nil {
+		t.Fatal("unable to decode the test certificate")
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pin := fmt.Sprintf("%x", sha256.Sum256(leaf.RawSubjectPublicKeyInfo))
+
+	cases := []struct {
+		name        string
+		pins        []string
+		expectedErr string
+	}{
+		{
+			name: "matching pin",
+			pins: []string{pin},
+		},
+		{
+			name:        "mismatching pin",
+			pins:        []string{strings.Repeat("0", 64)},
+			expectedErr: fmt.Sprintf("the SPKI fingerprint %q of the hub apiserver certificate matches none of the configured pins", pin),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			verify := spkiPinVerifier(c.pins)
+			err := verify([][]byte{block.Bytes}, nil)
+			testinghelpers.AssertError(t, err, c.expectedErr)
+		})
+	}
+}
+
 func TestGetSpokeClusterCABundle(t *testing.T) {
 	tempDir, err := ioutil.TempDir("", "testgetspokeclustercabundle")
 	if err != nil {
@@ -388,3 +901,37 @@ func TestGetSpokeClusterCABundle(t *testing.T) {
 		})
 	}
 }
+
+func TestBootstrapKubeconfigChangedCheck(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "testbootstrapkubeconfigchanged")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	bootstrapKubeconfigPath := path.Join(tempDir, "kubeconfig")
+
+	if _, err := bootstrapKubeconfigChangedCheck(bootstrapKubeconfigPath); err == nil {
+		t.Errorf("expected an error for a missing bootstrap kubeconfig")
+	}
+
+	testinghelpers.WriteFile(bootstrapKubeconfigPath, testinghelpers.NewKubeconfig(nil, nil))
+
+	changed, err := bootstrapKubeconfigChangedCheck(bootstrapKubeconfigPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the unchanged bootstrap kubeconfig does not restart the bootstrap process
+	if ok, err := changed(); err != nil || ok {
+		t.Errorf("expected the bootstrap kubeconfig to be unchanged, but got changed=%v, err=%v", ok, err)
+	}
+
+	// swap the bootstrap kubeconfig mid-bootstrap
+	testCert := testinghelpers.NewTestCert("test", 60*time.Second)
+	testinghelpers.WriteFile(bootstrapKubeconfigPath, testinghelpers.NewKubeconfig(testCert.Key, testCert.Cert))
+
+	if ok, err := changed(); err != nil || !ok {
+		t.Errorf("expected the bootstrap kubeconfig to be changed, but got changed=%v, err=%v", ok, err)
+	}
+}
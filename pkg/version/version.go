@@ -3,7 +3,8 @@ package version
 import (
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/component-base/metrics"
-	"k8s.io/component-base/metrics/legacyregistry"
+
+	registrationmetrics "open-cluster-management.io/registration/pkg/metrics"
 )
 
 var (
@@ -34,14 +35,15 @@ func Get() version.Info {
 }
 
 func init() {
-	buildInfo := metrics.NewGaugeVec(
-		&metrics.GaugeOpts{
-			Name: "open_cluster_management_registration_build_info",
-			Help: "A metric with a constant '1' value labeled by major, minor, git commit & git version from which Open Cluster Management Registration was built.",
-		},
-		[]string{"major", "minor", "gitCommit", "gitVersion"},
-	)
-	buildInfo.WithLabelValues(majorFromGit, minorFromGit, commitFromGit, versionFromGit).Set(1)
-
-	legacyregistry.MustRegister(buildInfo)
+	registrationmetrics.Register(func(prefix string) metrics.Registerable {
+		buildInfo := metrics.NewGaugeVec(
+			&metrics.GaugeOpts{
+				Name: registrationmetrics.Prefixed(prefix, "open_cluster_management_registration_build_info"),
+				Help: "A metric with a constant '1' value labeled by major, minor, git commit & git version from which Open Cluster Management Registration was built.",
+			},
+			[]string{"major", "minor", "gitCommit", "gitVersion"},
+		)
+		buildInfo.WithLabelValues(majorFromGit, minorFromGit, commitFromGit, versionFromGit).Set(1)
+		return buildInfo
+	})
 }
@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"reflect"
+	"sort"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+// managedClusterChanges describes the old-vs-new differences of an update request the
+// update-path rules care about. Computing them once with managedClusterDiff keeps the
+// comparisons out of the individual rules.
+type managedClusterChanges struct {
+	// acceptChanged reports whether the HubAcceptsClient field changed
+	acceptChanged bool
+
+	// oldClusterSet and newClusterSet are the clusterset label values of the old and
+	// the new object, empty when the label is unset. clusterSetChanged reports whether
+	// the value changed.
+	oldClusterSet     string
+	newClusterSet     string
+	clusterSetChanged bool
+
+	// addedLabels, removedLabels and changedLabels hold the label keys only present on
+	// the new object, only present on the old object, and present on both with
+	// different values, each sorted.
+	addedLabels   []string
+	removedLabels []string
+	changedLabels []string
+
+	// taintsChanged reports whether the taints differ between the objects
+	taintsChanged bool
+}
+
+// managedClusterDiff computes the changes between the old and the new object of an
+// update request.
+func managedClusterDiff(oldManagedCluster, newManagedCluster *clusterv1.ManagedCluster) managedClusterChanges {
+	changes := managedClusterChanges{
+		acceptChanged: oldManagedCluster.Spec.HubAcceptsClient != newManagedCluster.Spec.HubAcceptsClient,
+		oldClusterSet: oldManagedCluster.Labels[clusterSetLabel],
+		newClusterSet: newManagedCluster.Labels[clusterSetLabel],
+		taintsChanged: !reflect.DeepEqual(oldManagedCluster.Spec.Taints, newManagedCluster.Spec.Taints),
+	}
+	changes.clusterSetChanged = changes.oldClusterSet != changes.newClusterSet
+
+	for key, value := range newManagedCluster.Labels {
+		oldValue, ok := oldManagedCluster.Labels[key]
+		switch {
+		case !ok:
+			changes.addedLabels = append(changes.addedLabels, key)
+		case oldValue != value:
+			changes.changedLabels = append(changes.changedLabels, key)
+		}
+	}
+	for key := range oldManagedCluster.Labels {
+		if _, ok := newManagedCluster.Labels[key]; !ok {
+			changes.removedLabels = append(changes.removedLabels, key)
+		}
+	}
+	sort.Strings(changes.addedLabels)
+	sort.Strings(changes.removedLabels)
+	sort.Strings(changes.changedLabels)
+
+	return changes
+}
@@ -0,0 +1,95 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestManagedClusterDiff(t *testing.T) {
+	cases := []struct {
+		name            string
+		oldCluster      *clusterv1.ManagedCluster
+		newCluster      *clusterv1.ManagedCluster
+		expectedChanges managedClusterChanges
+	}{
+		{
+			name:            "no changes",
+			oldCluster:      newDiffCluster(false, nil, nil),
+			newCluster:      newDiffCluster(false, nil, nil),
+			expectedChanges: managedClusterChanges{},
+		},
+		{
+			name:       "the accept field is toggled",
+			oldCluster: newDiffCluster(false, nil, nil),
+			newCluster: newDiffCluster(true, nil, nil),
+			expectedChanges: managedClusterChanges{
+				acceptChanged: true,
+			},
+		},
+		{
+			name:       "the clusterset label changes",
+			oldCluster: newDiffCluster(false, map[string]string{clusterSetLabel: "set1"}, nil),
+			newCluster: newDiffCluster(false, map[string]string{clusterSetLabel: "set2"}, nil),
+			expectedChanges: managedClusterChanges{
+				oldClusterSet:     "set1",
+				newClusterSet:     "set2",
+				clusterSetChanged: true,
+				changedLabels:     []string{clusterSetLabel},
+			},
+		},
+		{
+			name:       "labels are added, removed and changed",
+			oldCluster: newDiffCluster(false, map[string]string{"removed": "a", "changed": "a", "kept": "a"}, nil),
+			newCluster: newDiffCluster(false, map[string]string{"added": "b", "changed": "b", "kept": "a"}, nil),
+			expectedChanges: managedClusterChanges{
+				addedLabels:   []string{"added"},
+				removedLabels: []string{"removed"},
+				changedLabels: []string{"changed"},
+			},
+		},
+		{
+			name:       "a taint is added",
+			oldCluster: newDiffCluster(false, nil, nil),
+			newCluster: newDiffCluster(false, nil, []clusterv1.Taint{{Key: "a", Effect: clusterv1.TaintEffectNoSelect}}),
+			expectedChanges: managedClusterChanges{
+				taintsChanged: true,
+			},
+		},
+		{
+			name: "a taint value changes",
+			oldCluster: newDiffCluster(false, nil,
+				[]clusterv1.Taint{{Key: "a", Value: "b", Effect: clusterv1.TaintEffectNoSelect}}),
+			newCluster: newDiffCluster(false, nil,
+				[]clusterv1.Taint{{Key: "a", Value: "c", Effect: clusterv1.TaintEffectNoSelect}}),
+			expectedChanges: managedClusterChanges{
+				taintsChanged: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actualChanges := managedClusterDiff(c.oldCluster, c.newCluster)
+			if !reflect.DeepEqual(actualChanges, c.expectedChanges) {
+				t.Errorf("expected %#v but got: %#v", c.expectedChanges, actualChanges)
+			}
+		})
+	}
+}
+
+func newDiffCluster(hubAcceptsClient bool, labels map[string]string, taints []clusterv1.Taint) *clusterv1.ManagedCluster {
+	return &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "cluster1",
+			Labels: labels,
+		},
+		Spec: clusterv1.ManagedClusterSpec{
+			HubAcceptsClient: hubAcceptsClient,
+			Taints:           taints,
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package cluster
+
+import (
+	"k8s.io/component-base/metrics"
+
+	registrationmetrics "open-cluster-management.io/registration/pkg/metrics"
+)
+
+// webhookRuleEnabled exports which configurable rules of the managedcluster validating
+// webhook are enabled, one series per rule with value 1 when enabled and 0 otherwise.
+// Scraped across hubs, it lets monitoring detect configuration drift between
+// environments.
+var webhookRuleEnabled *metrics.GaugeVec
+
+// webhookConfigParameter exports the numeric parameters of the managedcluster
+// validating webhook, e.g. the maximum number of taints, one series per parameter.
+var webhookConfigParameter *metrics.GaugeVec
+
+func init() {
+	registrationmetrics.Register(func(prefix string) metrics.Registerable {
+		webhookRuleEnabled = metrics.NewGaugeVec(
+			&metrics.GaugeOpts{
+				Name: registrationmetrics.Prefixed(prefix, "registration_webhook_rule_enabled"),
+				Help: "Whether a configurable rule of the managedcluster validating webhook is enabled.",
+			},
+			[]string{"rule"},
+		)
+		return webhookRuleEnabled
+	})
+	registrationmetrics.Register(func(prefix string) metrics.Registerable {
+		webhookConfigParameter = metrics.NewGaugeVec(
+			&metrics.GaugeOpts{
+				Name: registrationmetrics.Prefixed(prefix, "registration_webhook_config_parameter"),
+				Help: "The value of a numeric configuration parameter of the managedcluster validating webhook.",
+			},
+			[]string{"parameter"},
+		)
+		return webhookConfigParameter
+	})
+}
+
+// exportConfigMetrics sets the configuration gauges from the resolved webhook
+// configuration. The configuration does not change while the webhook runs, so the
+// gauges are set once at startup. It does nothing before the metrics registration
+// completed.
+func exportConfigMetrics(config ValidatingWebhookConfig) {
+	if webhookRuleEnabled == nil || webhookConfigParameter == nil {
+		return
+	}
+
+	rules := map[string]bool{
+		"exclude_reserved_labels_from_count":  config.ExcludeReservedLabelsFromCount,
+		"resolve_client_config_hosts":         config.ResolveClientConfigHosts,
+		"strict_client_config_resolution":     config.StrictClientConfigResolution,
+		"require_owner_annotation":            config.RequireOwnerAnnotation,
+		"deny_expired_client_config_ca":       config.DenyExpiredClientConfigCA,
+		"allow_terminating_cluster_namespace": config.AllowTerminatingClusterNamespace,
+		"require_external_ca_bundle":          config.RequireExternalCABundle,
+		"require_client_configs_for_accept":   config.RequireClientConfigsForAccept,
+		"impersonate_authorization_identity":  config.ImpersonateAuthorizationIdentity,
+	}
+	for rule, enabled := range rules {
+		value := 0.0
+		if enabled {
+			value = 1.0
+		}
+		webhookRuleEnabled.WithLabelValues(rule).Set(value)
+	}
+
+	webhookConfigParameter.WithLabelValues("max_taints").Set(float64(config.MaxTaints))
+	webhookConfigParameter.WithLabelValues("max_labels").Set(float64(config.MaxLabels))
+}
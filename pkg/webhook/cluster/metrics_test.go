@@ -0,0 +1,74 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	registrationmetrics "open-cluster-management.io/registration/pkg/metrics"
+)
+
+func TestExportConfigMetrics(t *testing.T) {
+	// complete the deferred metrics registration with the default prefix
+	registrationmetrics.SetPrefix("")
+
+	admissionHook := NewManagedClusterValidatingAdmissionHook(nil)
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	admissionHook.AddFlags(fs)
+	if err := fs.Parse([]string{"--require-owner-annotation", "--max-taints=8"}); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	exportConfigMetrics(admissionHook.EffectiveConfig())
+
+	expectedRules := map[string]float64{
+		"require_owner_annotation":    1,
+		"resolve_client_config_hosts": 0,
+	}
+	expectedParameters := map[string]float64{
+		"max_taints": 8,
+		"max_labels": defaultMaxLabels,
+	}
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	actualRules := map[string]float64{}
+	actualParameters := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				switch {
+				case family.GetName() == "registration_webhook_rule_enabled" && label.GetName() == "rule":
+					actualRules[label.GetValue()] = metric.GetGauge().GetValue()
+				case family.GetName() == "registration_webhook_config_parameter" && label.GetName() == "parameter":
+					actualParameters[label.GetValue()] = metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+
+	for rule, expected := range expectedRules {
+		actual, ok := actualRules[rule]
+		if !ok {
+			t.Errorf("expected a gauge for rule %q to be exported", rule)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("expected gauge value %v for rule %q but got: %v", expected, rule, actual)
+		}
+	}
+	for parameter, expected := range expectedParameters {
+		actual, ok := actualParameters[parameter]
+		if !ok {
+			t.Errorf("expected a gauge for parameter %q to be exported", parameter)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("expected gauge value %v for parameter %q but got: %v", expected, parameter, actual)
+		}
+	}
+}
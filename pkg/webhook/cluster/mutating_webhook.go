@@ -1,14 +1,20 @@
 package cluster
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/registration/pkg/helpers"
+	"open-cluster-management.io/registration/pkg/webhook/tracing"
+
+	"github.com/spf13/pflag"
 
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -17,6 +23,19 @@ import (
 	"k8s.io/klog/v2"
 )
 
+const (
+	// acceptedAtAnnotation records the time a managed cluster became accepted by the hub.
+	acceptedAtAnnotation = "register.open-cluster-management.io/accepted-at"
+
+	// acceptedByAnnotation records the user that accepted a managed cluster on the hub.
+	acceptedByAnnotation = "register.open-cluster-management.io/accepted-by"
+
+	// primaryURLAnnotation names the client config URL the hub integrations should use
+	// as the primary endpoint. With the reordering enabled, the mutating webhook moves
+	// the named client config to the front of the list.
+	primaryURLAnnotation = "cluster.open-cluster-management.io/primary-url"
+)
+
 var nowFunc = time.Now
 
 type jsonPatchOperation struct {
@@ -26,7 +45,18 @@ type jsonPatchOperation struct {
 }
 
 // ManagedClusterMutatingAdmissionHook will mutate the creating/updating managedcluster request.
-type ManagedClusterMutatingAdmissionHook struct{}
+type ManagedClusterMutatingAdmissionHook struct {
+	// ReorderByPrimaryURL moves the client config named by the primary-url annotation to
+	// the front of the client configs, so integrations using the first client config as
+	// the primary endpoint follow the annotation. Off by default.
+	ReorderByPrimaryURL bool
+}
+
+// AddFlags registers flags for the managedcluster mutating webhook
+func (a *ManagedClusterMutatingAdmissionHook) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&a.ReorderByPrimaryURL, "reorder-by-primary-url", false,
+		"Reorder the client configs of a ManagedCluster so the config named by the "+primaryURLAnnotation+" annotation comes first.")
+}
 
 // MutatingResource is called by generic-admission-server on startup to register the returned REST resource through which the
 // webhook is accessed by the kube apiserver.
@@ -58,6 +88,20 @@ func (a *ManagedClusterMutatingAdmissionHook) Admit(req *admissionv1beta1.Admiss
 		return status
 	}
 
+	// the span stays a no-op unless a tracer provider was installed by the tracing setup
+	_, span := tracing.StartAdmissionSpan(context.Background(), "ManagedClusterMutatingWebhook", req)
+	status = a.admit(req)
+	tracing.EndAdmissionSpan(span, status)
+	return status
+}
+
+// admit builds the json patches mutating the managedcluster of the given admission
+// request.
+func (a *ManagedClusterMutatingAdmissionHook) admit(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	status := &admissionv1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+
 	managedCluster := &clusterv1.ManagedCluster{}
 	if err := json.Unmarshal(req.Object.Raw, managedCluster); err != nil {
 		status.Allowed = false
@@ -77,6 +121,24 @@ func (a *ManagedClusterMutatingAdmissionHook) Admit(req *admissionv1beta1.Admiss
 	}
 	jsonPatches = append(jsonPatches, taintJsonPatches...)
 
+	// move the client config named by the primary-url annotation to the front, so the
+	// integrations using the first client config follow the annotation
+	primaryJsonPatches, orderedCluster, status := a.processPrimaryURL(managedCluster)
+	if !status.Allowed {
+		return status
+	}
+	jsonPatches = append(jsonPatches, primaryJsonPatches...)
+
+	// canonicalize the client config URLs so equivalent spellings converge to one stored form
+	jsonPatches = append(jsonPatches, a.processClientConfigURLs(orderedCluster)...)
+
+	// stamp the acceptance time and the accepting user when the cluster becomes accepted
+	acceptedJsonPatches, status := a.processAcceptedAnnotations(managedCluster, req.OldObject.Raw, req.UserInfo.Username)
+	if !status.Allowed {
+		return status
+	}
+	jsonPatches = append(jsonPatches, acceptedJsonPatches...)
+
 	if len(jsonPatches) == 0 {
 		return status
 	}
@@ -125,11 +187,18 @@ func (a *ManagedClusterMutatingAdmissionHook) processTaints(managedCluster *clus
 	var jsonPatches []jsonPatchOperation
 	now := metav1.NewTime(nowFunc())
 	for index, taint := range managedCluster.Spec.Taints {
+		// the unreachable taint is managed by the hub unreachable taint controller, which
+		// sets timeAdded to the time the cluster became unavailable; keep it as is instead
+		// of resetting it
+		if taint.Key == clusterv1.ManagedClusterTaintUnreachable {
+			continue
+		}
 		originalTaint := helpers.FindTaintByKey(oldManagedCluster, taint.Key)
 		switch {
 		case originalTaint == nil:
-			// new taint
-			if !taint.TimeAdded.IsZero() {
+			// new taint; on create (no old object) the webhook owns timeAdded, so a
+			// user-supplied value is overwritten instead of rejected
+			if !taint.TimeAdded.IsZero() && oldManagedCluster != nil {
 				invalidTaints = append(invalidTaints, taint.Key)
 				continue
 			}
@@ -154,13 +223,188 @@ func (a *ManagedClusterMutatingAdmissionHook) processTaints(managedCluster *clus
 	}
 
 	status.Allowed = false
-	status.Result = &metav1.Status{
-		Status: metav1.StatusFailure, Code: http.StatusBadRequest, Reason: metav1.StatusReasonBadRequest,
-		Message: fmt.Sprintf("It is not allowed to set TimeAdded of Taint %q.", strings.Join(invalidTaints, ",")),
-	}
+	status.Result = newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.taints",
+		fmt.Sprintf("It is not allowed to set TimeAdded of Taint %q.", strings.Join(invalidTaints, ",")))
 	return nil, status
 }
 
+// processAcceptedAnnotations generates json patches that record the acceptance time and,
+// if derivable from the admission user, the accepting user in the annotations of the
+// cluster when hubAcceptsClient flips to true. The annotations are stamped once at the
+// transition for audit and are not overwritten on subsequent updates.
+func (a *ManagedClusterMutatingAdmissionHook) processAcceptedAnnotations(managedCluster *clusterv1.ManagedCluster, oldManagedClusterRaw []byte, username string) ([]jsonPatchOperation, *admissionv1beta1.AdmissionResponse) {
+	status := &admissionv1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+
+	if !managedCluster.Spec.HubAcceptsClient {
+		return nil, status
+	}
+
+	if len(oldManagedClusterRaw) > 0 {
+		oldManagedCluster := &clusterv1.ManagedCluster{}
+		if err := json.Unmarshal(oldManagedClusterRaw, oldManagedCluster); err != nil {
+			status.Allowed = false
+			status.Result = &metav1.Status{
+				Status: metav1.StatusFailure, Code: http.StatusInternalServerError, Reason: metav1.StatusReasonInternalError,
+				Message: err.Error(),
+			}
+			return nil, status
+		}
+		// the cluster is already accepted, this is not the transition
+		if oldManagedCluster.Spec.HubAcceptsClient {
+			return nil, status
+		}
+	}
+
+	annotations := map[string]string{
+		acceptedAtAnnotation: nowFunc().UTC().Format(time.RFC3339),
+	}
+	if len(username) > 0 {
+		annotations[acceptedByAnnotation] = username
+	}
+	for key := range annotations {
+		if _, ok := managedCluster.Annotations[key]; ok {
+			delete(annotations, key)
+		}
+	}
+	if len(annotations) == 0 {
+		return nil, status
+	}
+
+	if managedCluster.Annotations == nil {
+		return []jsonPatchOperation{{
+			Operation: "add",
+			Path:      "/metadata/annotations",
+			Value:     annotations,
+		}}, status
+	}
+
+	var jsonPatches []jsonPatchOperation
+	for _, key := range []string{acceptedAtAnnotation, acceptedByAnnotation} {
+		value, ok := annotations[key]
+		if !ok {
+			continue
+		}
+		jsonPatches = append(jsonPatches, jsonPatchOperation{
+			Operation: "add",
+			Path:      "/metadata/annotations/" + escapeJsonPointer(key),
+			Value:     value,
+		})
+	}
+	return jsonPatches, status
+}
+
+// processPrimaryURL generates a json patch that moves the client config named by the
+// primary-url annotation to the front of the client configs, keeping the relative order
+// of the remaining configs. It also returns the cluster the subsequent mutations operate
+// on, with the reordering applied. An annotation referencing a URL that matches none of
+// the client configs denies the request; a silently ignored primary would leave the
+// integrations on the wrong endpoint.
+func (a *ManagedClusterMutatingAdmissionHook) processPrimaryURL(managedCluster *clusterv1.ManagedCluster) ([]jsonPatchOperation, *clusterv1.ManagedCluster, *admissionv1beta1.AdmissionResponse) {
+	status := &admissionv1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+
+	if !a.ReorderByPrimaryURL {
+		return nil, managedCluster, status
+	}
+	primaryURL, ok := managedCluster.Annotations[primaryURLAnnotation]
+	if !ok {
+		return nil, managedCluster, status
+	}
+
+	// the annotation is matched against the canonical URL forms, so an alternative
+	// spelling of a listed URL still names it
+	primaryIndex := -1
+	normalizedPrimaryURL := normalizeClientConfigURL(primaryURL)
+	for index, clientConfig := range managedCluster.Spec.ManagedClusterClientConfigs {
+		if normalizeClientConfigURL(clientConfig.URL) == normalizedPrimaryURL {
+			primaryIndex = index
+			break
+		}
+	}
+	if primaryIndex < 0 {
+		status.Allowed = false
+		status.Result = newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+			fmt.Sprintf("the url %q of the %s annotation does not match any client config url", primaryURL, primaryURLAnnotation))
+		return nil, nil, status
+	}
+	if primaryIndex == 0 {
+		return nil, managedCluster, status
+	}
+
+	clientConfigs := managedCluster.Spec.ManagedClusterClientConfigs
+	ordered := make([]clusterv1.ClientConfig, 0, len(clientConfigs))
+	ordered = append(ordered, clientConfigs[primaryIndex])
+	ordered = append(ordered, clientConfigs[:primaryIndex]...)
+	ordered = append(ordered, clientConfigs[primaryIndex+1:]...)
+
+	orderedCluster := managedCluster.DeepCopy()
+	orderedCluster.Spec.ManagedClusterClientConfigs = ordered
+
+	return []jsonPatchOperation{{
+		Operation: "replace",
+		Path:      "/spec/managedClusterClientConfigs",
+		Value:     ordered,
+	}}, orderedCluster, status
+}
+
+// escapeJsonPointer escapes a json patch path segment as defined by RFC 6901.
+func escapeJsonPointer(segment string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(segment)
+}
+
+// processClientConfigURLs generates json patches that replace client config URLs with
+// their canonical form, so equivalent spellings of the same URL do not produce diff noise
+// or defeat duplicate detection.
+func (a *ManagedClusterMutatingAdmissionHook) processClientConfigURLs(managedCluster *clusterv1.ManagedCluster) []jsonPatchOperation {
+	var jsonPatches []jsonPatchOperation
+	for index, clientConfig := range managedCluster.Spec.ManagedClusterClientConfigs {
+		normalized := normalizeClientConfigURL(clientConfig.URL)
+		if normalized == clientConfig.URL {
+			continue
+		}
+		jsonPatches = append(jsonPatches, jsonPatchOperation{
+			Operation: "replace",
+			Path:      fmt.Sprintf("/spec/managedClusterClientConfigs/%d/url", index),
+			Value:     normalized,
+		})
+	}
+	return jsonPatches
+}
+
+// normalizeClientConfigURL returns the canonical form of a client config URL: the scheme
+// and host are lowercased, an explicit scheme default port is stripped and a trailing
+// slash is trimmed. A non-default port is kept since stripping it would change the
+// semantics of the URL, an unparsable URL is returned as is and left to the validating
+// webhook.
+func normalizeClientConfigURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || len(u.Host) == 0 {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	switch {
+	case len(port) == 0,
+		u.Scheme == "https" && port == "443",
+		u.Scheme == "http" && port == "80":
+		// an IPv6 host keeps its brackets when the port is dropped
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		u.Host = host
+	default:
+		u.Host = net.JoinHostPort(host, port)
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u.String()
+}
+
 // Initialize is called by generic-admission-server on startup to setup initialization that managedclusters webhook needs.
 func (a *ManagedClusterMutatingAdmissionHook) Initialize(kubeClientConfig *rest.Config, stopCh <-chan struct{}) error {
 	// do nothing
@@ -10,6 +10,7 @@ import (
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
@@ -59,20 +60,36 @@ func TestManagedClusterMutate(t *testing.T) {
 				build(),
 		},
 		{
-			name: "new taint request denied",
+			name: "user supplied timeAdded is overwritten on create",
 			request: &admissionv1beta1.AdmissionRequest{
 				Resource:  managedclustersSchema,
 				Operation: admissionv1beta1.Create,
 				Object: newManagedCluster().
 					withLeaseDurationSeconds(60).
-					addTaint(newTaint("a", "b", clusterv1.TaintEffectNoSelect, newTime(now, 0))).
+					addTaint(newTaint("a", "b", clusterv1.TaintEffectNoSelect, newTime(now, time.Hour))).
 					addTaint(newTaint("c", "d", clusterv1.TaintEffectPreferNoSelect, newTime(now, 0))).
 					build(),
 			},
-			expectedResponse: newAdmissionResponse(false).
-				withResult(metav1.StatusFailure, http.StatusBadRequest, metav1.StatusReasonBadRequest, "It is not allowed to set TimeAdded of Taint \"a,c\".").
+			expectedResponse: newAdmissionResponse(true).
+				addJsonPatch(newTaintTimeAddedJsonPatch(0, now)).
+				addJsonPatch(newTaintTimeAddedJsonPatch(1, now)).
 				build(),
 		},
+		{
+			name: "keep timeAdded of the unreachable taint",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object: newManagedCluster().
+					withLeaseDurationSeconds(60).
+					addTaint(newTaint(clusterv1.ManagedClusterTaintUnreachable, "", clusterv1.TaintEffectNoSelect, newTime(now, -10*time.Minute))).
+					build(),
+				OldObject: newManagedCluster().
+					withLeaseDurationSeconds(60).
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(true).build(),
+		},
 		{
 			name: "update taint",
 			request: &admissionv1beta1.AdmissionRequest{
@@ -110,7 +127,7 @@ func TestManagedClusterMutate(t *testing.T) {
 					build(),
 			},
 			expectedResponse: newAdmissionResponse(false).
-				withResult(metav1.StatusFailure, http.StatusBadRequest, metav1.StatusReasonBadRequest, "It is not allowed to set TimeAdded of Taint \"a,c\".").
+				withDenialResult(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.taints", "It is not allowed to set TimeAdded of Taint \"a,c\".").
 				build(),
 		},
 		{
@@ -147,6 +164,357 @@ func TestManagedClusterMutate(t *testing.T) {
 	}
 }
 
+func TestNormalizeClientConfigURL(t *testing.T) {
+	cases := []struct {
+		name        string
+		urls        []string
+		expectedURL string
+	}{
+		{
+			name: "equivalent forms map to one canonical form",
+			urls: []string{
+				"https://Cluster1.Example.com:6443/",
+				"https://cluster1.example.com:6443",
+				"HTTPS://CLUSTER1.EXAMPLE.COM:6443/",
+			},
+			expectedURL: "https://cluster1.example.com:6443",
+		},
+		{
+			name: "the explicit scheme default port is stripped",
+			urls: []string{
+				"https://cluster1.example.com:443/",
+				"https://cluster1.example.com:443",
+				"https://cluster1.example.com/",
+			},
+			expectedURL: "https://cluster1.example.com",
+		},
+		{
+			name:        "a non-default port is kept",
+			urls:        []string{"https://cluster1.example.com:8443/"},
+			expectedURL: "https://cluster1.example.com:8443",
+		},
+		{
+			name:        "the path is kept without its trailing slash",
+			urls:        []string{"https://cluster1.example.com:6443/apiserver/"},
+			expectedURL: "https://cluster1.example.com:6443/apiserver",
+		},
+		{
+			name:        "an IPv6 host keeps its brackets",
+			urls:        []string{"https://[2001:DB8::1]:443/"},
+			expectedURL: "https://[2001:db8::1]",
+		},
+		{
+			name:        "an unparsable URL is returned as is",
+			urls:        []string{"not a url"},
+			expectedURL: "not a url",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for _, url := range c.urls {
+				if actual := normalizeClientConfigURL(url); actual != c.expectedURL {
+					t.Errorf("expected %q to normalize to %q but got: %q", url, c.expectedURL, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestManagedClusterMutateClientConfigURLs(t *testing.T) {
+	cases := []struct {
+		name             string
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "a non-canonical client config URL is replaced",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedCluster().
+					withLeaseDurationSeconds(60).
+					addClientConfigURL("https://Cluster1.Example.com:6443/").
+					addClientConfigURL("https://cluster2.example.com:6443").
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(true).
+				addJsonPatch(jsonPatchOperation{
+					Operation: "replace",
+					Path:      "/spec/managedClusterClientConfigs/0/url",
+					Value:     "https://cluster1.example.com:6443",
+				}).
+				build(),
+		},
+		{
+			name: "canonical client config URLs are left unchanged",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedCluster().
+					withLeaseDurationSeconds(60).
+					addClientConfigURL("https://cluster1.example.com:6443").
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(true).build(),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := &ManagedClusterMutatingAdmissionHook{}
+			actualResponse := admissionHook.Admit(c.request)
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected \n%#v but got: \n%#v", c.expectedResponse, actualResponse)
+			}
+		})
+	}
+}
+
+func TestManagedClusterMutatePrimaryURL(t *testing.T) {
+	cases := []struct {
+		name             string
+		reorderEnabled   bool
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name:           "the primary client config is moved to the front",
+			reorderEnabled: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedCluster().
+					withLeaseDurationSeconds(60).
+					withAnnotations(map[string]string{primaryURLAnnotation: "https://cluster2.example.com:6443"}).
+					addClientConfigURL("https://cluster1.example.com:6443").
+					addClientConfigURL("https://cluster2.example.com:6443").
+					addClientConfigURL("https://cluster3.example.com:6443").
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(true).
+				addJsonPatch(jsonPatchOperation{
+					Operation: "replace",
+					Path:      "/spec/managedClusterClientConfigs",
+					Value: []clusterv1.ClientConfig{
+						{URL: "https://cluster2.example.com:6443"},
+						{URL: "https://cluster1.example.com:6443"},
+						{URL: "https://cluster3.example.com:6443"},
+					},
+				}).
+				build(),
+		},
+		{
+			name:           "an alternative spelling of a listed url names the primary",
+			reorderEnabled: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedCluster().
+					withLeaseDurationSeconds(60).
+					withAnnotations(map[string]string{primaryURLAnnotation: "https://Cluster2.Example.com:6443/"}).
+					addClientConfigURL("https://cluster1.example.com:6443").
+					addClientConfigURL("https://cluster2.example.com:6443").
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(true).
+				addJsonPatch(jsonPatchOperation{
+					Operation: "replace",
+					Path:      "/spec/managedClusterClientConfigs",
+					Value: []clusterv1.ClientConfig{
+						{URL: "https://cluster2.example.com:6443"},
+						{URL: "https://cluster1.example.com:6443"},
+					},
+				}).
+				build(),
+		},
+		{
+			name:           "a primary client config already at the front is left unchanged",
+			reorderEnabled: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedCluster().
+					withLeaseDurationSeconds(60).
+					withAnnotations(map[string]string{primaryURLAnnotation: "https://cluster1.example.com:6443"}).
+					addClientConfigURL("https://cluster1.example.com:6443").
+					addClientConfigURL("https://cluster2.example.com:6443").
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(true).build(),
+		},
+		{
+			name:           "a primary annotation referencing a missing url is denied",
+			reorderEnabled: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedCluster().
+					withLeaseDurationSeconds(60).
+					withAnnotations(map[string]string{primaryURLAnnotation: "https://missing.example.com:6443"}).
+					addClientConfigURL("https://cluster1.example.com:6443").
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(false).
+				withDenialResult(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+					"the url \"https://missing.example.com:6443\" of the cluster.open-cluster-management.io/primary-url annotation does not match any client config url").
+				build(),
+		},
+		{
+			name: "the primary annotation is ignored while the reordering is off",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedCluster().
+					withLeaseDurationSeconds(60).
+					withAnnotations(map[string]string{primaryURLAnnotation: "https://cluster2.example.com:6443"}).
+					addClientConfigURL("https://cluster1.example.com:6443").
+					addClientConfigURL("https://cluster2.example.com:6443").
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(true).build(),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := &ManagedClusterMutatingAdmissionHook{ReorderByPrimaryURL: c.reorderEnabled}
+			actualResponse := admissionHook.Admit(c.request)
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected \n%#v but got: \n%#v", c.expectedResponse, actualResponse)
+			}
+		})
+	}
+}
+
+func TestManagedClusterMutateAcceptedAnnotations(t *testing.T) {
+	now := time.Now()
+	nowFunc = func() time.Time {
+		return now
+	}
+	acceptedAt := now.UTC().Format(time.RFC3339)
+
+	cases := []struct {
+		name             string
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "annotations are stamped when a cluster is created accepted",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				UserInfo:  authenticationv1.UserInfo{Username: "hub-admin"},
+				Object: newManagedCluster().
+					withHubAcceptsClient(true).
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(true).
+				addJsonPatch(jsonPatchOperation{
+					Operation: "add",
+					Path:      "/metadata/annotations",
+					Value: map[string]string{
+						acceptedAtAnnotation: acceptedAt,
+						acceptedByAnnotation: "hub-admin",
+					},
+				}).
+				build(),
+		},
+		{
+			name: "annotations are stamped when hubAcceptsClient flips to true",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				UserInfo:  authenticationv1.UserInfo{Username: "hub-admin"},
+				Object: newManagedCluster().
+					withHubAcceptsClient(true).
+					withAnnotations(map[string]string{"test": "value"}).
+					build(),
+				OldObject: newManagedCluster().
+					withHubAcceptsClient(false).
+					withAnnotations(map[string]string{"test": "value"}).
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(true).
+				addJsonPatch(jsonPatchOperation{
+					Operation: "add",
+					Path:      "/metadata/annotations/register.open-cluster-management.io~1accepted-at",
+					Value:     acceptedAt,
+				}).
+				addJsonPatch(jsonPatchOperation{
+					Operation: "add",
+					Path:      "/metadata/annotations/register.open-cluster-management.io~1accepted-by",
+					Value:     "hub-admin",
+				}).
+				build(),
+		},
+		{
+			name: "annotations are not overwritten on subsequent updates",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				UserInfo:  authenticationv1.UserInfo{Username: "another-admin"},
+				Object: newManagedCluster().
+					withHubAcceptsClient(true).
+					withAnnotations(map[string]string{
+						acceptedAtAnnotation: "2020-01-01T00:00:00Z",
+						acceptedByAnnotation: "hub-admin",
+					}).
+					build(),
+				OldObject: newManagedCluster().
+					withHubAcceptsClient(true).
+					withAnnotations(map[string]string{
+						acceptedAtAnnotation: "2020-01-01T00:00:00Z",
+						acceptedByAnnotation: "hub-admin",
+					}).
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(true).build(),
+		},
+		{
+			name: "only the acceptance time is stamped without an admission user",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedCluster().
+					withHubAcceptsClient(true).
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(true).
+				addJsonPatch(jsonPatchOperation{
+					Operation: "add",
+					Path:      "/metadata/annotations",
+					Value: map[string]string{
+						acceptedAtAnnotation: acceptedAt,
+					},
+				}).
+				build(),
+		},
+		{
+			name: "an unaccepted cluster is not stamped",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				UserInfo:  authenticationv1.UserInfo{Username: "hub-admin"},
+				Object: newManagedCluster().
+					withHubAcceptsClient(false).
+					build(),
+			},
+			expectedResponse: newAdmissionResponse(true).build(),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := &ManagedClusterMutatingAdmissionHook{}
+			actualResponse := admissionHook.Admit(c.request)
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected \n%#v but got: \n%#v", c.expectedResponse, actualResponse)
+			}
+		})
+	}
+}
+
 type admissionResponseBuilder struct {
 	jsonPatchOperations []jsonPatchOperation
 	response            admissionv1beta1.AdmissionResponse
@@ -167,13 +535,8 @@ func (b *admissionResponseBuilder) addJsonPatch(jsonPatch jsonPatchOperation) *a
 	return b
 }
 
-func (b *admissionResponseBuilder) withResult(status string, code int32, reason metav1.StatusReason, message string) *admissionResponseBuilder {
-	b.response.Result = &metav1.Status{
-		Status:  status,
-		Code:    code,
-		Reason:  reason,
-		Message: message,
-	}
+func (b *admissionResponseBuilder) withDenialResult(code int32, reason metav1.StatusReason, field, message string) *admissionResponseBuilder {
+	b.response.Result = newDenialStatus(code, reason, field, message)
 	return b
 }
 
@@ -200,6 +563,21 @@ func (b *managedClusterBuilder) withLeaseDurationSeconds(leaseDurationSeconds in
 	return b
 }
 
+func (b *managedClusterBuilder) withHubAcceptsClient(hubAcceptsClient bool) *managedClusterBuilder {
+	b.cluster.Spec.HubAcceptsClient = hubAcceptsClient
+	return b
+}
+
+func (b *managedClusterBuilder) withAnnotations(annotations map[string]string) *managedClusterBuilder {
+	b.cluster.Annotations = annotations
+	return b
+}
+
+func (b *managedClusterBuilder) addClientConfigURL(url string) *managedClusterBuilder {
+	b.cluster.Spec.ManagedClusterClientConfigs = append(b.cluster.Spec.ManagedClusterClientConfigs, clusterv1.ClientConfig{URL: url})
+	return b
+}
+
 func (b *managedClusterBuilder) addTaint(taint clusterv1.Taint) *managedClusterBuilder {
 	b.cluster.Spec.Taints = append(b.cluster.Spec.Taints, taint)
 	return b
@@ -0,0 +1,23 @@
+package cluster
+
+import (
+	authenticationv1 "k8s.io/api/authentication/v1"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+// PolicyDecision is the result of evaluating an admission policy against a request.
+type PolicyDecision struct {
+	// Allowed indicates whether the policy allows the request.
+	Allowed bool
+
+	// Message explains the violation when the request is not allowed.
+	Message string
+}
+
+// PolicyEvaluator evaluates an admission policy, e.g. an OPA/Rego policy loaded from a
+// file or ConfigMap, against a ManagedCluster request. oldCluster is nil for create
+// requests. The evaluator only runs after all built-in checks allowed the request;
+// returning an error fails the request closed. The policy engine itself is kept out of
+// this package, the webhook binary wires an evaluator when a policy is configured.
+type PolicyEvaluator func(oldCluster, cluster *clusterv1.ManagedCluster, userInfo authenticationv1.UserInfo) (PolicyDecision, error)
@@ -2,18 +2,32 @@ package cluster
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"open-cluster-management.io/registration/pkg/helpers"
+	webhookmetrics "open-cluster-management.io/registration/pkg/webhook/metrics"
+	"open-cluster-management.io/registration/pkg/webhook/tracing"
+	"open-cluster-management.io/registration/pkg/webhook/warnings"
 
 	operatorhelpers "github.com/openshift/library-go/pkg/operator/v1helpers"
+	"github.com/spf13/pflag"
 
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -24,11 +38,284 @@ import (
 
 const (
 	clusterSetLabel = "cluster.open-cluster-management.io/clusterset"
+
+	// clusterNameLabel marks the namespace the registration controllers create on the
+	// hub for a managed cluster. A namespace without it belongs to something else.
+	clusterNameLabel = "open-cluster-management.io/cluster-name"
+
+	// reservedLabelDomain is the label key domain reserved for the registration
+	// controllers. Labels under this domain should not be set or modified manually.
+	reservedLabelDomain = "open-cluster-management.io"
+
+	// defaultMaxTaints is the default maximum number of taints a ManagedCluster may have
+	defaultMaxTaints = 16
+
+	// defaultMaxLabels is the default maximum number of labels a ManagedCluster may
+	// carry. It is generous on purpose, the limit exists to stop automation from dumping
+	// hundreds of labels onto a cluster.
+	defaultMaxLabels = 64
+
+	// defaultOwnerAnnotationKey is the default annotation key checked when an owner
+	// annotation is required on new ManagedClusters
+	defaultOwnerAnnotationKey = "owner"
+
+	// defaultReservedNameSubresource is the default ManagedCluster subresource the create
+	// permission is checked on for reserved cluster names
+	defaultReservedNameSubresource = "reservedname"
+
+	// defaultAcceptCheckGroup/Resource/Subresource are the default resource attributes of
+	// the SubjectAccessReview authorizing changes of the HubAcceptsClient field
+	defaultAcceptCheckGroup       = "register.open-cluster-management.io"
+	defaultAcceptCheckResource    = "managedclusters"
+	defaultAcceptCheckSubresource = "accept"
+
+	// defaultFreezeOverrideAnnotation is the default annotation exempting a new
+	// ManagedCluster from an active creation freeze
+	defaultFreezeOverrideAnnotation = "open-cluster-management.io/freeze-override"
+
+	// defaultFreezeOverrideSubresource is the default ManagedCluster subresource the
+	// create permission is checked on for a freeze override
+	defaultFreezeOverrideSubresource = "freezeoverride"
 )
 
 // ManagedClusterValidatingAdmissionHook will validate the creating/updating managedcluster request.
 type ManagedClusterValidatingAdmissionHook struct {
 	kubeClient kubernetes.Interface
+
+	// resolver resolves the hosts of the client config URLs when the resolution
+	// preflight is enabled. net.DefaultResolver is used while it is nil; tests inject
+	// a fake.
+	resolver hostResolver
+
+	// MaxTaints is the maximum number of taints a ManagedCluster may have. If it is not
+	// positive, defaultMaxTaints applies.
+	MaxTaints int
+
+	// MaxLabels is the maximum number of labels a ManagedCluster may carry. If it is not
+	// positive, defaultMaxLabels applies.
+	MaxLabels int
+
+	// ExcludeReservedLabelsFromCount excludes the labels managed by the registration
+	// controllers from the label count, so controller owned labels do not use up the
+	// budget of user labels.
+	ExcludeReservedLabelsFromCount bool
+
+	// AllowedClientConfigPorts restricts the ports the client config URLs may use. If it
+	// is empty, any port not in DeniedClientConfigPorts is accepted.
+	AllowedClientConfigPorts []int
+
+	// DeniedClientConfigPorts lists the ports the client config URLs must not use.
+	DeniedClientConfigPorts []int
+
+	// ResolveClientConfigHosts enables a DNS resolution preflight for the hosts of the
+	// client config URLs. A host that is known not to exist is rejected; a resolution
+	// that times out only adds a warning, DNS in admission must not block requests on a
+	// slow resolver.
+	ResolveClientConfigHosts bool
+
+	// StrictClientConfigResolution rejects a client config host on any resolver error
+	// instead of failing open with a warning. It only takes effect together with
+	// ResolveClientConfigHosts.
+	StrictClientConfigResolution bool
+
+	// RequireOwnerAnnotation makes the webhook reject new ManagedClusters without a
+	// non-empty owner annotation. Existing clusters can still be updated without one.
+	RequireOwnerAnnotation bool
+
+	// OwnerAnnotationKey is the annotation key checked when RequireOwnerAnnotation is
+	// set. If it is empty, defaultOwnerAnnotationKey applies.
+	OwnerAnnotationKey string
+
+	// AnnotationValidators maps an annotation key to a regular expression its value must
+	// match. Annotations without a configured validator and absent annotations are not
+	// checked; requiring an annotation is covered by RequireOwnerAnnotation.
+	AnnotationValidators map[string]string
+
+	// ReservedLabelAllowedUsers lists the users allowed to set or modify labels under
+	// the reserved label domain, usually the service accounts of the controllers
+	// managing those labels. An empty list disables the check; enabling it requires
+	// naming the hub controller identities, otherwise their label updates are
+	// rejected as well.
+	ReservedLabelAllowedUsers []string
+
+	// ReservedTaintPrefixes lists the taint key prefixes reserved for the registration
+	// controllers, e.g. cluster.open-cluster-management.io/. Users adding, modifying or
+	// removing a taint under a reserved prefix would fight the controller managing it.
+	ReservedTaintPrefixes []string
+
+	// ReservedTaintAllowedUsers lists the users allowed to change taints under the
+	// reserved taint prefixes, usually the service accounts of the controllers managing
+	// those taints.
+	ReservedTaintAllowedUsers []string
+
+	// AllowedTaintEffects lists the taint effects a ManagedCluster may use. A hub
+	// scheduler older than the agents silently ignores an effect it does not know yet;
+	// restricting to the supported effects turns that into a clear rejection. An empty
+	// list accepts all effects.
+	AllowedTaintEffects []string
+
+	// ImmutableIdentityKeys lists the label and annotation keys that identify a cluster,
+	// e.g. a cluster-id label set by the agent. Once the cluster is accepted their values
+	// may still be set for the first time but no longer changed or removed, an edited
+	// identity breaks the correlation with the spoke.
+	ImmutableIdentityKeys []string
+
+	// ReservedClusterNames lists the cluster names reserved for privileged users, e.g.
+	// local-cluster for the cluster representing the hub itself. Creating a
+	// ManagedCluster with a reserved name additionally requires the create permission
+	// checked on the reserved name subresource. Without any configured name the check is
+	// disabled.
+	ReservedClusterNames []string
+
+	// ReservedNameSubresource is the ManagedCluster subresource the create permission is
+	// checked on for reserved cluster names. If it is empty,
+	// defaultReservedNameSubresource applies.
+	ReservedNameSubresource string
+
+	// DenyExpiredClientConfigCA makes the webhook reject a ManagedCluster whose client
+	// config CA bundle contains only expired certificates. Without it the webhook
+	// responds with a warning instead.
+	DenyExpiredClientConfigCA bool
+
+	// AllowTerminatingClusterNamespace lets a ManagedCluster be created while the
+	// namespace of an earlier registration of the same cluster is still terminating.
+	// The registration controller keeps retrying to create the namespace and proceeds
+	// once the termination completed. Without it such a create is rejected with a
+	// clear message pointing at the terminating namespace.
+	AllowTerminatingClusterNamespace bool
+
+	// RequireExternalCABundle makes the webhook reject a client config pointing at a
+	// non-loopback host without a CA bundle. Such an agent falls back to the system
+	// roots, which usually cannot validate a self-signed hub apiserver certificate.
+	// Loopback and link-local URLs, e.g. in local test setups, stay exempt.
+	RequireExternalCABundle bool
+
+	// RequireClientConfigsForAccept makes the webhook reject creating a ManagedCluster
+	// with HubAcceptsClient set but without any client config. Accepting a cluster that
+	// was never described hides misconfigurations until the agent connects; requiring the
+	// client configs first surfaces them at accept time.
+	RequireClientConfigsForAccept bool
+
+	// CreationFreezeWindows lists the time windows during which creating a
+	// ManagedCluster is rejected, each formatted as two RFC3339 times separated by a
+	// slash, e.g. 2026-12-24T00:00:00Z/2027-01-02T00:00:00Z. Updating existing clusters
+	// stays allowed during a freeze. An empty list disables the freeze.
+	CreationFreezeWindows []string
+
+	// FreezeOverrideAnnotation is the annotation exempting a new ManagedCluster from an
+	// active creation freeze when it is set to "true". The override additionally
+	// requires the create permission on the freeze override subresource. If it is
+	// empty, defaultFreezeOverrideAnnotation applies.
+	FreezeOverrideAnnotation string
+
+	// FreezeOverrideSubresource is the ManagedCluster subresource the create permission
+	// is checked on for a freeze override. If it is empty,
+	// defaultFreezeOverrideSubresource applies.
+	FreezeOverrideSubresource string
+
+	// DenialStatusCodes overrides the HTTP status code the webhook responds with for
+	// denials with the given status reason, e.g. Forbidden=400. Denials with an unmapped
+	// reason keep their default codes.
+	DenialStatusCodes map[string]int
+
+	// PolicyEvaluator, when set, evaluates a configured admission policy against the
+	// request after the built-in checks allowed it. It is nil by default, no policy is
+	// evaluated then.
+	PolicyEvaluator PolicyEvaluator
+
+	// ImpersonateAuthorizationIdentity evaluates the SubjectAccessReview checks as the
+	// configured authorization identity instead of the request user. In a delegated
+	// setup the webhook runs with high privileges but must authorize as a third party,
+	// not as the user of the admission request.
+	ImpersonateAuthorizationIdentity bool
+
+	// AuthorizationIdentityUser and AuthorizationIdentityGroups are the user and groups
+	// the SubjectAccessReview checks are evaluated for when
+	// ImpersonateAuthorizationIdentity is set.
+	AuthorizationIdentityUser   string
+	AuthorizationIdentityGroups []string
+
+	// AcceptCheckGroup, AcceptCheckResource and AcceptCheckSubresource are the resource
+	// attributes of the SubjectAccessReview authorizing changes of the HubAcceptsClient
+	// field, for installations whose RBAC grants the accept permission on a different
+	// resource. Empty fields keep their defaults.
+	AcceptCheckGroup       string
+	AcceptCheckResource    string
+	AcceptCheckSubresource string
+}
+
+// NewManagedClusterValidatingAdmissionHook returns a managedcluster validating webhook
+// using the given kube client for the SubjectAccessReview checks. The admission server
+// replaces the client when it initializes the hook; passing a fake client allows the
+// Validate handler to be exercised directly in tests without an HTTP server.
+func NewManagedClusterValidatingAdmissionHook(kubeClient kubernetes.Interface) *ManagedClusterValidatingAdmissionHook {
+	return &ManagedClusterValidatingAdmissionHook{kubeClient: kubeClient}
+}
+
+// AddFlags registers flags for the managedcluster validating webhook
+func (a *ManagedClusterValidatingAdmissionHook) AddFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&a.MaxTaints, "max-taints", defaultMaxTaints,
+		"The maximum number of taints a ManagedCluster may have.")
+	fs.IntVar(&a.MaxLabels, "max-labels", defaultMaxLabels,
+		"The maximum number of labels a ManagedCluster may carry.")
+	fs.BoolVar(&a.ExcludeReservedLabelsFromCount, "exclude-reserved-labels-from-count", false,
+		"Exclude the labels managed by the registration controllers from the label count.")
+	fs.IntSliceVar(&a.AllowedClientConfigPorts, "allowed-client-config-ports", nil,
+		"The ports the client config URLs of a ManagedCluster may use. An empty list allows any port.")
+	fs.IntSliceVar(&a.DeniedClientConfigPorts, "denied-client-config-ports", nil,
+		"The ports the client config URLs of a ManagedCluster must not use.")
+	fs.BoolVar(&a.ResolveClientConfigHosts, "resolve-client-config-hosts", false,
+		"Reject a ManagedCluster whose client config URL hosts do not resolve. A resolution timeout only adds a warning.")
+	fs.BoolVar(&a.StrictClientConfigResolution, "strict-client-config-resolution", false,
+		"Reject a client config host on any resolver error instead of failing open with a warning. Only takes effect with --resolve-client-config-hosts.")
+	fs.BoolVar(&a.RequireOwnerAnnotation, "require-owner-annotation", false,
+		"Reject creating a ManagedCluster without a non-empty owner annotation.")
+	fs.StringVar(&a.OwnerAnnotationKey, "owner-annotation-key", defaultOwnerAnnotationKey,
+		"The annotation key checked when --require-owner-annotation is set.")
+	fs.StringToStringVar(&a.AnnotationValidators, "annotation-validators", nil,
+		"A mapping from annotation key to a regular expression its value must match, e.g. owner=^\\S+@\\S+$. Annotations without a validator are not checked.")
+	fs.StringSliceVar(&a.ReservedLabelAllowedUsers, "reserved-label-allowed-users", nil,
+		"The users allowed to set or modify labels under the open-cluster-management.io domain. Include the service accounts of the hub controllers managing such labels. An empty list disables the check.")
+	fs.StringSliceVar(&a.ReservedTaintPrefixes, "reserved-taint-prefixes", nil,
+		"The taint key prefixes reserved for the registration controllers, e.g. cluster.open-cluster-management.io/. Taints under a reserved prefix may only be changed by the allowed users.")
+	fs.StringSliceVar(&a.ReservedTaintAllowedUsers, "reserved-taint-allowed-users", nil,
+		"The users allowed to add, modify or remove taints under the reserved taint prefixes.")
+	fs.StringSliceVar(&a.AllowedTaintEffects, "allowed-taint-effects", nil,
+		"The taint effects a ManagedCluster may use, e.g. NoSelect. Restrict this to the effects the hub scheduler supports when it is older than the agents. An empty list accepts all effects.")
+	fs.StringSliceVar(&a.ImmutableIdentityKeys, "immutable-identity-keys", nil,
+		"The label and annotation keys identifying a cluster whose values may no longer be changed or removed once the cluster is accepted.")
+	fs.StringSliceVar(&a.ReservedClusterNames, "reserved-cluster-names", nil,
+		"A list of ManagedCluster names reserved for privileged users, e.g. local-cluster. Creating a cluster with a reserved name requires the create permission checked on the reserved name subresource. An empty list disables the check.")
+	fs.StringVar(&a.ReservedNameSubresource, "cluster-reserved-name-subresource", defaultReservedNameSubresource,
+		"The ManagedCluster subresource the create permission is checked on for reserved cluster names.")
+	fs.BoolVar(&a.DenyExpiredClientConfigCA, "deny-expired-client-config-ca", false,
+		"Reject a ManagedCluster whose client config CA bundle contains only expired certificates instead of responding with a warning.")
+	fs.BoolVar(&a.AllowTerminatingClusterNamespace, "allow-terminating-cluster-namespace", false,
+		"Allow creating a ManagedCluster while the namespace of an earlier registration of the same cluster is still terminating, instead of rejecting the creation until the termination completed.")
+	fs.BoolVar(&a.RequireExternalCABundle, "require-external-ca-bundle", false,
+		"Reject a client config pointing at a non-loopback host without a CA bundle. Loopback and link-local URLs stay exempt.")
+	fs.BoolVar(&a.RequireClientConfigsForAccept, "require-client-configs-for-accept", false,
+		"Reject creating a ManagedCluster with hubAcceptsClient set but without any client config.")
+	fs.StringSliceVar(&a.CreationFreezeWindows, "creation-freeze-windows", nil,
+		"Time windows during which creating a ManagedCluster is rejected, each formatted as two RFC3339 times separated by a slash, e.g. 2026-12-24T00:00:00Z/2027-01-02T00:00:00Z. Updating existing clusters stays allowed during a freeze.")
+	fs.StringVar(&a.FreezeOverrideAnnotation, "freeze-override-annotation", defaultFreezeOverrideAnnotation,
+		"The annotation exempting a new ManagedCluster from an active creation freeze when set to true. The override requires the create permission on the freeze override subresource.")
+	fs.StringVar(&a.FreezeOverrideSubresource, "freeze-override-subresource", defaultFreezeOverrideSubresource,
+		"The ManagedCluster subresource the create permission is checked on for a freeze override.")
+	fs.StringToIntVar(&a.DenialStatusCodes, "denial-status-codes", nil,
+		"A mapping from denial status reason to the HTTP status code to respond with, e.g. Forbidden=400. Denials with an unmapped reason keep their default codes.")
+	fs.BoolVar(&a.ImpersonateAuthorizationIdentity, "impersonate-authorization-identity", false,
+		"Evaluate the SubjectAccessReview checks as the identity configured with --authorization-identity-user and --authorization-identity-groups instead of the request user.")
+	fs.StringVar(&a.AuthorizationIdentityUser, "authorization-identity-user", "",
+		"The user the SubjectAccessReview checks are evaluated for when --impersonate-authorization-identity is set.")
+	fs.StringSliceVar(&a.AuthorizationIdentityGroups, "authorization-identity-groups", nil,
+		"The groups the SubjectAccessReview checks are evaluated for when --impersonate-authorization-identity is set.")
+	fs.StringVar(&a.AcceptCheckGroup, "accept-check-group", defaultAcceptCheckGroup,
+		"The API group of the SubjectAccessReview authorizing changes of the HubAcceptsClient field.")
+	fs.StringVar(&a.AcceptCheckResource, "accept-check-resource", defaultAcceptCheckResource,
+		"The resource of the SubjectAccessReview authorizing changes of the HubAcceptsClient field.")
+	fs.StringVar(&a.AcceptCheckSubresource, "accept-check-subresource", defaultAcceptCheckSubresource,
+		"The subresource of the SubjectAccessReview authorizing changes of the HubAcceptsClient field.")
 }
 
 // ValidatingResource is called by generic-admission-server on startup to register the returned REST resource through which the
@@ -55,43 +342,180 @@ func (a *ManagedClusterValidatingAdmissionHook) Validate(admissionSpec *admissio
 		return status
 	}
 
+	// the span stays a no-op unless a tracer provider was installed by the tracing setup
+	ctx, span := tracing.StartAdmissionSpan(context.Background(), "ManagedClusterValidatingWebhook", admissionSpec)
 	switch admissionSpec.Operation {
 	case admissionv1beta1.Create:
-		return a.validateCreateRequest(admissionSpec)
+		status = a.validateCreateRequest(ctx, admissionSpec)
 	case admissionv1beta1.Update:
-		return a.validateUpdateRequest(admissionSpec)
+		status = a.validateUpdateRequest(ctx, admissionSpec)
 	default:
 		status.Allowed = true
-		return status
 	}
+	tracing.EndAdmissionSpan(span, status)
+	return status
 }
 
 // Initialize is called by generic-admission-server on startup to setup initialization that managedclusters webhook needs.
 func (a *ManagedClusterValidatingAdmissionHook) Initialize(kubeClientConfig *rest.Config, stopCh <-chan struct{}) error {
 	var err error
 	a.kubeClient, err = kubernetes.NewForConfig(kubeClientConfig)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// the configuration does not change while the webhook runs, export it once
+	exportConfigMetrics(a.EffectiveConfig())
+	return nil
+}
+
+// ready reports whether the hook finished its initialization. The admission server only
+// serves after Initialize returned, but a request racing the startup must not reach the
+// rules that look up state outside the request object.
+func (a *ManagedClusterValidatingAdmissionHook) ready() bool {
+	return a.kubeClient != nil
+}
+
+// notReadyStatus returns a retryable denial for a request that reaches a lookup based
+// rule before the hook is ready. Degrading to a transient error is preferred over a
+// wrong allow or deny decision from incomplete data.
+func notReadyStatus() *metav1.Status {
+	return newDenialStatus(http.StatusServiceUnavailable, metav1.StatusReasonServiceUnavailable, "",
+		"the webhook has not finished its initialization, retry the request later")
 }
 
 // validateCreateRequest validates create managed cluster operation
-func (a *ManagedClusterValidatingAdmissionHook) validateCreateRequest(request *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+func (a *ManagedClusterValidatingAdmissionHook) validateCreateRequest(ctx context.Context, request *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
 	status := &admissionv1beta1.AdmissionResponse{}
 
 	// validate ManagedCluster object firstly
 	managedCluster, err := a.validateManagedClusterObj(request.Object)
 	if err != nil {
 		status.Allowed = false
-		status.Result = &metav1.Status{
-			Status: metav1.StatusFailure, Code: http.StatusBadRequest, Reason: metav1.StatusReasonBadRequest,
-			Message: err.Error(),
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec", err.Error())
+		return status
+	}
+
+	// check the number of taints does not exceed the maximum allowed
+	if err := a.validateTaintCount(managedCluster, nil); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.taints", err.Error())
+		return status
+	}
+
+	// check every taint effect is in the configured allowlist
+	if err := a.validateAllowedTaintEffects(managedCluster); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.taints", err.Error())
+		return status
+	}
+
+	// check the number of labels does not exceed the maximum allowed
+	if err := a.validateLabelCount(managedCluster, nil); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.labels", err.Error())
+		return status
+	}
+
+	// reject a clusterset label with an empty value, it corresponds to no real clusterset
+	if err := validateClusterSetLabel(managedCluster); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.labels", err.Error())
+		return status
+	}
+
+	// a client config CA bundle whose certificates are all expired guarantees connection
+	// failure, reject or warn depending on the configuration
+	caBundleErrs := validateClientConfigCABundles(managedCluster)
+	if len(caBundleErrs) > 0 && a.DenyExpiredClientConfigCA {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.managedClusterClientConfigs",
+			operatorhelpers.NewMultiLineAggregate(caBundleErrs).Error())
+		return status
+	}
+
+	// an external client config without a CA bundle relies on the system roots, which
+	// usually cannot validate a self-signed hub apiserver certificate
+	if externalCAErrs := a.validateExternalCABundles(managedCluster); len(externalCAErrs) > 0 {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.managedClusterClientConfigs",
+			operatorhelpers.NewMultiLineAggregate(externalCAErrs).Error())
+		return status
+	}
+
+	// optionally check that the client config hosts resolve
+	resolveErrs, resolveWarnings := a.resolveClientConfigHosts(managedCluster)
+	if len(resolveErrs) > 0 {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.managedClusterClientConfigs",
+			operatorhelpers.NewMultiLineAggregate(resolveErrs).Error())
+		return status
+	}
+
+	// a new cluster must carry an owner annotation if one is required
+	if err := a.validateOwnerAnnotation(managedCluster); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations", err.Error())
+		return status
+	}
+
+	// annotation values must conform to their configured validation rules
+	if annotationErrs := a.validateAnnotations(managedCluster); len(annotationErrs) > 0 {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+			operatorhelpers.NewMultiLineAggregate(annotationErrs).Error())
+		return status
+	}
+
+	// during a configured creation freeze new clusters are rejected, unless the request
+	// carries an authorized freeze override
+	if window := a.activeFreezeWindow(time.Now()); window != nil {
+		if status := a.allowFrozenCreation(ctx, managedCluster, request.UserInfo, window); !status.Allowed {
+			return status
 		}
+	}
+
+	// a reserved cluster name may only be used by users granted the reserved name permission
+	if a.isReservedClusterName(managedCluster.Name) {
+		if status := a.allowReservedClusterName(ctx, managedCluster.Name, request.UserInfo); !status.Allowed {
+			return status
+		}
+	}
+
+	// a cluster whose name collides with a namespace owned by something else cannot onboard
+	if result := a.validateClusterNamespace(ctx, managedCluster); result != nil {
+		status.Allowed = false
+		status.Result = result
+		return status
+	}
+
+	// reserved labels may only be set by the allowed users
+	if err := a.validateReservedLabels(request.UserInfo, nil, managedCluster); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.labels", err.Error())
+		return status
+	}
+
+	// taints under a reserved prefix may only be set by the allowed users
+	if err := a.validateReservedTaints(request.UserInfo, nil, managedCluster); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "spec.taints", err.Error())
 		return status
 	}
 
 	if managedCluster.Spec.HubAcceptsClient {
+		// optionally require the client configs to be populated before a new cluster is
+		// accepted, so a misconfigured registration is caught at accept time
+		if a.RequireClientConfigsForAccept && len(managedCluster.Spec.ManagedClusterClientConfigs) == 0 {
+			status.Allowed = false
+			status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.hubAcceptsClient",
+				"a ManagedCluster cannot be accepted without client configs, register the cluster first or provide the URL of its apiserver in spec.managedClusterClientConfigs")
+			return status
+		}
+
 		// the HubAcceptsClient field is changed, we need to check the request user whether
 		// has been allowed to change the HubAcceptsClient field with SubjectAccessReview api
-		if status := a.allowUpdateAcceptField(managedCluster.Name, request.UserInfo); !status.Allowed {
+		if status := a.allowUpdateAcceptField(ctx, managedCluster.Name, request.UserInfo); !status.Allowed {
 			return status
 		}
 	}
@@ -102,20 +526,19 @@ func (a *ManagedClusterValidatingAdmissionHook) validateCreateRequest(request *a
 		clusterSetName = managedCluster.Labels[clusterSetLabel]
 	}
 
-	return a.allowSetClusterSetLabel(request.UserInfo, "", clusterSetName)
+	status = withWarnings(a.allowSetClusterSetLabel(ctx, request.UserInfo, "", clusterSetName), warnings.SeverityWarning, caBundleErrs)
+	status = withWarnings(status, warnings.SeverityInfo, resolveWarnings)
+	return a.evaluatePolicy(status, nil, managedCluster, request.UserInfo)
 }
 
 // validateUpdateRequest validates update managed cluster operation.
-func (a *ManagedClusterValidatingAdmissionHook) validateUpdateRequest(request *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+func (a *ManagedClusterValidatingAdmissionHook) validateUpdateRequest(ctx context.Context, request *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
 	status := &admissionv1beta1.AdmissionResponse{}
 
 	oldManagedCluster := &clusterv1.ManagedCluster{}
 	if err := json.Unmarshal(request.OldObject.Raw, oldManagedCluster); err != nil {
 		status.Allowed = false
-		status.Result = &metav1.Status{
-			Status: metav1.StatusFailure, Code: http.StatusBadRequest, Reason: metav1.StatusReasonBadRequest,
-			Message: err.Error(),
-		}
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec", err.Error())
 		return status
 	}
 
@@ -123,31 +546,121 @@ func (a *ManagedClusterValidatingAdmissionHook) validateUpdateRequest(request *a
 	newManagedCluster, err := a.validateManagedClusterObj(request.Object)
 	if err != nil {
 		status.Allowed = false
-		status.Result = &metav1.Status{
-			Status: metav1.StatusFailure, Code: http.StatusBadRequest, Reason: metav1.StatusReasonBadRequest,
-			Message: err.Error(),
-		}
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec", err.Error())
+		return status
+	}
+
+	// compute the old-vs-new changes once, the update rules below consume them
+	changes := managedClusterDiff(oldManagedCluster, newManagedCluster)
+
+	// check the number of taints does not exceed the maximum allowed
+	if err := a.validateTaintCount(newManagedCluster, oldManagedCluster); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.taints", err.Error())
+		return status
+	}
+
+	// check every taint effect is in the configured allowlist
+	if err := a.validateAllowedTaintEffects(newManagedCluster); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.taints", err.Error())
+		return status
+	}
+
+	// check the number of labels does not exceed the maximum allowed
+	if err := a.validateLabelCount(newManagedCluster, oldManagedCluster); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.labels", err.Error())
+		return status
+	}
+
+	// reject a clusterset label with an empty value, it corresponds to no real clusterset
+	if err := validateClusterSetLabel(newManagedCluster); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.labels", err.Error())
+		return status
+	}
+
+	// annotation values must conform to their configured validation rules
+	if annotationErrs := a.validateAnnotations(newManagedCluster); len(annotationErrs) > 0 {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+			operatorhelpers.NewMultiLineAggregate(annotationErrs).Error())
+		return status
+	}
+
+	// the identity label and annotation keys are immutable once the cluster is accepted
+	if identityErrs := a.validateIdentityKeys(oldManagedCluster, newManagedCluster); len(identityErrs) > 0 {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata",
+			operatorhelpers.NewMultiLineAggregate(identityErrs).Error())
+		return status
+	}
+
+	// an accepted cluster must keep at least one client config
+	if err := validateClientConfigRemoval(newManagedCluster, oldManagedCluster); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.managedClusterClientConfigs", err.Error())
+		return status
+	}
+
+	// a client config CA bundle whose certificates are all expired guarantees connection
+	// failure, reject or warn depending on the configuration
+	caBundleErrs := validateClientConfigCABundles(newManagedCluster)
+	if len(caBundleErrs) > 0 && a.DenyExpiredClientConfigCA {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.managedClusterClientConfigs",
+			operatorhelpers.NewMultiLineAggregate(caBundleErrs).Error())
+		return status
+	}
+
+	// an external client config without a CA bundle relies on the system roots, which
+	// usually cannot validate a self-signed hub apiserver certificate
+	if externalCAErrs := a.validateExternalCABundles(newManagedCluster); len(externalCAErrs) > 0 {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.managedClusterClientConfigs",
+			operatorhelpers.NewMultiLineAggregate(externalCAErrs).Error())
+		return status
+	}
+
+	// optionally check that the client config hosts resolve
+	resolveErrs, resolveWarnings := a.resolveClientConfigHosts(newManagedCluster)
+	if len(resolveErrs) > 0 {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.managedClusterClientConfigs",
+			operatorhelpers.NewMultiLineAggregate(resolveErrs).Error())
 		return status
 	}
 
-	if newManagedCluster.Spec.HubAcceptsClient != oldManagedCluster.Spec.HubAcceptsClient {
+	// reserved labels may only be modified by the allowed users
+	if err := a.validateReservedLabels(request.UserInfo, oldManagedCluster, newManagedCluster); err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.labels", err.Error())
+		return status
+	}
+
+	// taints under a reserved prefix may only be modified by the allowed users, only an
+	// update that touches the taints needs the check
+	if changes.taintsChanged {
+		if err := a.validateReservedTaints(request.UserInfo, oldManagedCluster, newManagedCluster); err != nil {
+			status.Allowed = false
+			status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "spec.taints", err.Error())
+			return status
+		}
+	}
+
+	if changes.acceptChanged {
 		// the HubAcceptsClient field is changed, we need to check the request user whether
 		// has been allowed to update the HubAcceptsClient field with SubjectAccessReview api
-		if status := a.allowUpdateAcceptField(newManagedCluster.Name, request.UserInfo); !status.Allowed {
+		if status := a.allowUpdateAcceptField(ctx, newManagedCluster.Name, request.UserInfo); !status.Allowed {
 			return status
 		}
 	}
 
 	// check whether the request user has been allowed to set clusterset label
-	var originalClusterSetName, currentClusterSetName string
-	if len(oldManagedCluster.Labels) > 0 {
-		originalClusterSetName = oldManagedCluster.Labels[clusterSetLabel]
-	}
-	if len(newManagedCluster.Labels) > 0 {
-		currentClusterSetName = newManagedCluster.Labels[clusterSetLabel]
-	}
-
-	return a.allowSetClusterSetLabel(request.UserInfo, originalClusterSetName, currentClusterSetName)
+	status = withWarnings(a.allowSetClusterSetLabel(ctx, request.UserInfo, changes.oldClusterSet, changes.newClusterSet), warnings.SeverityWarning, caBundleErrs)
+	status = withWarnings(status, warnings.SeverityInfo, resolveWarnings)
+	return a.evaluatePolicy(status, oldManagedCluster, newManagedCluster, request.UserInfo)
 }
 
 // validateManagedClusterObj validates the fileds of ManagedCluster object
@@ -159,62 +672,941 @@ func (a *ManagedClusterValidatingAdmissionHook) validateManagedClusterObj(reques
 		errs = append(errs, err)
 	}
 
-	// there are no spoke client configs, finish the validation process
-	if len(managedCluster.Spec.ManagedClusterClientConfigs) == 0 {
-		return managedCluster, operatorhelpers.NewMultiLineAggregate(errs)
+	// validate the taints, a taint with an empty key is almost always a serialization mistake
+	for index, taint := range managedCluster.Spec.Taints {
+		if len(taint.Key) == 0 {
+			errs = append(errs, fmt.Errorf("taint key of taints[%d] is empty", index))
+		}
 	}
 
+	// reject conflicting taint effects on the same key, NoSelect together with
+	// PreferNoSelect or NoSelectIfNew is contradictory for the scheduler
+	errs = append(errs, validateTaintEffects(managedCluster.Spec.Taints)...)
+
 	// validate the url in spoke client configs
 	for _, clientConfig := range managedCluster.Spec.ManagedClusterClientConfigs {
 		if !helpers.IsValidHTTPSURL(clientConfig.URL) {
 			errs = append(errs, fmt.Errorf("url %q is invalid in client configs", clientConfig.URL))
+			continue
+		}
+		if err := a.validateClientConfigPort(clientConfig.URL); err != nil {
+			errs = append(errs, err)
 		}
 	}
 
 	return managedCluster, operatorhelpers.NewMultiLineAggregate(errs)
 }
 
-// allowUpdateHubAcceptsClientField using SubjectAccessReview API to check whether a request user has been authorized to update
-// HubAcceptsClient field
-func (a *ManagedClusterValidatingAdmissionHook) allowUpdateAcceptField(clusterName string, userInfo authenticationv1.UserInfo) *admissionv1beta1.AdmissionResponse {
-	status := &admissionv1beta1.AdmissionResponse{}
+// ValidatingWebhookConfig holds the effective, resolved configuration enforced by the
+// managedcluster validating webhook.
+type ValidatingWebhookConfig struct {
+	MaxTaints                        int               `json:"maxTaints"`
+	MaxLabels                        int               `json:"maxLabels"`
+	ExcludeReservedLabelsFromCount   bool              `json:"excludeReservedLabelsFromCount"`
+	AllowedClientConfigPorts         []int             `json:"allowedClientConfigPorts,omitempty"`
+	DeniedClientConfigPorts          []int             `json:"deniedClientConfigPorts,omitempty"`
+	ResolveClientConfigHosts         bool              `json:"resolveClientConfigHosts"`
+	StrictClientConfigResolution     bool              `json:"strictClientConfigResolution"`
+	RequireOwnerAnnotation           bool              `json:"requireOwnerAnnotation"`
+	OwnerAnnotationKey               string            `json:"ownerAnnotationKey,omitempty"`
+	AnnotationValidators             map[string]string `json:"annotationValidators,omitempty"`
+	ReservedLabelAllowedUsers        []string          `json:"reservedLabelAllowedUsers,omitempty"`
+	ReservedTaintPrefixes            []string          `json:"reservedTaintPrefixes,omitempty"`
+	ReservedTaintAllowedUsers        []string          `json:"reservedTaintAllowedUsers,omitempty"`
+	AllowedTaintEffects              []string          `json:"allowedTaintEffects,omitempty"`
+	ImmutableIdentityKeys            []string          `json:"immutableIdentityKeys,omitempty"`
+	ReservedClusterNames             []string          `json:"reservedClusterNames,omitempty"`
+	ReservedNameSubresource          string            `json:"reservedNameSubresource,omitempty"`
+	AllowTerminatingClusterNamespace bool              `json:"allowTerminatingClusterNamespace"`
+	DenyExpiredClientConfigCA        bool              `json:"denyExpiredClientConfigCA"`
+	RequireExternalCABundle          bool              `json:"requireExternalCABundle"`
+	RequireClientConfigsForAccept    bool              `json:"requireClientConfigsForAccept"`
+	CreationFreezeWindows            []string          `json:"creationFreezeWindows,omitempty"`
+	FreezeOverrideAnnotation         string            `json:"freezeOverrideAnnotation,omitempty"`
+	FreezeOverrideSubresource        string            `json:"freezeOverrideSubresource,omitempty"`
+	DenialStatusCodes                map[string]int    `json:"denialStatusCodes,omitempty"`
+	ImpersonateAuthorizationIdentity bool              `json:"impersonateAuthorizationIdentity"`
+	AuthorizationIdentityUser        string            `json:"authorizationIdentityUser,omitempty"`
+	AuthorizationIdentityGroups      []string          `json:"authorizationIdentityGroups,omitempty"`
+	AcceptCheckGroup                 string            `json:"acceptCheckGroup,omitempty"`
+	AcceptCheckResource              string            `json:"acceptCheckResource,omitempty"`
+	AcceptCheckSubresource           string            `json:"acceptCheckSubresource,omitempty"`
+}
+
+// EffectiveConfig returns the configuration the webhook actually enforces, with all
+// defaults resolved.
+func (a *ManagedClusterValidatingAdmissionHook) EffectiveConfig() ValidatingWebhookConfig {
+	maxTaints := a.MaxTaints
+	if maxTaints <= 0 {
+		maxTaints = defaultMaxTaints
+	}
+	maxLabels := a.MaxLabels
+	if maxLabels <= 0 {
+		maxLabels = defaultMaxLabels
+	}
+	ownerAnnotationKey := a.OwnerAnnotationKey
+	if len(ownerAnnotationKey) == 0 {
+		ownerAnnotationKey = defaultOwnerAnnotationKey
+	}
+	acceptCheckGroup := a.AcceptCheckGroup
+	if len(acceptCheckGroup) == 0 {
+		acceptCheckGroup = defaultAcceptCheckGroup
+	}
+	acceptCheckResource := a.AcceptCheckResource
+	if len(acceptCheckResource) == 0 {
+		acceptCheckResource = defaultAcceptCheckResource
+	}
+	acceptCheckSubresource := a.AcceptCheckSubresource
+	if len(acceptCheckSubresource) == 0 {
+		acceptCheckSubresource = defaultAcceptCheckSubresource
+	}
+	reservedNameSubresource := a.ReservedNameSubresource
+	if len(reservedNameSubresource) == 0 {
+		reservedNameSubresource = defaultReservedNameSubresource
+	}
+	freezeOverrideAnnotation := a.FreezeOverrideAnnotation
+	if len(freezeOverrideAnnotation) == 0 {
+		freezeOverrideAnnotation = defaultFreezeOverrideAnnotation
+	}
+	freezeOverrideSubresource := a.FreezeOverrideSubresource
+	if len(freezeOverrideSubresource) == 0 {
+		freezeOverrideSubresource = defaultFreezeOverrideSubresource
+	}
+	return ValidatingWebhookConfig{
+		MaxTaints:                        maxTaints,
+		MaxLabels:                        maxLabels,
+		ExcludeReservedLabelsFromCount:   a.ExcludeReservedLabelsFromCount,
+		AllowedClientConfigPorts:         a.AllowedClientConfigPorts,
+		DeniedClientConfigPorts:          a.DeniedClientConfigPorts,
+		ResolveClientConfigHosts:         a.ResolveClientConfigHosts,
+		StrictClientConfigResolution:     a.StrictClientConfigResolution,
+		RequireOwnerAnnotation:           a.RequireOwnerAnnotation,
+		OwnerAnnotationKey:               ownerAnnotationKey,
+		AnnotationValidators:             a.AnnotationValidators,
+		ReservedLabelAllowedUsers:        a.ReservedLabelAllowedUsers,
+		ReservedTaintPrefixes:            a.ReservedTaintPrefixes,
+		ReservedTaintAllowedUsers:        a.ReservedTaintAllowedUsers,
+		AllowedTaintEffects:              a.AllowedTaintEffects,
+		ImmutableIdentityKeys:            a.ImmutableIdentityKeys,
+		ReservedClusterNames:             a.ReservedClusterNames,
+		ReservedNameSubresource:          reservedNameSubresource,
+		AllowTerminatingClusterNamespace: a.AllowTerminatingClusterNamespace,
+		DenyExpiredClientConfigCA:        a.DenyExpiredClientConfigCA,
+		RequireExternalCABundle:          a.RequireExternalCABundle,
+		RequireClientConfigsForAccept:    a.RequireClientConfigsForAccept,
+		CreationFreezeWindows:            a.CreationFreezeWindows,
+		FreezeOverrideAnnotation:         freezeOverrideAnnotation,
+		FreezeOverrideSubresource:        freezeOverrideSubresource,
+		DenialStatusCodes:                a.DenialStatusCodes,
+		ImpersonateAuthorizationIdentity: a.ImpersonateAuthorizationIdentity,
+		AuthorizationIdentityUser:        a.AuthorizationIdentityUser,
+		AuthorizationIdentityGroups:      a.AuthorizationIdentityGroups,
+		AcceptCheckGroup:                 acceptCheckGroup,
+		AcceptCheckResource:              acceptCheckResource,
+		AcceptCheckSubresource:           acceptCheckSubresource,
+	}
+}
+
+// clientConfigResolveTimeout bounds the DNS resolution of a single client config host.
+// It is kept tight, an admission request must not wait long on a slow resolver.
+const clientConfigResolveTimeout = 2 * time.Second
+
+// hostResolver resolves host names. It matches the LookupHost method of net.Resolver,
+// so tests can inject a fake resolver.
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// resolveClientConfigHosts checks that the hosts of the client config URLs resolve when
+// the resolution preflight is enabled. A host that is known not to exist yields an error;
+// a resolution timeout or, unless configured strict, any other resolver error yields a
+// warning only.
+func (a *ManagedClusterValidatingAdmissionHook) resolveClientConfigHosts(managedCluster *clusterv1.ManagedCluster) (errs, warnings []error) {
+	if !a.ResolveClientConfigHosts {
+		return nil, nil
+	}
+
+	resolver := a.resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	for _, clientConfig := range managedCluster.Spec.ManagedClusterClientConfigs {
+		parsedURL, err := url.Parse(clientConfig.URL)
+		if err != nil {
+			// an unparseable URL is already rejected by the URL validation
+			continue
+		}
+		host := parsedURL.Hostname()
+		if len(host) == 0 || net.ParseIP(host) != nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), clientConfigResolveTimeout)
+		_, err = resolver.LookupHost(ctx, host)
+		cancel()
+		if err == nil {
+			continue
+		}
+
+		dnsErr, isDNSErr := err.(*net.DNSError)
+		switch {
+		case isDNSErr && dnsErr.IsNotFound:
+			errs = append(errs, fmt.Errorf("host %q of url %q does not resolve", host, clientConfig.URL))
+		case isDNSErr && dnsErr.IsTimeout:
+			warnings = append(warnings, fmt.Errorf("host %q of url %q could not be resolved within %v", host, clientConfig.URL, clientConfigResolveTimeout))
+		case a.StrictClientConfigResolution:
+			errs = append(errs, fmt.Errorf("unable to resolve host %q of url %q: %v", host, clientConfig.URL, err))
+		default:
+			warnings = append(warnings, fmt.Errorf("unable to resolve host %q of url %q: %v", host, clientConfig.URL, err))
+		}
+	}
+	return errs, warnings
+}
+
+// validateClientConfigPort checks the port of a client config URL against the configured
+// allowlist and denylist. Without any configuration all ports are accepted. A URL without
+// an explicit port uses the https default port 443.
+func (a *ManagedClusterValidatingAdmissionHook) validateClientConfigPort(clientConfigURL string) error {
+	if len(a.AllowedClientConfigPorts) == 0 && len(a.DeniedClientConfigPorts) == 0 {
+		return nil
+	}
+
+	parsedURL, err := url.Parse(clientConfigURL)
+	if err != nil {
+		return fmt.Errorf("url %q is invalid in client configs", clientConfigURL)
+	}
+
+	port := 443
+	if len(parsedURL.Port()) > 0 {
+		port, err = strconv.Atoi(parsedURL.Port())
+		if err != nil {
+			return fmt.Errorf("url %q is invalid in client configs", clientConfigURL)
+		}
+	}
+
+	for _, deniedPort := range a.DeniedClientConfigPorts {
+		if port == deniedPort {
+			return fmt.Errorf("port %d of url %q is denied in client configs", port, clientConfigURL)
+		}
+	}
+
+	if len(a.AllowedClientConfigPorts) == 0 {
+		return nil
+	}
+	for _, allowedPort := range a.AllowedClientConfigPorts {
+		if port == allowedPort {
+			return nil
+		}
+	}
+	return fmt.Errorf("port %d of url %q is not allowed in client configs", port, clientConfigURL)
+}
+
+// validateTaintEffects rejects a taints slice that contains the same key with the
+// NoSelect effect together with the PreferNoSelect or NoSelectIfNew effect, which is
+// contradictory selection guidance for the same key.
+func validateTaintEffects(taints []clusterv1.Taint) []error {
+	effectsByKey := map[string]map[clusterv1.TaintEffect]bool{}
+	for _, taint := range taints {
+		if len(taint.Key) == 0 {
+			continue
+		}
+		if effectsByKey[taint.Key] == nil {
+			effectsByKey[taint.Key] = map[clusterv1.TaintEffect]bool{}
+		}
+		effectsByKey[taint.Key][taint.Effect] = true
+	}
+
+	errs := []error{}
+	for _, taint := range taints {
+		effects := effectsByKey[taint.Key]
+		if effects == nil || !effects[clusterv1.TaintEffectNoSelect] {
+			continue
+		}
+		if effects[clusterv1.TaintEffectPreferNoSelect] || effects[clusterv1.TaintEffectNoSelectIfNew] {
+			errs = append(errs, fmt.Errorf("taints with key %q have conflicting effects, %s cannot be combined with %s or %s",
+				taint.Key, clusterv1.TaintEffectNoSelect, clusterv1.TaintEffectPreferNoSelect, clusterv1.TaintEffectNoSelectIfNew))
+		}
+		// report each key only once
+		delete(effectsByKey, taint.Key)
+	}
+	return errs
+}
+
+// validateClusterSetLabel rejects a clusterset label that is present with an empty value,
+// which names no real ManagedClusterSet. A ManagedCluster is kept out of any clusterset by
+// omitting the label entirely.
+func validateClusterSetLabel(managedCluster *clusterv1.ManagedCluster) error {
+	value, ok := managedCluster.Labels[clusterSetLabel]
+	if ok && len(value) == 0 {
+		return fmt.Errorf("the %s label must not have an empty value, remove the label instead", clusterSetLabel)
+	}
+	return nil
+}
+
+// validateReservedLabels rejects a request that sets, modifies or removes labels under
+// the reserved label domain unless the request user is in the configured allowlist. The
+// clusterset label is excepted, changing it is authorized by its own SubjectAccessReview
+// checks. Without a configured allowlist the check is disabled, like the reserved taint
+// check: nobody could maintain the labels the hub controllers manage otherwise.
+func (a *ManagedClusterValidatingAdmissionHook) validateReservedLabels(userInfo authenticationv1.UserInfo, oldManagedCluster, managedCluster *clusterv1.ManagedCluster) error {
+	if len(a.ReservedLabelAllowedUsers) == 0 {
+		return nil
+	}
+
+	changed := []string{}
+	for key, value := range managedCluster.Labels {
+		if !isReservedLabelKey(key) {
+			continue
+		}
+		if oldManagedCluster != nil {
+			if oldValue, ok := oldManagedCluster.Labels[key]; ok && oldValue == value {
+				continue
+			}
+		}
+		changed = append(changed, key)
+	}
+	if oldManagedCluster != nil {
+		for key := range oldManagedCluster.Labels {
+			if !isReservedLabelKey(key) {
+				continue
+			}
+			if _, ok := managedCluster.Labels[key]; !ok {
+				changed = append(changed, key)
+			}
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	for _, allowedUser := range a.ReservedLabelAllowedUsers {
+		if userInfo.Username == allowedUser {
+			return nil
+		}
+	}
+
+	sort.Strings(changed)
+	return fmt.Errorf("user %q is not allowed to set or modify the reserved labels %q",
+		userInfo.Username, strings.Join(changed, ","))
+}
+
+// validateReservedTaints rejects a request that adds, modifies or removes taints whose
+// keys are under a configured reserved taint prefix unless the request user is in the
+// configured allowlist, so user edits do not fight the controllers managing those taints.
+func (a *ManagedClusterValidatingAdmissionHook) validateReservedTaints(userInfo authenticationv1.UserInfo, oldManagedCluster, managedCluster *clusterv1.ManagedCluster) error {
+	if len(a.ReservedTaintPrefixes) == 0 {
+		return nil
+	}
+
+	oldTaints := map[string]clusterv1.Taint{}
+	if oldManagedCluster != nil {
+		for _, taint := range oldManagedCluster.Spec.Taints {
+			oldTaints[taint.Key] = taint
+		}
+	}
+
+	changed := []string{}
+	seen := map[string]bool{}
+	for _, taint := range managedCluster.Spec.Taints {
+		seen[taint.Key] = true
+		if !a.isReservedTaintKey(taint.Key) {
+			continue
+		}
+		// TimeAdded is stamped by the controllers and not compared, an untouched taint
+		// must not be flagged as changed
+		if oldTaint, ok := oldTaints[taint.Key]; ok && oldTaint.Value == taint.Value && oldTaint.Effect == taint.Effect {
+			continue
+		}
+		changed = append(changed, taint.Key)
+	}
+	for key := range oldTaints {
+		if !a.isReservedTaintKey(key) {
+			continue
+		}
+		if !seen[key] {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	for _, allowedUser := range a.ReservedTaintAllowedUsers {
+		if userInfo.Username == allowedUser {
+			return nil
+		}
+	}
+
+	sort.Strings(changed)
+	return fmt.Errorf("user %q is not allowed to set or modify the reserved taints %q",
+		userInfo.Username, strings.Join(changed, ","))
+}
+
+// isReservedTaintKey returns true if the given taint key is under one of the configured
+// reserved taint prefixes.
+func (a *ManagedClusterValidatingAdmissionHook) isReservedTaintKey(key string) bool {
+	for _, prefix := range a.ReservedTaintPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isReservedLabelKey returns true if the given label key is under the reserved label
+// domain and managed by the registration controllers. The clusterset label is not
+// reserved, it has its own authorization.
+func isReservedLabelKey(key string) bool {
+	if key == clusterSetLabel {
+		return false
+	}
+	slashIndex := strings.Index(key, "/")
+	if slashIndex < 0 {
+		return false
+	}
+	domain := key[:slashIndex]
+	return domain == reservedLabelDomain || strings.HasSuffix(domain, "."+reservedLabelDomain)
+}
+
+// validateIdentityKeys rejects an update that changes or removes the value of a
+// configured identity key on an accepted ManagedCluster. Identity keys correlate the
+// cluster with its spoke and must stay stable once registration completed; before the
+// cluster is accepted they may still be edited freely, and a value absent so far may be
+// set for the first time at any point.
+func (a *ManagedClusterValidatingAdmissionHook) validateIdentityKeys(oldManagedCluster, managedCluster *clusterv1.ManagedCluster) []error {
+	if len(a.ImmutableIdentityKeys) == 0 || !oldManagedCluster.Spec.HubAcceptsClient {
+		return nil
+	}
+
+	errs := []error{}
+	for _, key := range a.ImmutableIdentityKeys {
+		if err := validateIdentityKey("label", key, oldManagedCluster.Labels, managedCluster.Labels); err != nil {
+			errs = append(errs, err)
+		}
+		if err := validateIdentityKey("annotation", key, oldManagedCluster.Annotations, managedCluster.Annotations); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// validateIdentityKey checks a single identity key in the given old and new label or
+// annotation maps. A key the old object does not carry is not checked.
+func validateIdentityKey(kind, key string, oldValues, values map[string]string) error {
+	oldValue, ok := oldValues[key]
+	if !ok {
+		return nil
+	}
+	value, ok := values[key]
+	if !ok {
+		return fmt.Errorf("the identity %s %q cannot be removed after the cluster is accepted", kind, key)
+	}
+	if value != oldValue {
+		return fmt.Errorf("the identity %s %q cannot be changed after the cluster is accepted", kind, key)
+	}
+	return nil
+}
+
+// validateOwnerAnnotation rejects a new ManagedCluster without a non-empty owner
+// annotation when one is required. It is only applied to create requests, so legacy
+// clusters without the annotation can still be updated.
+func (a *ManagedClusterValidatingAdmissionHook) validateOwnerAnnotation(managedCluster *clusterv1.ManagedCluster) error {
+	if !a.RequireOwnerAnnotation {
+		return nil
+	}
+
+	annotationKey := a.OwnerAnnotationKey
+	if len(annotationKey) == 0 {
+		annotationKey = defaultOwnerAnnotationKey
+	}
+
+	if len(managedCluster.Annotations[annotationKey]) > 0 {
+		return nil
+	}
+	return fmt.Errorf("a non-empty %s annotation is required, set the annotation to the email or team owning the cluster", annotationKey)
+}
+
+// validateAnnotations checks the annotation values of a ManagedCluster against the
+// configured validation rules. Only annotations that are present and have a configured
+// validator are checked. An invalid validator expression is a configuration mistake and
+// also rejects the request, silently skipping the rule would let non-conforming values
+// through unnoticed.
+func (a *ManagedClusterValidatingAdmissionHook) validateAnnotations(managedCluster *clusterv1.ManagedCluster) []error {
+	if len(a.AnnotationValidators) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(a.AnnotationValidators))
+	for key := range a.AnnotationValidators {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	errs := []error{}
+	for _, key := range keys {
+		value, ok := managedCluster.Annotations[key]
+		if !ok {
+			continue
+		}
+		pattern := a.AnnotationValidators[key]
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("the validator of annotation %q is not a valid regular expression: %v", key, err))
+			continue
+		}
+		if !expression.MatchString(value) {
+			errs = append(errs, fmt.Errorf("the value %q of annotation %q does not match the configured rule %q", value, key, pattern))
+		}
+	}
+	return errs
+}
+
+// validateClusterNamespace rejects a new ManagedCluster whose name collides with an
+// existing namespace that was not created by the registration controllers. The hub
+// creates a namespace named after the cluster, so onboarding into a foreign namespace
+// would conflict with the unrelated resources in it. A namespace carrying the cluster
+// name label belongs to an earlier registration of the same cluster and is allowed.
+func (a *ManagedClusterValidatingAdmissionHook) validateClusterNamespace(ctx context.Context, managedCluster *clusterv1.ManagedCluster) *metav1.Status {
+	if !a.ready() {
+		return notReadyStatus()
+	}
+
+	namespace, err := a.kubeClient.CoreV1().Namespaces().Get(ctx, managedCluster.Name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return a.denialStatus(http.StatusInternalServerError, metav1.StatusReasonInternalError, "metadata.name",
+			fmt.Sprintf("unable to check the namespace %q: %v", managedCluster.Name, err))
+	}
+
+	if namespace.Labels[clusterNameLabel] == managedCluster.Name {
+		if namespace.DeletionTimestamp.IsZero() || a.AllowTerminatingClusterNamespace {
+			return nil
+		}
+		// without the clear denial a cluster created into a terminating namespace
+		// fails later with a confusing namespace creation error
+		return a.denialStatus(http.StatusConflict, metav1.StatusReasonConflict, "metadata.name",
+			fmt.Sprintf("the namespace %q of an earlier registration of this cluster is still terminating, retry the creation after the termination completed", managedCluster.Name))
+	}
+	return a.denialStatus(http.StatusConflict, metav1.StatusReasonConflict, "metadata.name",
+		fmt.Sprintf("the namespace %q already exists and is not owned by the cluster registration, a managed cluster cannot be created with that name", managedCluster.Name))
+}
+
+// validateClientConfigRemoval rejects an update that removes all client configs of an
+// accepted ManagedCluster, since without any client config the integrations on the hub
+// can no longer reach the cluster. Adding or removing individual client configs is still
+// allowed as long as at least one is kept.
+func validateClientConfigRemoval(managedCluster, oldManagedCluster *clusterv1.ManagedCluster) error {
+	if !managedCluster.Spec.HubAcceptsClient {
+		return nil
+	}
+	if len(managedCluster.Spec.ManagedClusterClientConfigs) > 0 {
+		return nil
+	}
+	if len(oldManagedCluster.Spec.ManagedClusterClientConfigs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("at least one client config is required for an accepted managed cluster, the client configs cannot all be removed")
+}
+
+// validateClientConfigCABundles returns an error for each client config whose CA bundle
+// contains certificates that are all already expired, which guarantees the connections of
+// the hub integrations to the cluster will fail. A bundle with at least one unexpired
+// certificate passes, its expired certificates are simply never used for verification.
+func validateClientConfigCABundles(managedCluster *clusterv1.ManagedCluster) []error {
+	errs := []error{}
+	now := time.Now()
+	for _, clientConfig := range managedCluster.Spec.ManagedClusterClientConfigs {
+		certs := parseCABundle(clientConfig.CABundle)
+		if len(certs) == 0 {
+			continue
+		}
+		expired := true
+		for _, cert := range certs {
+			if now.Before(cert.NotAfter) {
+				expired = false
+				break
+			}
+		}
+		if expired {
+			errs = append(errs, fmt.Errorf("all certificates in the CA bundle of client config %q are expired", clientConfig.URL))
+		}
+	}
+	return errs
+}
+
+// validateExternalCABundles returns an error for each client config that points at a
+// non-loopback host without a CA bundle, when the rule is enabled. An agent connecting to
+// such a URL falls back to the system roots for verification, which usually cannot
+// validate a self-signed hub apiserver certificate. Loopback and link-local URLs stay
+// exempt, local test setups commonly omit the CA bundle on purpose.
+func (a *ManagedClusterValidatingAdmissionHook) validateExternalCABundles(managedCluster *clusterv1.ManagedCluster) []error {
+	if !a.RequireExternalCABundle {
+		return nil
+	}
+
+	errs := []error{}
+	for _, clientConfig := range managedCluster.Spec.ManagedClusterClientConfigs {
+		if len(clientConfig.CABundle) > 0 {
+			continue
+		}
+		parsedURL, err := url.Parse(clientConfig.URL)
+		if err != nil {
+			// an unparseable URL is already rejected by the URL validation
+			continue
+		}
+		if isLoopbackOrLinkLocalHost(parsedURL.Hostname()) {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("a CA bundle is required for the external url %q in client configs", clientConfig.URL))
+	}
+	return errs
+}
+
+// isLoopbackOrLinkLocalHost returns true if the given host is localhost, a loopback IP or
+// a link-local IP.
+func isLoopbackOrLinkLocalHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast()
+}
+
+// parseCABundle returns the certificates in the given PEM encoded CA bundle. Blocks that
+// are not parseable certificates are skipped.
+func parseCABundle(caBundle []byte) []*x509.Certificate {
+	certs := []*x509.Certificate{}
+	for block, rest := pem.Decode(caBundle); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+	}
+	return certs
+}
+
+// withWarnings appends a warning with the given severity for each of the given errors to
+// the admission response.
+func withWarnings(status *admissionv1beta1.AdmissionResponse, severity warnings.Severity, errs []error) *admissionv1beta1.AdmissionResponse {
+	for _, err := range errs {
+		status = warnings.Append(status, severity, "%s", err.Error())
+	}
+	return status
+}
+
+// validateTaintCount checks the number of taints of a ManagedCluster does not exceed the
+// maximum allowed. An update which reduces an over-limit taint count is allowed so that an
+// over-limit cluster can still be brought back under the cap.
+func (a *ManagedClusterValidatingAdmissionHook) validateTaintCount(managedCluster, oldManagedCluster *clusterv1.ManagedCluster) error {
+	maxTaints := a.MaxTaints
+	if maxTaints <= 0 {
+		maxTaints = defaultMaxTaints
+	}
+
+	taintCount := len(managedCluster.Spec.Taints)
+	if taintCount <= maxTaints {
+		return nil
+	}
+
+	if oldManagedCluster != nil && taintCount < len(oldManagedCluster.Spec.Taints) {
+		return nil
+	}
+
+	return fmt.Errorf("the number of taints (%d) exceeds the maximum allowed (%d)", taintCount, maxTaints)
+}
+
+// validateAllowedTaintEffects checks every taint effect of a ManagedCluster against the
+// configured allowlist. A scheduler that does not know an effect yet silently ignores
+// it; with an allowlist the mismatch surfaces at admission time instead.
+func (a *ManagedClusterValidatingAdmissionHook) validateAllowedTaintEffects(managedCluster *clusterv1.ManagedCluster) error {
+	if len(a.AllowedTaintEffects) == 0 {
+		return nil
+	}
+
+	errs := []error{}
+	for index, taint := range managedCluster.Spec.Taints {
+		allowed := false
+		for _, effect := range a.AllowedTaintEffects {
+			if string(taint.Effect) == effect {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			errs = append(errs, fmt.Errorf("taint effect %q of taints[%d] is not in the allowed taint effects %v", taint.Effect, index, a.AllowedTaintEffects))
+		}
+	}
+
+	return operatorhelpers.NewMultiLineAggregate(errs)
+}
+
+// validateLabelCount checks the number of labels of a ManagedCluster does not exceed the
+// maximum allowed. An update which reduces an over-limit label count is allowed so that an
+// over-limit cluster can still be brought back under the cap.
+func (a *ManagedClusterValidatingAdmissionHook) validateLabelCount(managedCluster, oldManagedCluster *clusterv1.ManagedCluster) error {
+	maxLabels := a.MaxLabels
+	if maxLabels <= 0 {
+		maxLabels = defaultMaxLabels
+	}
+
+	labelCount := a.countedLabels(managedCluster)
+	if labelCount <= maxLabels {
+		return nil
+	}
+
+	if oldManagedCluster != nil && labelCount < a.countedLabels(oldManagedCluster) {
+		return nil
+	}
+
+	return fmt.Errorf("the number of labels (%d) exceeds the maximum allowed (%d)", labelCount, maxLabels)
+}
+
+// countedLabels returns the number of labels counted against the maximum. The labels
+// managed by the registration controllers, including the clusterset label, are excluded
+// when configured, users cannot remove them to get back under the cap anyway.
+func (a *ManagedClusterValidatingAdmissionHook) countedLabels(managedCluster *clusterv1.ManagedCluster) int {
+	count := 0
+	for key := range managedCluster.Labels {
+		if a.ExcludeReservedLabelsFromCount && (key == clusterSetLabel || isReservedLabelKey(key)) {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// isReservedClusterName returns true if the given cluster name is one of the configured
+// reserved cluster names.
+func (a *ManagedClusterValidatingAdmissionHook) isReservedClusterName(name string) bool {
+	for _, reservedName := range a.ReservedClusterNames {
+		if name == reservedName {
+			return true
+		}
+	}
+	return false
+}
+
+// newSubjectAccessReview builds the SubjectAccessReview for an authorization check. By
+// default the check is evaluated for the request user; with an impersonated authorization
+// identity configured, it is evaluated for that identity instead.
+func (a *ManagedClusterValidatingAdmissionHook) newSubjectAccessReview(userInfo authenticationv1.UserInfo, resourceAttributes *authorizationv1.ResourceAttributes) *authorizationv1.SubjectAccessReview {
+	if a.ImpersonateAuthorizationIdentity {
+		return &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:               a.AuthorizationIdentityUser,
+				Groups:             a.AuthorizationIdentityGroups,
+				ResourceAttributes: resourceAttributes,
+			},
+		}
+	}
 
 	extra := make(map[string]authorizationv1.ExtraValue)
 	for k, v := range userInfo.Extra {
 		extra[k] = authorizationv1.ExtraValue(v)
 	}
-
-	sar := &authorizationv1.SubjectAccessReview{
+	return &authorizationv1.SubjectAccessReview{
 		Spec: authorizationv1.SubjectAccessReviewSpec{
-			User:   userInfo.Username,
-			UID:    userInfo.UID,
-			Groups: userInfo.Groups,
-			Extra:  extra,
-			ResourceAttributes: &authorizationv1.ResourceAttributes{
-				Group:       "register.open-cluster-management.io",
-				Resource:    "managedclusters",
-				Verb:        "update",
-				Subresource: "accept",
-				Name:        clusterName,
-			},
+			User:               userInfo.Username,
+			UID:                userInfo.UID,
+			Groups:             userInfo.Groups,
+			Extra:              extra,
+			ResourceAttributes: resourceAttributes,
 		},
 	}
-	sar, err := a.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), sar, metav1.CreateOptions{})
+}
+
+// freezeWindow is a parsed creation freeze window.
+type freezeWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// activeFreezeWindow returns the configured creation freeze window containing the given
+// time, or nil when no freeze is active. A window that cannot be parsed is skipped with
+// a warning, a misconfigured schedule must not block all onboarding.
+func (a *ManagedClusterValidatingAdmissionHook) activeFreezeWindow(now time.Time) *freezeWindow {
+	for _, window := range a.CreationFreezeWindows {
+		parts := strings.Split(window, "/")
+		if len(parts) != 2 {
+			klog.Warningf("skipping malformed creation freeze window %q", window)
+			continue
+		}
+		start, err := time.Parse(time.RFC3339, parts[0])
+		if err != nil {
+			klog.Warningf("skipping creation freeze window %q with an invalid start time: %v", window, err)
+			continue
+		}
+		end, err := time.Parse(time.RFC3339, parts[1])
+		if err != nil {
+			klog.Warningf("skipping creation freeze window %q with an invalid end time: %v", window, err)
+			continue
+		}
+		if !now.Before(start) && now.Before(end) {
+			return &freezeWindow{start: start, end: end}
+		}
+	}
+	return nil
+}
+
+// allowFrozenCreation handles a create request during an active creation freeze. The
+// request is rejected unless the cluster carries the freeze override annotation and the
+// user holds the create permission on the freeze override subresource.
+func (a *ManagedClusterValidatingAdmissionHook) allowFrozenCreation(ctx context.Context, managedCluster *clusterv1.ManagedCluster, userInfo authenticationv1.UserInfo, window *freezeWindow) *admissionv1beta1.AdmissionResponse {
+	status := &admissionv1beta1.AdmissionResponse{}
+
+	overrideAnnotation := a.FreezeOverrideAnnotation
+	if len(overrideAnnotation) == 0 {
+		overrideAnnotation = defaultFreezeOverrideAnnotation
+	}
+	if managedCluster.Annotations[overrideAnnotation] != "true" {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "",
+			fmt.Sprintf("creating a ManagedCluster is frozen until %s, retry after the freeze ended or request a freeze override",
+				window.end.UTC().Format(time.RFC3339)))
+		return status
+	}
+
+	if !a.ready() {
+		status.Allowed = false
+		status.Result = notReadyStatus()
+		return status
+	}
+
+	subresource := a.FreezeOverrideSubresource
+	if len(subresource) == 0 {
+		subresource = defaultFreezeOverrideSubresource
+	}
+
+	sar := a.newSubjectAccessReview(userInfo, &authorizationv1.ResourceAttributes{
+		Group:       "cluster.open-cluster-management.io",
+		Resource:    "managedclusters",
+		Subresource: subresource,
+		Verb:        "create",
+		Name:        managedCluster.Name,
+	})
+	sarCtx, sarSpan := tracing.StartSpan(ctx, "SubjectAccessReview")
+	sarStart := time.Now()
+	sar, err := a.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(sarCtx, sar, metav1.CreateOptions{})
+	tracing.EndSpan(sarSpan, err)
+	webhookmetrics.ObserveSubjectAccessReview("managedclusters", sarStart, err, err == nil && sar.Status.Allowed)
 	if err != nil {
 		status.Allowed = false
-		status.Result = &metav1.Status{
-			Status: metav1.StatusFailure, Code: http.StatusForbidden, Reason: metav1.StatusReasonForbidden,
-			Message: err.Error(),
-		}
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.annotations", err.Error())
 		return status
 	}
 
 	if !sar.Status.Allowed {
 		status.Allowed = false
-		status.Result = &metav1.Status{
-			Status: metav1.StatusFailure, Code: http.StatusForbidden, Reason: metav1.StatusReasonForbidden,
-			Message: fmt.Sprintf("user %q cannot update the HubAcceptsClient field", userInfo.Username),
-		}
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.annotations",
+			fmt.Sprintf("user %q is not allowed to override the creation freeze", userInfo.Username))
+		return status
+	}
+
+	status.Allowed = true
+	return status
+}
+
+// allowReservedClusterName checks if the user has permission to create a cluster with a
+// reserved name, checked on the configured subresource.
+func (a *ManagedClusterValidatingAdmissionHook) allowReservedClusterName(ctx context.Context, clusterName string, userInfo authenticationv1.UserInfo) *admissionv1beta1.AdmissionResponse {
+	status := &admissionv1beta1.AdmissionResponse{}
+
+	if !a.ready() {
+		status.Allowed = false
+		status.Result = notReadyStatus()
+		return status
+	}
+
+	subresource := a.ReservedNameSubresource
+	if len(subresource) == 0 {
+		subresource = defaultReservedNameSubresource
+	}
+
+	sar := a.newSubjectAccessReview(userInfo, &authorizationv1.ResourceAttributes{
+		Group:       "cluster.open-cluster-management.io",
+		Resource:    "managedclusters",
+		Subresource: subresource,
+		Verb:        "create",
+		Name:        clusterName,
+	})
+	sarCtx, sarSpan := tracing.StartSpan(ctx, "SubjectAccessReview")
+	sarStart := time.Now()
+	sar, err := a.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(sarCtx, sar, metav1.CreateOptions{})
+	tracing.EndSpan(sarSpan, err)
+	webhookmetrics.ObserveSubjectAccessReview("managedclusters", sarStart, err, err == nil && sar.Status.Allowed)
+	if err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.name", err.Error())
+		return status
+	}
+
+	if !sar.Status.Allowed {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.name",
+			fmt.Sprintf("user %q is not allowed to create the managed cluster with the reserved name %q", userInfo.Username, clusterName))
+		return status
+	}
+
+	status.Allowed = true
+	return status
+}
+
+// allowUpdateHubAcceptsClientField using SubjectAccessReview API to check whether a request user has been authorized to update
+// HubAcceptsClient field
+func (a *ManagedClusterValidatingAdmissionHook) allowUpdateAcceptField(ctx context.Context, clusterName string, userInfo authenticationv1.UserInfo) *admissionv1beta1.AdmissionResponse {
+	status := &admissionv1beta1.AdmissionResponse{}
+
+	if !a.ready() {
+		status.Allowed = false
+		status.Result = notReadyStatus()
+		return status
+	}
+
+	group := a.AcceptCheckGroup
+	if len(group) == 0 {
+		group = defaultAcceptCheckGroup
+	}
+	resource := a.AcceptCheckResource
+	if len(resource) == 0 {
+		resource = defaultAcceptCheckResource
+	}
+	subresource := a.AcceptCheckSubresource
+	if len(subresource) == 0 {
+		subresource = defaultAcceptCheckSubresource
+	}
+
+	sar := a.newSubjectAccessReview(userInfo, &authorizationv1.ResourceAttributes{
+		Group:       group,
+		Resource:    resource,
+		Verb:        "update",
+		Subresource: subresource,
+		Name:        clusterName,
+	})
+	sarCtx, sarSpan := tracing.StartSpan(ctx, "SubjectAccessReview")
+	sarStart := time.Now()
+	sar, err := a.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(sarCtx, sar, metav1.CreateOptions{})
+	tracing.EndSpan(sarSpan, err)
+	webhookmetrics.ObserveSubjectAccessReview(resource, sarStart, err, err == nil && sar.Status.Allowed)
+	if err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "spec.hubAcceptsClient", err.Error())
+		return status
+	}
+
+	if !sar.Status.Allowed {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "spec.hubAcceptsClient",
+			fmt.Sprintf("user %q cannot update the HubAcceptsClient field", userInfo.Username))
 		return status
 	}
 
@@ -223,19 +1615,19 @@ func (a *ManagedClusterValidatingAdmissionHook) allowUpdateAcceptField(clusterNa
 }
 
 // allowSetClusterSetLabel checks whether a request user has been authorized to set clusterset label
-func (a *ManagedClusterValidatingAdmissionHook) allowSetClusterSetLabel(userInfo authenticationv1.UserInfo, originalClusterSet, newClusterSet string) *admissionv1beta1.AdmissionResponse {
+func (a *ManagedClusterValidatingAdmissionHook) allowSetClusterSetLabel(ctx context.Context, userInfo authenticationv1.UserInfo, originalClusterSet, newClusterSet string) *admissionv1beta1.AdmissionResponse {
 	if originalClusterSet == newClusterSet {
 		return &admissionv1beta1.AdmissionResponse{Allowed: true}
 	}
 
 	if len(originalClusterSet) > 0 {
-		if status := a.allowUpdateClusterSet(userInfo, originalClusterSet); !status.Allowed {
+		if status := a.allowUpdateClusterSet(ctx, userInfo, originalClusterSet); !status.Allowed {
 			return status
 		}
 	}
 
 	if len(newClusterSet) > 0 {
-		if status := a.allowUpdateClusterSet(userInfo, newClusterSet); !status.Allowed {
+		if status := a.allowUpdateClusterSet(ctx, userInfo, newClusterSet); !status.Allowed {
 			return status
 		}
 	}
@@ -247,48 +1639,93 @@ func (a *ManagedClusterValidatingAdmissionHook) allowSetClusterSetLabel(userInfo
 
 // allowUpdateClusterSet checks whether a request user has been authorized to add/remove a ManagedCluster
 // to/from the ManagedClusterSet
-func (a *ManagedClusterValidatingAdmissionHook) allowUpdateClusterSet(userInfo authenticationv1.UserInfo, clusterSetName string) *admissionv1beta1.AdmissionResponse {
+func (a *ManagedClusterValidatingAdmissionHook) allowUpdateClusterSet(ctx context.Context, userInfo authenticationv1.UserInfo, clusterSetName string) *admissionv1beta1.AdmissionResponse {
 	status := &admissionv1beta1.AdmissionResponse{}
 
-	extra := make(map[string]authorizationv1.ExtraValue)
-	for k, v := range userInfo.Extra {
-		extra[k] = authorizationv1.ExtraValue(v)
+	if !a.ready() {
+		status.Allowed = false
+		status.Result = notReadyStatus()
+		return status
 	}
 
-	sar := &authorizationv1.SubjectAccessReview{
-		Spec: authorizationv1.SubjectAccessReviewSpec{
-			User:   userInfo.Username,
-			UID:    userInfo.UID,
-			Groups: userInfo.Groups,
-			Extra:  extra,
-			ResourceAttributes: &authorizationv1.ResourceAttributes{
-				Group:       "cluster.open-cluster-management.io",
-				Resource:    "managedclustersets",
-				Subresource: "join",
-				Name:        clusterSetName,
-				Verb:        "create",
-			},
-		},
-	}
-	sar, err := a.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), sar, metav1.CreateOptions{})
+	sar := a.newSubjectAccessReview(userInfo, &authorizationv1.ResourceAttributes{
+		Group:       "cluster.open-cluster-management.io",
+		Resource:    "managedclustersets",
+		Subresource: "join",
+		Name:        clusterSetName,
+		Verb:        "create",
+	})
+	sarCtx, sarSpan := tracing.StartSpan(ctx, "SubjectAccessReview")
+	sarStart := time.Now()
+	sar, err := a.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(sarCtx, sar, metav1.CreateOptions{})
+	tracing.EndSpan(sarSpan, err)
+	webhookmetrics.ObserveSubjectAccessReview("managedclustersets", sarStart, err, err == nil && sar.Status.Allowed)
 	if err != nil {
 		status.Allowed = false
-		status.Result = &metav1.Status{
-			Status: metav1.StatusFailure, Code: http.StatusForbidden, Reason: metav1.StatusReasonForbidden,
-			Message: err.Error(),
-		}
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.labels", err.Error())
 		return status
 	}
 
 	if !sar.Status.Allowed {
 		status.Allowed = false
-		status.Result = &metav1.Status{
-			Status: metav1.StatusFailure, Code: http.StatusForbidden, Reason: metav1.StatusReasonForbidden,
-			Message: fmt.Sprintf("user %q cannot add/remove a ManagedCluster to/from ManagedClusterSet %q", userInfo.Username, clusterSetName),
-		}
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.labels",
+			fmt.Sprintf("user %q cannot add/remove a ManagedCluster to/from ManagedClusterSet %q", userInfo.Username, clusterSetName))
 		return status
 	}
 
 	status.Allowed = true
 	return status
 }
+
+// evaluatePolicy runs the configured admission policy, if any, after the built-in checks
+// allowed the request. A policy violation denies the request with the policy's message. An
+// evaluation failure fails the request closed; a broken policy is disabled by removing the
+// evaluator configuration, the built-in checks are not affected by it.
+func (a *ManagedClusterValidatingAdmissionHook) evaluatePolicy(status *admissionv1beta1.AdmissionResponse, oldCluster, cluster *clusterv1.ManagedCluster, userInfo authenticationv1.UserInfo) *admissionv1beta1.AdmissionResponse {
+	if a.PolicyEvaluator == nil || !status.Allowed {
+		return status
+	}
+
+	decision, err := a.PolicyEvaluator(oldCluster, cluster, userInfo)
+	if err != nil {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusInternalServerError, metav1.StatusReasonInternalError, "",
+			fmt.Sprintf("the admission policy failed to evaluate: %v", err))
+		return status
+	}
+	if !decision.Allowed {
+		status.Allowed = false
+		status.Result = a.denialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "", decision.Message)
+	}
+	return status
+}
+
+// denialStatus returns a failure status for an admission denial, honoring a configured
+// status code override for the denial reason.
+func (a *ManagedClusterValidatingAdmissionHook) denialStatus(code int32, reason metav1.StatusReason, field, message string) *metav1.Status {
+	if override, ok := a.DenialStatusCodes[string(reason)]; ok {
+		code = int32(override)
+	}
+	return newDenialStatus(code, reason, field, message)
+}
+
+// newDenialStatus returns a failure status for an admission denial. Besides the human readable
+// message, the denial is encoded in the status details as a machine readable cause, so clients
+// that parse structured admission errors do not need to parse the message.
+func newDenialStatus(code int32, reason metav1.StatusReason, field, message string) *metav1.Status {
+	return &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    code,
+		Reason:  reason,
+		Message: message,
+		Details: &metav1.StatusDetails{
+			Causes: []metav1.StatusCause{
+				{
+					Type:    metav1.CauseType(reason),
+					Field:   field,
+					Message: message,
+				},
+			},
+		},
+	}
+}
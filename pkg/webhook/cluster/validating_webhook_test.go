@@ -1,18 +1,29 @@
 package cluster
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	testinghelpers "open-cluster-management.io/registration/pkg/helpers/testing"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubefake "k8s.io/client-go/kubernetes/fake"
@@ -76,10 +87,19 @@ func TestManagedClusterValidate(t *testing.T) {
 			},
 			expectedResponse: &admissionv1beta1.AdmissionResponse{
 				Allowed: false,
-				Result: &metav1.Status{
-					Status: metav1.StatusFailure, Code: http.StatusBadRequest, Reason: metav1.StatusReasonBadRequest,
-					Message: "url \"http://127.0.0.1:8001\" is invalid in client configs",
-				},
+				Result:  newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec", "url \"http://127.0.0.1:8001\" is invalid in client configs"),
+			},
+		},
+		{
+			name: "validate creating ManagedCluster with a taint with empty key",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithTaints(clusterv1.Taint{Key: "a", Effect: clusterv1.TaintEffectNoSelect}, clusterv1.Taint{Effect: clusterv1.TaintEffectNoSelect}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result:  newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec", "taint key of taints[1] is empty"),
 			},
 		},
 		{
@@ -92,10 +112,7 @@ func TestManagedClusterValidate(t *testing.T) {
 			},
 			expectedResponse: &admissionv1beta1.AdmissionResponse{
 				Allowed: false,
-				Result: &metav1.Status{
-					Status: metav1.StatusFailure, Code: http.StatusForbidden, Reason: metav1.StatusReasonForbidden,
-					Message: "user \"tester\" cannot update the HubAcceptsClient field",
-				},
+				Result:  newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "spec.hubAcceptsClient", "user \"tester\" cannot update the HubAcceptsClient field"),
 			},
 		},
 		{
@@ -133,10 +150,7 @@ func TestManagedClusterValidate(t *testing.T) {
 			},
 			expectedResponse: &admissionv1beta1.AdmissionResponse{
 				Allowed: false,
-				Result: &metav1.Status{
-					Status: metav1.StatusFailure, Code: http.StatusForbidden, Reason: metav1.StatusReasonForbidden,
-					Message: "user \"tester\" cannot update the HubAcceptsClient field",
-				},
+				Result:  newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "spec.hubAcceptsClient", "user \"tester\" cannot update the HubAcceptsClient field"),
 			},
 		},
 		{
@@ -175,10 +189,7 @@ func TestManagedClusterValidate(t *testing.T) {
 			},
 			expectedResponse: &admissionv1beta1.AdmissionResponse{
 				Allowed: false,
-				Result: &metav1.Status{
-					Status: metav1.StatusFailure, Code: http.StatusForbidden, Reason: metav1.StatusReasonForbidden,
-					Message: "user \"\" cannot add/remove a ManagedCluster to/from ManagedClusterSet \"clusterset1\"",
-				},
+				Result:  newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.labels", "user \"\" cannot add/remove a ManagedCluster to/from ManagedClusterSet \"clusterset1\""),
 			},
 			allowUpdateClusterSets: map[string]bool{
 				"clusterset1": false,
@@ -210,10 +221,7 @@ func TestManagedClusterValidate(t *testing.T) {
 			},
 			expectedResponse: &admissionv1beta1.AdmissionResponse{
 				Allowed: false,
-				Result: &metav1.Status{
-					Status: metav1.StatusFailure, Code: http.StatusForbidden, Reason: metav1.StatusReasonForbidden,
-					Message: "user \"\" cannot add/remove a ManagedCluster to/from ManagedClusterSet \"clusterset1\"",
-				},
+				Result:  newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.labels", "user \"\" cannot add/remove a ManagedCluster to/from ManagedClusterSet \"clusterset1\""),
 			},
 		},
 		{
@@ -226,22 +234,19 @@ func TestManagedClusterValidate(t *testing.T) {
 			},
 			expectedResponse: &admissionv1beta1.AdmissionResponse{
 				Allowed: false,
-				Result: &metav1.Status{
-					Status: metav1.StatusFailure, Code: http.StatusForbidden, Reason: metav1.StatusReasonForbidden,
-					Message: "user \"\" cannot add/remove a ManagedCluster to/from ManagedClusterSet \"clusterset2\"",
-				},
+				Result:  newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.labels", "user \"\" cannot add/remove a ManagedCluster to/from ManagedClusterSet \"clusterset2\""),
 			},
 			allowUpdateClusterSets: map[string]bool{
 				"clusterset1": true,
 			},
 		},
 		{
-			name: "validate resetting clusterset label",
+			name: "validate removing clusterset label",
 			request: &admissionv1beta1.AdmissionRequest{
 				Resource:  managedclustersSchema,
 				Operation: admissionv1beta1.Update,
 				OldObject: newManagedClusterObjWithClientSet("clusterset1"),
-				Object:    newManagedClusterObjWithClientSet(""),
+				Object:    newManagedClusterObj(),
 			},
 			expectedResponse: &admissionv1beta1.AdmissionResponse{
 				Allowed: true,
@@ -277,7 +282,7 @@ func TestManagedClusterValidate(t *testing.T) {
 				},
 			)
 
-			admissionHook := &ManagedClusterValidatingAdmissionHook{kubeClient: kubeClient}
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubeClient)
 
 			actualResponse := admissionHook.Validate(c.request)
 
@@ -288,47 +293,2418 @@ func TestManagedClusterValidate(t *testing.T) {
 	}
 }
 
-func newManagedClusterObj() runtime.RawExtension {
-	managedCluster := testinghelpers.NewManagedCluster()
-	clusterObj, _ := json.Marshal(managedCluster)
-	return runtime.RawExtension{
-		Raw: clusterObj,
+func TestManagedClusterValidateTaintCount(t *testing.T) {
+	newTaints := func(count int) []clusterv1.Taint {
+		taints := []clusterv1.Taint{}
+		for i := 0; i < count; i++ {
+			taints = append(taints, clusterv1.Taint{Key: fmt.Sprintf("key-%d", i), Effect: clusterv1.TaintEffectNoSelect})
+		}
+		return taints
 	}
-}
 
-func newManagedClusterObjWithHubAcceptsClient(hubAcceptsClient bool) runtime.RawExtension {
-	managedCluster := testinghelpers.NewManagedCluster()
-	managedCluster.Spec.HubAcceptsClient = hubAcceptsClient
-	clusterObj, _ := json.Marshal(managedCluster)
-	return runtime.RawExtension{
-		Raw: clusterObj,
+	cases := []struct {
+		name             string
+		maxTaints        int
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "validate creating ManagedCluster with taint count at the default cap",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithTaints(newTaints(16)...),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate creating ManagedCluster with taint count over the default cap",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithTaints(newTaints(17)...),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result:  newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.taints", "the number of taints (17) exceeds the maximum allowed (16)"),
+			},
+		},
+		{
+			name:      "validate creating ManagedCluster with taint count over the configured cap",
+			maxTaints: 2,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithTaints(newTaints(3)...),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result:  newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.taints", "the number of taints (3) exceeds the maximum allowed (2)"),
+			},
+		},
+		{
+			name:      "validate updating ManagedCluster with taint count kept over the cap",
+			maxTaints: 2,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				OldObject: newManagedClusterObjWithTaints(newTaints(3)...),
+				Object:    newManagedClusterObjWithTaints(newTaints(3)...),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result:  newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.taints", "the number of taints (3) exceeds the maximum allowed (2)"),
+			},
+		},
+		{
+			name:      "validate updating ManagedCluster with taint count reduced but still over the cap",
+			maxTaints: 2,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				OldObject: newManagedClusterObjWithTaints(newTaints(4)...),
+				Object:    newManagedClusterObjWithTaints(newTaints(3)...),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := &ManagedClusterValidatingAdmissionHook{
+				kubeClient: kubefake.NewSimpleClientset(),
+				MaxTaints:  c.maxTaints,
+			}
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
 	}
 }
 
-func newManagedClusterObjWithClientConfigs(clientConfig clusterv1.ClientConfig) runtime.RawExtension {
-	managedCluster := testinghelpers.NewManagedCluster()
-	managedCluster.Spec.ManagedClusterClientConfigs = []clusterv1.ClientConfig{clientConfig}
-	clusterObj, _ := json.Marshal(managedCluster)
-	return runtime.RawExtension{
-		Raw: clusterObj,
+func TestManagedClusterValidateTaintEffects(t *testing.T) {
+	cases := []struct {
+		name             string
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "validate taints with distinct keys",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedClusterObjWithTaints(
+					clusterv1.Taint{Key: "a", Effect: clusterv1.TaintEffectNoSelect},
+					clusterv1.Taint{Key: "b", Effect: clusterv1.TaintEffectPreferNoSelect},
+				),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate NoSelect combined with PreferNoSelect on the same key",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedClusterObjWithTaints(
+					clusterv1.Taint{Key: "a", Effect: clusterv1.TaintEffectNoSelect},
+					clusterv1.Taint{Key: "a", Value: "b", Effect: clusterv1.TaintEffectPreferNoSelect},
+				),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec",
+					"taints with key \"a\" have conflicting effects, NoSelect cannot be combined with PreferNoSelect or NoSelectIfNew"),
+			},
+		},
+		{
+			name: "validate NoSelect combined with NoSelectIfNew on the same key",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedClusterObjWithTaints(
+					clusterv1.Taint{Key: "a", Effect: clusterv1.TaintEffectNoSelectIfNew},
+					clusterv1.Taint{Key: "a", Value: "b", Effect: clusterv1.TaintEffectNoSelect},
+				),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec",
+					"taints with key \"a\" have conflicting effects, NoSelect cannot be combined with PreferNoSelect or NoSelectIfNew"),
+			},
+		},
+		{
+			name: "validate PreferNoSelect combined with NoSelectIfNew on the same key",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedClusterObjWithTaints(
+					clusterv1.Taint{Key: "a", Effect: clusterv1.TaintEffectPreferNoSelect},
+					clusterv1.Taint{Key: "a", Value: "b", Effect: clusterv1.TaintEffectNoSelectIfNew},
+				),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubefake.NewSimpleClientset())
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
 	}
 }
 
-func newManagedClusterObjWithClientSet(clusterSetName string) runtime.RawExtension {
-	managedCluster := testinghelpers.NewManagedCluster()
-	managedCluster.Labels = map[string]string{
-		clusterSetLabel: clusterSetName,
+func TestManagedClusterValidateAllowedTaintEffects(t *testing.T) {
+	cases := []struct {
+		name             string
+		allowedEffects   []string
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name:           "validate a taint with an allowed effect",
+			allowedEffects: []string{"NoSelect"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithTaints(clusterv1.Taint{Key: "a", Effect: clusterv1.TaintEffectNoSelect}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:           "validate a taint with a disallowed effect",
+			allowedEffects: []string{"NoSelect"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithTaints(clusterv1.Taint{Key: "a", Effect: clusterv1.TaintEffectPreferNoSelect}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.taints",
+					"taint effect \"PreferNoSelect\" of taints[0] is not in the allowed taint effects [NoSelect]"),
+			},
+		},
+		{
+			name:           "validate updating a taint to a disallowed effect",
+			allowedEffects: []string{"NoSelect"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				OldObject: newManagedClusterObjWithTaints(clusterv1.Taint{Key: "a", Effect: clusterv1.TaintEffectNoSelect}),
+				Object:    newManagedClusterObjWithTaints(clusterv1.Taint{Key: "a", Effect: clusterv1.TaintEffectNoSelectIfNew}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.taints",
+					"taint effect \"NoSelectIfNew\" of taints[0] is not in the allowed taint effects [NoSelect]"),
+			},
+		},
+		{
+			name: "validate a taint without a configured allowlist",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithTaints(clusterv1.Taint{Key: "a", Effect: clusterv1.TaintEffectPreferNoSelect}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
 	}
-	clusterObj, _ := json.Marshal(managedCluster)
-	return runtime.RawExtension{
-		Raw: clusterObj,
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubefake.NewSimpleClientset())
+			admissionHook.AllowedTaintEffects = c.allowedEffects
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
 	}
 }
 
-func newManagedClusterSet(name string) *clusterv1beta1.ManagedClusterSet {
-	return &clusterv1beta1.ManagedClusterSet{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
+func TestManagedClusterValidateClusterSetLabel(t *testing.T) {
+	cases := []struct {
+		name             string
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "validate creating ManagedCluster without clusterset label",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObj(),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate creating ManagedCluster with a valid clusterset label",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientSet("clusterset1"),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
 		},
+		{
+			name: "validate creating ManagedCluster with an empty clusterset label value",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientSet(""),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.labels",
+					"the cluster.open-cluster-management.io/clusterset label must not have an empty value, remove the label instead"),
+			},
+		},
+		{
+			name: "validate updating ManagedCluster with an empty clusterset label value",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				OldObject: newManagedClusterObjWithClientSet("clusterset1"),
+				Object:    newManagedClusterObjWithClientSet(""),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.labels",
+					"the cluster.open-cluster-management.io/clusterset label must not have an empty value, remove the label instead"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: true,
+						},
+					}, nil
+				},
+			)
+
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubeClient)
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateClientConfigPort(t *testing.T) {
+	cases := []struct {
+		name             string
+		allowedPorts     []int
+		deniedPorts      []int
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "validate client config url without port restrictions",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://127.0.0.1:22"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:         "validate client config url with an allowed port",
+			allowedPorts: []int{443, 6443, 8443},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://127.0.0.1:8443"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:         "validate client config url without an explicit port",
+			allowedPorts: []int{443, 6443, 8443},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://127.0.0.1"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:         "validate client config url with a port not in the allowlist",
+			allowedPorts: []int{443, 6443, 8443},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://127.0.0.1:22"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result:  newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec", "port 22 of url \"https://127.0.0.1:22\" is not allowed in client configs"),
+			},
+		},
+		{
+			name:        "validate client config url with a denied port",
+			deniedPorts: []int{0, 22},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://127.0.0.1:22"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result:  newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec", "port 22 of url \"https://127.0.0.1:22\" is denied in client configs"),
+			},
+		},
+		{
+			name:        "validate client config url with a port not in the denylist",
+			deniedPorts: []int{0, 22},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://127.0.0.1:8443"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := &ManagedClusterValidatingAdmissionHook{
+				kubeClient:               kubefake.NewSimpleClientset(),
+				AllowedClientConfigPorts: c.allowedPorts,
+				DeniedClientConfigPorts:  c.deniedPorts,
+			}
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateReservedLabels(t *testing.T) {
+	cases := []struct {
+		name             string
+		allowedUsers     []string
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "validate creating ManagedCluster with a reserved label without a configured allowlist",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithLabels(map[string]string{"cluster.open-cluster-management.io/foo": "bar"}),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:         "validate creating ManagedCluster with a reserved label by an ordinary user",
+			allowedUsers: []string{"system:serviceaccount:open-cluster-management:registration-controller-sa"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithLabels(map[string]string{"cluster.open-cluster-management.io/foo": "bar"}),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.labels",
+					"user \"tester\" is not allowed to set or modify the reserved labels \"cluster.open-cluster-management.io/foo\""),
+			},
+		},
+		{
+			name:         "validate creating ManagedCluster with a reserved label by an allowlisted user",
+			allowedUsers: []string{"system:serviceaccount:open-cluster-management:registration-controller-sa"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithLabels(map[string]string{"cluster.open-cluster-management.io/foo": "bar"}),
+				UserInfo:  authenticationv1.UserInfo{Username: "system:serviceaccount:open-cluster-management:registration-controller-sa"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:         "validate updating ManagedCluster modifying a reserved label",
+			allowedUsers: []string{"system:serviceaccount:open-cluster-management:registration-controller-sa"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObjWithLabels(map[string]string{"clusterset.open-cluster-management.io/mcs1": "modified"}),
+				OldObject: newManagedClusterObjWithLabels(map[string]string{"clusterset.open-cluster-management.io/mcs1": ""}),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.labels",
+					"user \"tester\" is not allowed to set or modify the reserved labels \"clusterset.open-cluster-management.io/mcs1\""),
+			},
+		},
+		{
+			name:         "validate updating ManagedCluster removing a reserved label",
+			allowedUsers: []string{"system:serviceaccount:open-cluster-management:registration-controller-sa"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObj(),
+				OldObject: newManagedClusterObjWithLabels(map[string]string{"open-cluster-management.io/foo": "bar"}),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.labels",
+					"user \"tester\" is not allowed to set or modify the reserved labels \"open-cluster-management.io/foo\""),
+			},
+		},
+		{
+			name:         "validate updating ManagedCluster keeping a reserved label unchanged",
+			allowedUsers: []string{"system:serviceaccount:open-cluster-management:registration-controller-sa"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObjWithLabels(map[string]string{"cluster.open-cluster-management.io/foo": "bar", "env": "dev"}),
+				OldObject: newManagedClusterObjWithLabels(map[string]string{"cluster.open-cluster-management.io/foo": "bar"}),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:         "validate creating ManagedCluster with a non-reserved label",
+			allowedUsers: []string{"system:serviceaccount:open-cluster-management:registration-controller-sa"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithLabels(map[string]string{"example.com/foo": "bar", "env": "dev"}),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{Allowed: true},
+					}, nil
+				},
+			)
+
+			admissionHook := &ManagedClusterValidatingAdmissionHook{
+				kubeClient:                kubeClient,
+				ReservedLabelAllowedUsers: c.allowedUsers,
+			}
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateReservedTaints(t *testing.T) {
+	cases := []struct {
+		name             string
+		reservedPrefixes []string
+		allowedUsers     []string
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name:             "validate creating ManagedCluster with a reserved taint by an ordinary user",
+			reservedPrefixes: []string{"cluster.open-cluster-management.io/"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithTaints(clusterv1.Taint{Key: "cluster.open-cluster-management.io/unreachable", Effect: clusterv1.TaintEffectNoSelect}),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "spec.taints",
+					"user \"tester\" is not allowed to set or modify the reserved taints \"cluster.open-cluster-management.io/unreachable\""),
+			},
+		},
+		{
+			name:             "validate creating ManagedCluster with a reserved taint by an allowlisted user",
+			reservedPrefixes: []string{"cluster.open-cluster-management.io/"},
+			allowedUsers:     []string{"system:serviceaccount:open-cluster-management:registration-controller-sa"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithTaints(clusterv1.Taint{Key: "cluster.open-cluster-management.io/unreachable", Effect: clusterv1.TaintEffectNoSelect}),
+				UserInfo:  authenticationv1.UserInfo{Username: "system:serviceaccount:open-cluster-management:registration-controller-sa"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:             "validate updating ManagedCluster removing a reserved taint",
+			reservedPrefixes: []string{"cluster.open-cluster-management.io/"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObj(),
+				OldObject: newManagedClusterObjWithTaints(clusterv1.Taint{Key: "cluster.open-cluster-management.io/unreachable", Effect: clusterv1.TaintEffectNoSelect}),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "spec.taints",
+					"user \"tester\" is not allowed to set or modify the reserved taints \"cluster.open-cluster-management.io/unreachable\""),
+			},
+		},
+		{
+			name:             "validate updating ManagedCluster keeping a reserved taint unchanged",
+			reservedPrefixes: []string{"cluster.open-cluster-management.io/"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object: newManagedClusterObjWithTaints(
+					clusterv1.Taint{Key: "cluster.open-cluster-management.io/unreachable", Effect: clusterv1.TaintEffectNoSelect},
+					clusterv1.Taint{Key: "example.com/maintenance", Effect: clusterv1.TaintEffectPreferNoSelect},
+				),
+				OldObject: newManagedClusterObjWithTaints(clusterv1.Taint{Key: "cluster.open-cluster-management.io/unreachable", Effect: clusterv1.TaintEffectNoSelect}),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:             "validate creating ManagedCluster with an ordinary taint",
+			reservedPrefixes: []string{"cluster.open-cluster-management.io/"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithTaints(clusterv1.Taint{Key: "example.com/maintenance", Effect: clusterv1.TaintEffectNoSelect}),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate creating ManagedCluster with a controller-like taint without reserved prefixes",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithTaints(clusterv1.Taint{Key: "cluster.open-cluster-management.io/unreachable", Effect: clusterv1.TaintEffectNoSelect}),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := &ManagedClusterValidatingAdmissionHook{
+				kubeClient:                kubefake.NewSimpleClientset(),
+				ReservedTaintPrefixes:     c.reservedPrefixes,
+				ReservedTaintAllowedUsers: c.allowedUsers,
+			}
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateReservedNames(t *testing.T) {
+	cases := []struct {
+		name             string
+		reservedNames    []string
+		sarAllowed       bool
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name:          "validate creating ManagedCluster with a reserved name by an unauthorized user",
+			reservedNames: []string{"local-cluster"},
+			sarAllowed:    false,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithName("local-cluster"),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.name",
+					"user \"tester\" is not allowed to create the managed cluster with the reserved name \"local-cluster\""),
+			},
+		},
+		{
+			name:          "validate creating ManagedCluster with a reserved name by an authorized user",
+			reservedNames: []string{"local-cluster"},
+			sarAllowed:    true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithName("local-cluster"),
+				UserInfo:  authenticationv1.UserInfo{Username: "system:admin"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:          "validate creating ManagedCluster with a non-reserved name",
+			reservedNames: []string{"local-cluster"},
+			sarAllowed:    false,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObj(),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:       "validate creating ManagedCluster without reserved names configured",
+			sarAllowed: false,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithName("local-cluster"),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:          "validate updating ManagedCluster with a reserved name",
+			reservedNames: []string{"local-cluster"},
+			sarAllowed:    false,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObjWithName("local-cluster"),
+				OldObject: newManagedClusterObjWithName("local-cluster"),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					allowed := true
+
+					sar := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+					if sar.Spec.ResourceAttributes.Subresource == defaultReservedNameSubresource {
+						allowed = c.sarAllowed
+					}
+
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: allowed,
+						},
+					}, nil
+				},
+			)
+
+			admissionHook := &ManagedClusterValidatingAdmissionHook{
+				kubeClient:           kubeClient,
+				ReservedClusterNames: c.reservedNames,
+			}
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateExternalCABundle(t *testing.T) {
+	cases := []struct {
+		name                    string
+		requireExternalCABundle bool
+		request                 *admissionv1beta1.AdmissionRequest
+		expectedResponse        *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name:                    "validate creating ManagedCluster with an external url without CA bundle",
+			requireExternalCABundle: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://cluster1.example.com:6443"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.managedClusterClientConfigs",
+					"a CA bundle is required for the external url \"https://cluster1.example.com:6443\" in client configs"),
+			},
+		},
+		{
+			name:                    "validate creating ManagedCluster with a loopback url without CA bundle",
+			requireExternalCABundle: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://127.0.0.1:32769"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:                    "validate creating ManagedCluster with a localhost url without CA bundle",
+			requireExternalCABundle: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://localhost:6443"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:                    "validate creating ManagedCluster with an external url with CA bundle",
+			requireExternalCABundle: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://cluster1.example.com:6443", CABundle: []byte("ca-bundle")}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate creating ManagedCluster with an external url without CA bundle and the rule disabled",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://cluster1.example.com:6443"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := &ManagedClusterValidatingAdmissionHook{
+				kubeClient:              kubefake.NewSimpleClientset(),
+				RequireExternalCABundle: c.requireExternalCABundle,
+			}
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestManagedClusterAuthorizationIdentity(t *testing.T) {
+	cases := []struct {
+		name           string
+		impersonate    bool
+		identityUser   string
+		identityGroups []string
+		expectedUser   string
+		expectedGroups []string
+	}{
+		{
+			name:           "the checks are evaluated for the request user by default",
+			expectedUser:   "tester",
+			expectedGroups: []string{"testergroup"},
+		},
+		{
+			name:           "the checks are evaluated for the impersonated authorization identity",
+			impersonate:    true,
+			identityUser:   "system:serviceaccount:open-cluster-management:authorization-auditor",
+			identityGroups: []string{"system:serviceaccounts"},
+			expectedUser:   "system:serviceaccount:open-cluster-management:authorization-auditor",
+			expectedGroups: []string{"system:serviceaccounts"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var capturedSAR *authorizationv1.SubjectAccessReview
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					capturedSAR = action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: true,
+						},
+					}, nil
+				},
+			)
+
+			admissionHook := &ManagedClusterValidatingAdmissionHook{
+				kubeClient:                       kubeClient,
+				ImpersonateAuthorizationIdentity: c.impersonate,
+				AuthorizationIdentityUser:        c.identityUser,
+				AuthorizationIdentityGroups:      c.identityGroups,
+			}
+
+			// creating an accepted cluster triggers the accept authorization check
+			response := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithHubAcceptsClient(true),
+				UserInfo:  authenticationv1.UserInfo{Username: "tester", Groups: []string{"testergroup"}},
+			})
+			if !response.Allowed {
+				t.Fatalf("expected the request to be allowed, but got %#v", response.Result)
+			}
+
+			if capturedSAR == nil {
+				t.Fatal("expected a SubjectAccessReview, but none was created")
+			}
+			if capturedSAR.Spec.User != c.expectedUser {
+				t.Errorf("expected the SubjectAccessReview user %q but got %q", c.expectedUser, capturedSAR.Spec.User)
+			}
+			if !reflect.DeepEqual(capturedSAR.Spec.Groups, c.expectedGroups) {
+				t.Errorf("expected the SubjectAccessReview groups %v but got %v", c.expectedGroups, capturedSAR.Spec.Groups)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateOwnerAnnotation(t *testing.T) {
+	cases := []struct {
+		name                   string
+		requireOwnerAnnotation bool
+		ownerAnnotationKey     string
+		request                *admissionv1beta1.AdmissionRequest
+		expectedResponse       *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "validate creating ManagedCluster without required owner annotation",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObj(),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:                   "validate creating ManagedCluster with a missing owner annotation",
+			requireOwnerAnnotation: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObj(),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+					"a non-empty owner annotation is required, set the annotation to the email or team owning the cluster"),
+			},
+		},
+		{
+			name:                   "validate creating ManagedCluster with an empty owner annotation",
+			requireOwnerAnnotation: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithAnnotations(map[string]string{"owner": ""}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+					"a non-empty owner annotation is required, set the annotation to the email or team owning the cluster"),
+			},
+		},
+		{
+			name:                   "validate creating ManagedCluster with an owner annotation",
+			requireOwnerAnnotation: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithAnnotations(map[string]string{"owner": "team-a@example.com"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:                   "validate creating ManagedCluster with a customized owner annotation key",
+			requireOwnerAnnotation: true,
+			ownerAnnotationKey:     "example.com/owner",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithAnnotations(map[string]string{"owner": "team-a@example.com"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+					"a non-empty example.com/owner annotation is required, set the annotation to the email or team owning the cluster"),
+			},
+		},
+		{
+			name:                   "validate updating ManagedCluster without owner annotation",
+			requireOwnerAnnotation: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObj(),
+				OldObject: newManagedClusterObj(),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := &ManagedClusterValidatingAdmissionHook{
+				kubeClient:             kubefake.NewSimpleClientset(),
+				RequireOwnerAnnotation: c.requireOwnerAnnotation,
+				OwnerAnnotationKey:     c.ownerAnnotationKey,
+			}
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateAnnotations(t *testing.T) {
+	cases := []struct {
+		name                 string
+		annotationValidators map[string]string
+		request              *admissionv1beta1.AdmissionRequest
+		expectedResponse     *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "validate creating ManagedCluster without annotation validators",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithAnnotations(map[string]string{"owner": "team-a"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:                 "validate creating ManagedCluster with a conforming annotation",
+			annotationValidators: map[string]string{"owner": `^\S+@\S+$`},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithAnnotations(map[string]string{"owner": "team-a@example.com"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:                 "validate creating ManagedCluster with a non-conforming annotation",
+			annotationValidators: map[string]string{"owner": `^\S+@\S+$`},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithAnnotations(map[string]string{"owner": "team-a"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+					`the value "team-a" of annotation "owner" does not match the configured rule "^\\S+@\\S+$"`),
+			},
+		},
+		{
+			name:                 "validate creating ManagedCluster with a non-conforming cost center annotation",
+			annotationValidators: map[string]string{"cost-center": `^CC-[0-9]{4}$`},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithAnnotations(map[string]string{"cost-center": "marketing"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+					`the value "marketing" of annotation "cost-center" does not match the configured rule "^CC-[0-9]{4}$"`),
+			},
+		},
+		{
+			name:                 "an absent annotation is not checked",
+			annotationValidators: map[string]string{"cost-center": `^CC-[0-9]{4}$`},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObj(),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:                 "an invalid validator expression rejects the request",
+			annotationValidators: map[string]string{"owner": "["},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithAnnotations(map[string]string{"owner": "team-a"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+					"the validator of annotation \"owner\" is not a valid regular expression: error parsing regexp: missing closing ]: `[`"),
+			},
+		},
+		{
+			name:                 "validate updating ManagedCluster with a non-conforming annotation",
+			annotationValidators: map[string]string{"owner": `^\S+@\S+$`},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObjWithAnnotations(map[string]string{"owner": "team-a"}),
+				OldObject: newManagedClusterObj(),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+					`the value "team-a" of annotation "owner" does not match the configured rule "^\\S+@\\S+$"`),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := &ManagedClusterValidatingAdmissionHook{
+				kubeClient:           kubefake.NewSimpleClientset(),
+				AnnotationValidators: c.annotationValidators,
+			}
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateIdentityKeys(t *testing.T) {
+	identityLabel := "agent.example.com/cluster-id"
+	cases := []struct {
+		name                  string
+		immutableIdentityKeys []string
+		request               *admissionv1beta1.AdmissionRequest
+		expectedResponse      *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name:                  "an identity label can be edited before the cluster is accepted",
+			immutableIdentityKeys: []string{identityLabel},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObjWithIdentity(false, map[string]string{identityLabel: "id-new"}, nil),
+				OldObject: newManagedClusterObjWithIdentity(false, map[string]string{identityLabel: "id-old"}, nil),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:                  "an identity label cannot be changed after the cluster is accepted",
+			immutableIdentityKeys: []string{identityLabel},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObjWithIdentity(true, map[string]string{identityLabel: "id-new"}, nil),
+				OldObject: newManagedClusterObjWithIdentity(true, map[string]string{identityLabel: "id-old"}, nil),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata",
+					`the identity label "agent.example.com/cluster-id" cannot be changed after the cluster is accepted`),
+			},
+		},
+		{
+			name:                  "an identity annotation cannot be removed after the cluster is accepted",
+			immutableIdentityKeys: []string{"cluster-id"},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObjWithIdentity(true, nil, nil),
+				OldObject: newManagedClusterObjWithIdentity(true, nil, map[string]string{"cluster-id": "id-old"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata",
+					`the identity annotation "cluster-id" cannot be removed after the cluster is accepted`),
+			},
+		},
+		{
+			name:                  "an unchanged identity label passes after the cluster is accepted",
+			immutableIdentityKeys: []string{identityLabel},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObjWithIdentity(true, map[string]string{identityLabel: "id", "env": "prod"}, nil),
+				OldObject: newManagedClusterObjWithIdentity(true, map[string]string{identityLabel: "id"}, nil),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:                  "an identity label absent so far may be set after the cluster is accepted",
+			immutableIdentityKeys: []string{identityLabel},
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObjWithIdentity(true, map[string]string{identityLabel: "id"}, nil),
+				OldObject: newManagedClusterObjWithIdentity(true, nil, nil),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "edits pass without configured identity keys",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObjWithIdentity(true, map[string]string{identityLabel: "id-new"}, nil),
+				OldObject: newManagedClusterObjWithIdentity(true, map[string]string{identityLabel: "id-old"}, nil),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := &ManagedClusterValidatingAdmissionHook{
+				kubeClient:            kubefake.NewSimpleClientset(),
+				ImmutableIdentityKeys: c.immutableIdentityKeys,
+			}
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestAdmissionSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(tracesdk.NewTracerProvider(tracesdk.WithSyncer(exporter)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, &authorizationv1.SubjectAccessReview{
+			Status: authorizationv1.SubjectAccessReviewStatus{Allowed: true},
+		}, nil
+	})
+	admissionHook := NewManagedClusterValidatingAdmissionHook(kubeClient)
+
+	// accepting a cluster runs a SubjectAccessReview, so besides the admission span a
+	// child span covering the review is expected
+	response := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+		Resource:  managedclustersSchema,
+		Operation: admissionv1beta1.Create,
+		Object:    newManagedClusterObjWithHubAcceptsClient(true),
+	})
+	if !response.Allowed {
+		t.Fatalf("expected the request to be allowed but got: %v", response.Result)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected two exported spans but got: %d", len(spans))
+	}
+	// the child span ends before the admission span and is exported first
+	sarSpan, admissionSpan := spans[0], spans[1]
+	if sarSpan.Name != "SubjectAccessReview" {
+		t.Errorf("expected span %q but got: %q", "SubjectAccessReview", sarSpan.Name)
+	}
+	if admissionSpan.Name != "ManagedClusterValidatingWebhook" {
+		t.Errorf("expected span %q but got: %q", "ManagedClusterValidatingWebhook", admissionSpan.Name)
+	}
+	if sarSpan.Parent.SpanID() != admissionSpan.SpanContext.SpanID() {
+		t.Errorf("expected the SubjectAccessReview span to be a child of the admission span")
+	}
+	assertSpanAttribute(t, admissionSpan, "admission.resource", attribute.StringValue("managedclusters"))
+	assertSpanAttribute(t, admissionSpan, "admission.operation", attribute.StringValue("CREATE"))
+	assertSpanAttribute(t, admissionSpan, "admission.allowed", attribute.BoolValue(true))
+
+	// every further request produces its own admission span
+	exporter.Reset()
+	admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+		Resource:  managedclustersSchema,
+		Operation: admissionv1beta1.Create,
+		Object:    newManagedClusterObj(),
+	})
+	if spans := exporter.GetSpans(); len(spans) != 1 {
+		t.Errorf("expected one exported span but got: %d", len(spans))
+	}
+}
+
+func assertSpanAttribute(t *testing.T, span *tracesdk.SpanSnapshot, key string, expected attribute.Value) {
+	for _, keyValue := range span.Attributes {
+		if string(keyValue.Key) != key {
+			continue
+		}
+		if keyValue.Value != expected {
+			t.Errorf("expected attribute %q to be %v but got: %v", key, expected.AsInterface(), keyValue.Value.AsInterface())
+		}
+		return
+	}
+	t.Errorf("expected attribute %q on span %q", key, span.Name)
+}
+
+func TestManagedClusterValidateClientConfigRemoval(t *testing.T) {
+	cases := []struct {
+		name             string
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "validate removing all client configs from an accepted ManagedCluster",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newAcceptedManagedClusterObjWithClientConfigs(),
+				OldObject: newAcceptedManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://127.0.0.1:8443"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.managedClusterClientConfigs",
+					"at least one client config is required for an accepted managed cluster, the client configs cannot all be removed"),
+			},
+		},
+		{
+			name: "validate removing a client config from an accepted ManagedCluster",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newAcceptedManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://127.0.0.1:8443"}),
+				OldObject: newAcceptedManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://127.0.0.1:8443"}, clusterv1.ClientConfig{URL: "https://127.0.0.1:6443"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate removing all client configs from an unaccepted ManagedCluster",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObj(),
+				OldObject: newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://127.0.0.1:8443"}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate an accepted ManagedCluster which never had client configs",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newAcceptedManagedClusterObjWithClientConfigs(),
+				OldObject: newAcceptedManagedClusterObjWithClientConfigs(),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubefake.NewSimpleClientset())
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateExpiredCABundle(t *testing.T) {
+	expiredCert := testinghelpers.NewTestCert("expired-ca", -time.Hour).Cert
+	validCert := testinghelpers.NewTestCert("valid-ca", time.Hour).Cert
+	mixedBundle := append(append([]byte{}, expiredCert...), validCert...)
+
+	expiredMessage := "all certificates in the CA bundle of client config \"https://127.0.0.1:8443\" are expired"
+
+	cases := []struct {
+		name                      string
+		caBundle                  []byte
+		denyExpiredClientConfigCA bool
+		expectedResponse          *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name:     "warn on a CA bundle with only expired certificates",
+			caBundle: expiredCert,
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed:  true,
+				Warnings: []string{"[registration-webhook] [warning] " + expiredMessage},
+			},
+		},
+		{
+			name:                      "deny a CA bundle with only expired certificates",
+			caBundle:                  expiredCert,
+			denyExpiredClientConfigCA: true,
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result:  newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.managedClusterClientConfigs", expiredMessage),
+			},
+		},
+		{
+			name:                      "accept a CA bundle with an unexpired certificate",
+			caBundle:                  mixedBundle,
+			denyExpiredClientConfigCA: true,
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:                      "accept a CA bundle with only unexpired certificates",
+			caBundle:                  validCert,
+			denyExpiredClientConfigCA: true,
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := &ManagedClusterValidatingAdmissionHook{
+				kubeClient:                kubefake.NewSimpleClientset(),
+				DenyExpiredClientConfigCA: c.denyExpiredClientConfigCA,
+			}
+
+			actualResponse := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{
+					URL:      "https://127.0.0.1:8443",
+					CABundle: c.caBundle,
+				}),
+			})
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse, actualResponse)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateNotReady(t *testing.T) {
+	cases := []struct {
+		name             string
+		request          *admissionv1beta1.AdmissionRequest
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "a lookup based rule yields a retryable error before the hook is ready",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithHubAcceptsClient(true),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusServiceUnavailable, metav1.StatusReasonServiceUnavailable, "",
+					"the webhook has not finished its initialization, retry the request later"),
+			},
+		},
+		{
+			name: "a structural rule decides without the hook being ready",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithLabels(map[string]string{clusterSetLabel: ""}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.labels",
+					"the cluster.open-cluster-management.io/clusterset label must not have an empty value, remove the label instead"),
+			},
+		},
+		{
+			name: "a request without any lookup is allowed before the hook is ready",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterObj(),
+				OldObject: newManagedClusterObj(),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// a hook without a kube client has not finished its initialization yet
+			admissionHook := NewManagedClusterValidatingAdmissionHook(nil)
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+type fakeResolver struct {
+	hosts map[string][]string
+	err   error
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	addrs, ok := r.hosts[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+func TestManagedClusterValidateHostResolution(t *testing.T) {
+	cases := []struct {
+		name             string
+		resolveEnabled   bool
+		strict           bool
+		resolver         *fakeResolver
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name:           "a resolvable host is allowed",
+			resolveEnabled: true,
+			resolver:       &fakeResolver{hosts: map[string][]string{"hub.example.com": {"10.0.0.1"}}},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:           "an unresolvable host is rejected",
+			resolveEnabled: true,
+			resolver:       &fakeResolver{},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.managedClusterClientConfigs",
+					"host \"hub.example.com\" of url \"https://hub.example.com:6443\" does not resolve"),
+			},
+		},
+		{
+			name:           "a resolution timeout only adds a warning",
+			resolveEnabled: true,
+			resolver:       &fakeResolver{err: &net.DNSError{Err: "i/o timeout", Name: "hub.example.com", IsTimeout: true}},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed:  true,
+				Warnings: []string{"[registration-webhook] [info] host \"hub.example.com\" of url \"https://hub.example.com:6443\" could not be resolved within 2s"},
+			},
+		},
+		{
+			name:           "a resolver error fails open with a warning",
+			resolveEnabled: true,
+			resolver:       &fakeResolver{err: fmt.Errorf("connection refused")},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed:  true,
+				Warnings: []string{"[registration-webhook] [info] unable to resolve host \"hub.example.com\" of url \"https://hub.example.com:6443\": connection refused"},
+			},
+		},
+		{
+			name:           "a resolver error rejects the host when configured strict",
+			resolveEnabled: true,
+			strict:         true,
+			resolver:       &fakeResolver{err: fmt.Errorf("connection refused")},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.managedClusterClientConfigs",
+					"unable to resolve host \"hub.example.com\" of url \"https://hub.example.com:6443\": connection refused"),
+			},
+		},
+		{
+			name:     "no resolution is performed while the preflight is disabled",
+			resolver: &fakeResolver{},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{Allowed: true},
+					}, nil
+				},
+			)
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubeClient)
+			admissionHook.ResolveClientConfigHosts = c.resolveEnabled
+			admissionHook.StrictClientConfigResolution = c.strict
+			admissionHook.resolver = c.resolver
+
+			actualResponse := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://hub.example.com:6443"}),
+			})
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse, actualResponse)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateClusterNamespace(t *testing.T) {
+	terminatingTime := metav1.Now()
+	cases := []struct {
+		name             string
+		namespaces       []runtime.Object
+		allowTerminating bool
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "a cluster whose namespace does not exist yet is allowed",
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "a cluster reusing its registration owned namespace is allowed",
+			namespaces: []runtime.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   testinghelpers.TestManagedClusterName,
+						Labels: map[string]string{"open-cluster-management.io/cluster-name": testinghelpers.TestManagedClusterName},
+					},
+				},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "a cluster colliding with a foreign namespace is denied",
+			namespaces: []runtime.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: testinghelpers.TestManagedClusterName,
+					},
+				},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusConflict, metav1.StatusReasonConflict, "metadata.name",
+					fmt.Sprintf("the namespace %q already exists and is not owned by the cluster registration, a managed cluster cannot be created with that name", testinghelpers.TestManagedClusterName)),
+			},
+		},
+		{
+			name: "a cluster whose owned namespace is still terminating is denied",
+			namespaces: []runtime.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              testinghelpers.TestManagedClusterName,
+						Labels:            map[string]string{"open-cluster-management.io/cluster-name": testinghelpers.TestManagedClusterName},
+						DeletionTimestamp: &terminatingTime,
+					},
+				},
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusConflict, metav1.StatusReasonConflict, "metadata.name",
+					fmt.Sprintf("the namespace %q of an earlier registration of this cluster is still terminating, retry the creation after the termination completed", testinghelpers.TestManagedClusterName)),
+			},
+		},
+		{
+			name: "a terminating owned namespace is allowed when configured",
+			namespaces: []runtime.Object{
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              testinghelpers.TestManagedClusterName,
+						Labels:            map[string]string{"open-cluster-management.io/cluster-name": testinghelpers.TestManagedClusterName},
+						DeletionTimestamp: &terminatingTime,
+					},
+				},
+			},
+			allowTerminating: true,
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset(c.namespaces...)
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubeClient)
+			admissionHook.AllowTerminatingClusterNamespace = c.allowTerminating
+
+			actualResponse := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObj(),
+			})
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse, actualResponse)
+			}
+		})
+	}
+}
+
+func TestManagedClusterRequireClientConfigsForAccept(t *testing.T) {
+	cases := []struct {
+		name                 string
+		requireClientConfigs bool
+		object               runtime.RawExtension
+		expectedResponse     *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name:                 "an accepted cluster without client configs is denied",
+			requireClientConfigs: true,
+			object:               newManagedClusterObjWithHubAcceptsClient(true),
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.hubAcceptsClient",
+					"a ManagedCluster cannot be accepted without client configs, register the cluster first or provide the URL of its apiserver in spec.managedClusterClientConfigs"),
+			},
+		},
+		{
+			name:                 "an accepted cluster with client configs is allowed",
+			requireClientConfigs: true,
+			object:               newAcceptedManagedClusterObjWithClientConfigs(clusterv1.ClientConfig{URL: "https://127.0.0.1:6443"}),
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:   "an unaccepted cluster without client configs is allowed",
+			object: newManagedClusterObjWithHubAcceptsClient(false),
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:   "an accepted cluster without client configs is allowed while the rule is disabled",
+			object: newManagedClusterObjWithHubAcceptsClient(true),
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{Allowed: true},
+					}, nil
+				},
+			)
+
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubeClient)
+			admissionHook.RequireClientConfigsForAccept = c.requireClientConfigs
+
+			actualResponse := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    c.object,
+			})
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse, actualResponse)
+			}
+		})
+	}
+}
+
+func TestManagedClusterCreationFreeze(t *testing.T) {
+	freezeStart := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	freezeEnd := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	activeWindow := fmt.Sprintf("%s/%s", freezeStart, freezeEnd)
+	pastWindow := fmt.Sprintf("%s/%s",
+		time.Now().Add(-2*time.Hour).UTC().Format(time.RFC3339),
+		time.Now().Add(-time.Hour).UTC().Format(time.RFC3339))
+
+	cases := []struct {
+		name             string
+		freezeWindows    []string
+		allowOverride    bool
+		object           runtime.RawExtension
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name:          "creating a cluster during an active freeze window is denied",
+			freezeWindows: []string{activeWindow},
+			object:        newManagedClusterObj(),
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "",
+					fmt.Sprintf("creating a ManagedCluster is frozen until %s, retry after the freeze ended or request a freeze override", freezeEnd)),
+			},
+		},
+		{
+			name:          "creating a cluster outside the freeze windows is allowed",
+			freezeWindows: []string{pastWindow},
+			object:        newManagedClusterObj(),
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:          "an authorized freeze override allows the creation",
+			freezeWindows: []string{activeWindow},
+			allowOverride: true,
+			object:        newManagedClusterObjWithAnnotations(map[string]string{defaultFreezeOverrideAnnotation: "true"}),
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:          "an unauthorized freeze override is denied",
+			freezeWindows: []string{activeWindow},
+			object:        newManagedClusterObjWithAnnotations(map[string]string{defaultFreezeOverrideAnnotation: "true"}),
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.annotations",
+					"user \"tester\" is not allowed to override the creation freeze"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{Allowed: c.allowOverride},
+					}, nil
+				},
+			)
+
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubeClient)
+			admissionHook.CreationFreezeWindows = c.freezeWindows
+
+			actualResponse := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    c.object,
+				UserInfo:  authenticationv1.UserInfo{Username: "tester"},
+			})
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse, actualResponse)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateDenialStatusCodes(t *testing.T) {
+	cases := []struct {
+		name              string
+		request           *admissionv1beta1.AdmissionRequest
+		denialStatusCodes map[string]int
+		expectedResponse  *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "a forbidden denial keeps its default code without a mapping",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientSet("clusterset1"),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.labels",
+					"user \"\" cannot add/remove a ManagedCluster to/from ManagedClusterSet \"clusterset1\""),
+			},
+		},
+		{
+			name: "a forbidden denial responds with the mapped code",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithClientSet("clusterset1"),
+			},
+			denialStatusCodes: map[string]int{string(metav1.StatusReasonForbidden): http.StatusBadRequest},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonForbidden, "metadata.labels",
+					"user \"\" cannot add/remove a ManagedCluster to/from ManagedClusterSet \"clusterset1\""),
+			},
+		},
+		{
+			name: "a bad request denial responds with the mapped code",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithLabels(map[string]string{clusterSetLabel: ""}),
+			},
+			denialStatusCodes: map[string]int{string(metav1.StatusReasonBadRequest): http.StatusForbidden},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusForbidden, metav1.StatusReasonBadRequest, "metadata.labels",
+					"the cluster.open-cluster-management.io/clusterset label must not have an empty value, remove the label instead"),
+			},
+		},
+		{
+			name: "a mapping for another reason does not apply",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithLabels(map[string]string{clusterSetLabel: ""}),
+			},
+			denialStatusCodes: map[string]int{string(metav1.StatusReasonForbidden): http.StatusBadRequest},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.labels",
+					"the cluster.open-cluster-management.io/clusterset label must not have an empty value, remove the label instead"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: false,
+						},
+					}, nil
+				},
+			)
+
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubeClient)
+			admissionHook.DenialStatusCodes = c.denialStatusCodes
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidatePolicy(t *testing.T) {
+	cases := []struct {
+		name             string
+		request          *admissionv1beta1.AdmissionRequest
+		policyEvaluator  PolicyEvaluator
+		expectedResponse *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "an allowing policy keeps the request allowed",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObj(),
+			},
+			policyEvaluator: func(oldCluster, cluster *clusterv1.ManagedCluster, userInfo authenticationv1.UserInfo) (PolicyDecision, error) {
+				return PolicyDecision{Allowed: true}, nil
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "a denying policy denies the request with the policy message",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObj(),
+			},
+			policyEvaluator: func(oldCluster, cluster *clusterv1.ManagedCluster, userInfo authenticationv1.UserInfo) (PolicyDecision, error) {
+				return PolicyDecision{Message: fmt.Sprintf("cluster %q violates the naming policy", cluster.Name)}, nil
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusForbidden, metav1.StatusReasonForbidden, "",
+					fmt.Sprintf("cluster %q violates the naming policy", testinghelpers.TestManagedClusterName)),
+			},
+		},
+		{
+			name: "a broken policy fails the request closed",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObj(),
+			},
+			policyEvaluator: func(oldCluster, cluster *clusterv1.ManagedCluster, userInfo authenticationv1.UserInfo) (PolicyDecision, error) {
+				return PolicyDecision{}, fmt.Errorf("rego parse error")
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusInternalServerError, metav1.StatusReasonInternalError, "",
+					"the admission policy failed to evaluate: rego parse error"),
+			},
+		},
+		{
+			name: "the policy does not run when a built-in check denies the request",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithLabels(map[string]string{clusterSetLabel: ""}),
+			},
+			policyEvaluator: func(oldCluster, cluster *clusterv1.ManagedCluster, userInfo authenticationv1.UserInfo) (PolicyDecision, error) {
+				return PolicyDecision{}, fmt.Errorf("must not be evaluated")
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.labels",
+					"the cluster.open-cluster-management.io/clusterset label must not have an empty value, remove the label instead"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubefake.NewSimpleClientset())
+			admissionHook.PolicyEvaluator = c.policyEvaluator
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateLabelCount(t *testing.T) {
+	newLabels := func(count int) map[string]string {
+		labels := map[string]string{}
+		for i := 0; i < count; i++ {
+			labels[fmt.Sprintf("label-%d", i)] = "value"
+		}
+		return labels
+	}
+
+	cases := []struct {
+		name                           string
+		maxLabels                      int
+		excludeReservedLabelsFromCount bool
+		request                        *admissionv1beta1.AdmissionRequest
+		expectedResponse               *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name:      "create a cluster with the maximum number of labels",
+			maxLabels: 3,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithLabels(newLabels(3)),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:      "create a cluster exceeding the maximum number of labels",
+			maxLabels: 3,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithLabels(newLabels(4)),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.labels",
+					"the number of labels (4) exceeds the maximum allowed (3)"),
+			},
+		},
+		{
+			name:      "reduce the label count of an over-limit cluster",
+			maxLabels: 3,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				OldObject: newManagedClusterObjWithLabels(newLabels(6)),
+				Object:    newManagedClusterObjWithLabels(newLabels(5)),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:      "grow the label count of an over-limit cluster",
+			maxLabels: 3,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Update,
+				OldObject: newManagedClusterObjWithLabels(newLabels(4)),
+				Object:    newManagedClusterObjWithLabels(newLabels(5)),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.labels",
+					"the number of labels (5) exceeds the maximum allowed (3)"),
+			},
+		},
+		{
+			name:                           "reserved labels are excluded from the count when configured",
+			maxLabels:                      1,
+			excludeReservedLabelsFromCount: true,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedClusterObjWithLabels(map[string]string{
+					"env":                                   "prod",
+					"open-cluster-management.io/managed-by": "hub",
+				}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name:      "reserved labels count against the maximum by default",
+			maxLabels: 1,
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object: newManagedClusterObjWithLabels(map[string]string{
+					"env":                                   "prod",
+					"open-cluster-management.io/managed-by": "hub",
+				}),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: newDenialStatus(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.labels",
+					"the number of labels (2) exceeds the maximum allowed (1)"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubefake.NewSimpleClientset())
+			admissionHook.MaxLabels = c.maxLabels
+			admissionHook.ExcludeReservedLabelsFromCount = c.excludeReservedLabelsFromCount
+			admissionHook.ReservedLabelAllowedUsers = []string{""}
+
+			actualResponse := admissionHook.Validate(c.request)
+
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestManagedClusterValidateAcceptSARAttributes(t *testing.T) {
+	cases := []struct {
+		name               string
+		group              string
+		resource           string
+		subresource        string
+		expectedAttributes *authorizationv1.ResourceAttributes
+	}{
+		{
+			name: "the accept check uses the default resource attributes",
+			expectedAttributes: &authorizationv1.ResourceAttributes{
+				Group:       "register.open-cluster-management.io",
+				Resource:    "managedclusters",
+				Verb:        "update",
+				Subresource: "accept",
+				Name:        testinghelpers.TestManagedClusterName,
+			},
+		},
+		{
+			name:        "the accept check uses the configured resource attributes",
+			group:       "rbac.example.com",
+			resource:    "clusterregistrations",
+			subresource: "approve",
+			expectedAttributes: &authorizationv1.ResourceAttributes{
+				Group:       "rbac.example.com",
+				Resource:    "clusterregistrations",
+				Verb:        "update",
+				Subresource: "approve",
+				Name:        testinghelpers.TestManagedClusterName,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var actualAttributes *authorizationv1.ResourceAttributes
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					sar := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+					if sar.Spec.ResourceAttributes.Subresource != "join" {
+						actualAttributes = sar.Spec.ResourceAttributes
+					}
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: true,
+						},
+					}, nil
+				},
+			)
+
+			admissionHook := NewManagedClusterValidatingAdmissionHook(kubeClient)
+			admissionHook.AcceptCheckGroup = c.group
+			admissionHook.AcceptCheckResource = c.resource
+			admissionHook.AcceptCheckSubresource = c.subresource
+
+			response := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterObjWithHubAcceptsClient(true),
+			})
+			if !response.Allowed {
+				t.Fatalf("unexpected denial: %#v", response.Result)
+			}
+
+			if !reflect.DeepEqual(actualAttributes, c.expectedAttributes) {
+				t.Errorf("expected resource attributes %#v but got: %#v", c.expectedAttributes, actualAttributes)
+			}
+		})
+	}
+}
+
+func newManagedClusterObj() runtime.RawExtension {
+	managedCluster := testinghelpers.NewManagedCluster()
+	clusterObj, _ := json.Marshal(managedCluster)
+	return runtime.RawExtension{
+		Raw: clusterObj,
+	}
+}
+
+func newManagedClusterObjWithName(name string) runtime.RawExtension {
+	managedCluster := testinghelpers.NewManagedCluster()
+	managedCluster.Name = name
+	clusterObj, _ := json.Marshal(managedCluster)
+	return runtime.RawExtension{
+		Raw: clusterObj,
+	}
+}
+
+func newManagedClusterObjWithHubAcceptsClient(hubAcceptsClient bool) runtime.RawExtension {
+	managedCluster := testinghelpers.NewManagedCluster()
+	managedCluster.Spec.HubAcceptsClient = hubAcceptsClient
+	clusterObj, _ := json.Marshal(managedCluster)
+	return runtime.RawExtension{
+		Raw: clusterObj,
+	}
+}
+
+func newManagedClusterObjWithClientConfigs(clientConfig clusterv1.ClientConfig) runtime.RawExtension {
+	managedCluster := testinghelpers.NewManagedCluster()
+	managedCluster.Spec.ManagedClusterClientConfigs = []clusterv1.ClientConfig{clientConfig}
+	clusterObj, _ := json.Marshal(managedCluster)
+	return runtime.RawExtension{
+		Raw: clusterObj,
+	}
+}
+
+func newManagedClusterObjWithLabels(labels map[string]string) runtime.RawExtension {
+	managedCluster := testinghelpers.NewManagedCluster()
+	managedCluster.Labels = labels
+	clusterObj, _ := json.Marshal(managedCluster)
+	return runtime.RawExtension{
+		Raw: clusterObj,
+	}
+}
+
+func newManagedClusterObjWithAnnotations(annotations map[string]string) runtime.RawExtension {
+	managedCluster := testinghelpers.NewManagedCluster()
+	managedCluster.Annotations = annotations
+	clusterObj, _ := json.Marshal(managedCluster)
+	return runtime.RawExtension{
+		Raw: clusterObj,
+	}
+}
+
+func newManagedClusterObjWithIdentity(hubAcceptsClient bool, labels, annotations map[string]string) runtime.RawExtension {
+	managedCluster := testinghelpers.NewManagedCluster()
+	managedCluster.Spec.HubAcceptsClient = hubAcceptsClient
+	managedCluster.Labels = labels
+	managedCluster.Annotations = annotations
+	clusterObj, _ := json.Marshal(managedCluster)
+	return runtime.RawExtension{
+		Raw: clusterObj,
+	}
+}
+
+func newAcceptedManagedClusterObjWithClientConfigs(clientConfigs ...clusterv1.ClientConfig) runtime.RawExtension {
+	managedCluster := testinghelpers.NewManagedCluster()
+	managedCluster.Spec.HubAcceptsClient = true
+	managedCluster.Spec.ManagedClusterClientConfigs = clientConfigs
+	clusterObj, _ := json.Marshal(managedCluster)
+	return runtime.RawExtension{
+		Raw: clusterObj,
+	}
+}
+
+func newManagedClusterObjWithTaints(taints ...clusterv1.Taint) runtime.RawExtension {
+	managedCluster := testinghelpers.NewManagedCluster()
+	managedCluster.Spec.Taints = taints
+	clusterObj, _ := json.Marshal(managedCluster)
+	return runtime.RawExtension{
+		Raw: clusterObj,
+	}
+}
+
+func newManagedClusterObjWithClientSet(clusterSetName string) runtime.RawExtension {
+	managedCluster := testinghelpers.NewManagedCluster()
+	managedCluster.Labels = map[string]string{
+		clusterSetLabel: clusterSetName,
+	}
+	clusterObj, _ := json.Marshal(managedCluster)
+	return runtime.RawExtension{
+		Raw: clusterObj,
+	}
+}
+
+func newManagedClusterSet(name string) *clusterv1beta1.ManagedClusterSet {
+	return &clusterv1beta1.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+}
+
+func TestDenialResponseContainsStructuredCause(t *testing.T) {
+	admissionHook := NewManagedClusterValidatingAdmissionHook(kubefake.NewSimpleClientset())
+
+	response := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+		Resource:  managedclustersSchema,
+		Operation: admissionv1beta1.Create,
+		Object:    newManagedClusterObjWithTaints(clusterv1.Taint{Effect: clusterv1.TaintEffectNoSelect}),
+	})
+
+	if response.Allowed {
+		t.Fatalf("expected denial but got: %#v", response)
+	}
+	expectedMessage := "taint key of taints[0] is empty"
+	if response.Result.Message != expectedMessage {
+		t.Errorf("expected message %q but got: %q", expectedMessage, response.Result.Message)
+	}
+	if response.Result.Details == nil || len(response.Result.Details.Causes) != 1 {
+		t.Fatalf("expected one structured cause but got: %#v", response.Result.Details)
+	}
+	cause := response.Result.Details.Causes[0]
+	if cause.Type != metav1.CauseType(metav1.StatusReasonBadRequest) {
+		t.Errorf("expected cause type %q but got: %q", metav1.StatusReasonBadRequest, cause.Type)
+	}
+	if cause.Field != "spec" {
+		t.Errorf("expected cause field %q but got: %q", "spec", cause.Field)
+	}
+	if cause.Message != expectedMessage {
+		t.Errorf("expected cause message %q but got: %q", expectedMessage, cause.Message)
 	}
 }
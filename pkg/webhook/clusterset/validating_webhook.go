@@ -0,0 +1,237 @@
+package clusterset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	webhookmetrics "open-cluster-management.io/registration/pkg/webhook/metrics"
+	"open-cluster-management.io/registration/pkg/webhook/tracing"
+)
+
+// clusterSelectorAnnotation holds the label selector of a selector-type
+// ManagedClusterSet, as consumed by the membership controller on the hub.
+const clusterSelectorAnnotation = "cluster.open-cluster-management.io/cluster-selector"
+
+// ManagedClusterSetValidatingAdmissionHook will validate the creating ManagedClusterSet request.
+type ManagedClusterSetValidatingAdmissionHook struct {
+	kubeClient kubernetes.Interface
+
+	// ReservedNamePrefixes lists the name prefixes reserved for privileged users. Creating
+	// a ManagedClusterSet whose name starts with one of the prefixes additionally requires
+	// the permission checked on the reserved name subresource. Without any configured
+	// prefix the check is disabled.
+	ReservedNamePrefixes []string
+
+	// ReservedNameSubresource is the ManagedClusterSet subresource the create permission
+	// is checked on for reserved name prefixes.
+	ReservedNameSubresource string
+}
+
+// AddFlags registers the flags of the ManagedClusterSet validating webhook
+func (a *ManagedClusterSetValidatingAdmissionHook) AddFlags(fs *pflag.FlagSet) {
+	fs.StringSliceVar(&a.ReservedNamePrefixes, "cluster-set-reserved-name-prefixes", a.ReservedNamePrefixes,
+		"A list of ManagedClusterSet name prefixes reserved for privileged users, e.g. 'prod-'. Creating a cluster set with a reserved name prefix requires the permission checked on the reserved name subresource. An empty list disables the check.")
+	fs.StringVar(&a.ReservedNameSubresource, "cluster-set-reserved-name-subresource", a.ReservedNameSubresource,
+		"The ManagedClusterSet subresource the create permission is checked on for reserved name prefixes.")
+}
+
+// ValidatingResource is called by generic-admission-server on startup to register the returned REST resource through which the
+// webhook is accessed by the kube apiserver.
+func (a *ManagedClusterSetValidatingAdmissionHook) ValidatingResource() (plural schema.GroupVersionResource, singular string) {
+	return schema.GroupVersionResource{
+			Group:    "admission.cluster.open-cluster-management.io",
+			Version:  "v1",
+			Resource: "managedclustersetvalidators",
+		},
+		"managedclustersetvalidators"
+}
+
+// Validate is called by generic-admission-server when the registered REST resource above is called with an admission request.
+func (a *ManagedClusterSetValidatingAdmissionHook) Validate(admissionSpec *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	klog.V(4).Infof("validate %q operation for object %q", admissionSpec.Operation, admissionSpec.Object)
+
+	// only validate the request for ManagedClusterSet
+	if admissionSpec.Resource.Group != "cluster.open-cluster-management.io" ||
+		admissionSpec.Resource.Resource != "managedclustersets" {
+		return acceptRequest()
+	}
+
+	// only handle Create/Update Operation
+	if admissionSpec.Operation != admissionv1beta1.Create && admissionSpec.Operation != admissionv1beta1.Update {
+		return acceptRequest()
+	}
+
+	// the span stays a no-op unless a tracer provider was installed by the tracing setup
+	ctx, span := tracing.StartAdmissionSpan(context.Background(), "ManagedClusterSetValidatingWebhook", admissionSpec)
+	status := a.validateClusterSet(ctx, admissionSpec)
+	tracing.EndAdmissionSpan(span, status)
+	return status
+}
+
+// validateClusterSet validates a create or update ManagedClusterSet operation
+func (a *ManagedClusterSetValidatingAdmissionHook) validateClusterSet(ctx context.Context, admissionSpec *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	clusterSet := &clusterv1beta1.ManagedClusterSet{}
+	if err := json.Unmarshal(admissionSpec.Object.Raw, clusterSet); err != nil {
+		return denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "",
+			fmt.Sprintf("Unable to unmarshal the ManagedClusterSet object: %v", err))
+	}
+
+	// a selector-type cluster set with a malformed selector silently matches no cluster,
+	// the membership controller skips a selector it cannot parse
+	if status := validateClusterSelector(clusterSet); !status.Allowed {
+		return status
+	}
+
+	// the name and reserved name prefix checks only restrict who may create a cluster set
+	if admissionSpec.Operation != admissionv1beta1.Create {
+		return acceptRequest()
+	}
+
+	// a cluster joins a set via the clusterset label carrying the set name as its value,
+	// a set whose name is not a usable label value could never match any cluster
+	if errs := validation.IsValidLabelValue(clusterSet.Name); len(errs) > 0 {
+		return denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.name",
+			fmt.Sprintf("the cluster set name %q cannot be used as the value of the clusterset label: %s",
+				clusterSet.Name, strings.Join(errs, "; ")))
+	}
+
+	if !a.hasReservedNamePrefix(clusterSet.Name) {
+		return acceptRequest()
+	}
+
+	return a.allowReservedClusterSet(ctx, clusterSet.Name, admissionSpec.UserInfo)
+}
+
+// validateClusterSelector rejects a selector-type cluster set whose cluster selector
+// annotation does not parse as a label selector.
+func validateClusterSelector(clusterSet *clusterv1beta1.ManagedClusterSet) *admissionv1beta1.AdmissionResponse {
+	selectorValue, ok := clusterSet.Annotations[clusterSelectorAnnotation]
+	if !ok {
+		return acceptRequest()
+	}
+	if _, err := labels.Parse(selectorValue); err != nil {
+		return denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+			fmt.Sprintf("the cluster selector %q of the cluster set does not parse as a label selector: %v", selectorValue, err))
+	}
+	return acceptRequest()
+}
+
+// Initialize is called by generic-admission-server on startup to setup initialization that ManagedClusterSet webhook needs.
+func (a *ManagedClusterSetValidatingAdmissionHook) Initialize(kubeClientConfig *rest.Config, stopCh <-chan struct{}) error {
+	var err error
+	a.kubeClient, err = kubernetes.NewForConfig(kubeClientConfig)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ready reports whether the hook finished its initialization. The admission server only
+// serves after Initialize returned, but a request racing the startup must not reach the
+// rules that look up state outside the request object.
+func (a *ManagedClusterSetValidatingAdmissionHook) ready() bool {
+	return a.kubeClient != nil
+}
+
+// hasReservedNamePrefix returns true if the given cluster set name starts with one of the
+// configured reserved name prefixes.
+func (a *ManagedClusterSetValidatingAdmissionHook) hasReservedNamePrefix(name string) bool {
+	for _, prefix := range a.ReservedNamePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowReservedClusterSet checks if the user has permission to create a cluster set with a
+// reserved name prefix, checked on the configured subresource.
+func (a *ManagedClusterSetValidatingAdmissionHook) allowReservedClusterSet(ctx context.Context, clusterSetName string, userInfo authenticationv1.UserInfo) *admissionv1beta1.AdmissionResponse {
+	// the permission check looks up state outside the request object, return a retryable
+	// error instead of a wrong decision before the hook is ready
+	if !a.ready() {
+		return denyRequest(http.StatusServiceUnavailable, metav1.StatusReasonServiceUnavailable, "",
+			"the webhook has not finished its initialization, retry the request later")
+	}
+
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for k, v := range userInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   userInfo.Username,
+			UID:    userInfo.UID,
+			Groups: userInfo.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:       "cluster.open-cluster-management.io",
+				Resource:    "managedclustersets",
+				Subresource: a.ReservedNameSubresource,
+				Verb:        "create",
+				Name:        clusterSetName,
+			},
+		},
+	}
+	sarCtx, sarSpan := tracing.StartSpan(ctx, "SubjectAccessReview")
+	sarStart := time.Now()
+	sar, err := a.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(sarCtx, sar, metav1.CreateOptions{})
+	tracing.EndSpan(sarSpan, err)
+	webhookmetrics.ObserveSubjectAccessReview("managedclustersets", sarStart, err, err == nil && sar.Status.Allowed)
+	if err != nil {
+		return denyRequest(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.name", err.Error())
+	}
+	if !sar.Status.Allowed {
+		return denyRequest(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.name",
+			fmt.Sprintf("user %q is not allowed to create the cluster set %q with a reserved name prefix", userInfo.Username, clusterSetName))
+	}
+	return acceptRequest()
+}
+
+func acceptRequest() *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+}
+
+// denyRequest constructs a denial AdmissionResponse. Besides the human readable message, the
+// denial is encoded in the status details as a machine readable cause, so clients that parse
+// structured admission errors do not need to parse the message.
+func denyRequest(code int32, reason metav1.StatusReason, field, message string) *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    code,
+			Reason:  reason,
+			Message: message,
+			Details: &metav1.StatusDetails{
+				Causes: []metav1.StatusCause{
+					{
+						Type:    metav1.CauseType(reason),
+						Field:   field,
+						Message: message,
+					},
+				},
+			},
+		},
+	}
+}
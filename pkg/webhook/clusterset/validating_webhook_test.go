@@ -0,0 +1,267 @@
+package clusterset
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+var managedclustersetSchema = metav1.GroupVersionResource{
+	Group:    "cluster.open-cluster-management.io",
+	Version:  "v1beta1",
+	Resource: "managedclustersets",
+}
+
+func TestManagedClusterSetValidate(t *testing.T) {
+	cases := []struct {
+		name                    string
+		request                 *admissionv1beta1.AdmissionRequest
+		reservedNamePrefixes    []string
+		allowReservedClusterSet bool
+		expectedResponse        *admissionv1beta1.AdmissionResponse
+		expectedSubresource     string
+	}{
+		{
+			name: "validate non-managedclustersets request",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource: metav1.GroupVersionResource{
+					Group:    "test.open-cluster-management.io",
+					Version:  "v1",
+					Resource: "tests",
+				},
+			},
+			reservedNamePrefixes: []string{"prod-"},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate updating operation",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newManagedClusterSetObj("prod-clusters"),
+			},
+			reservedNamePrefixes: []string{"prod-"},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate creating cluster set without a reserved name prefix",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetObj("dev-clusters"),
+			},
+			reservedNamePrefixes: []string{"prod-"},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate creating reserved cluster set without configured prefixes",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetObj("prod-clusters"),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate creating cluster set whose name is a valid label value",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetObj("dev.clusters-1"),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate creating cluster set whose name is not a valid label value",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetObj(strings.Repeat("a", 64)),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.name",
+					fmt.Sprintf("the cluster set name %q cannot be used as the value of the clusterset label: must be no more than 63 characters",
+						strings.Repeat("a", 64))).Result,
+			},
+		},
+		{
+			name: "validate creating a selector-type cluster set with a valid selector",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newSelectorManagedClusterSetObj("dev-clusters", "environment=dev,region in (us,eu)"),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate creating a selector-type cluster set with a malformed selector",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newSelectorManagedClusterSetObj("dev-clusters", "environment=("),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+					fmt.Sprintf("the cluster selector %q of the cluster set does not parse as a label selector: %v",
+						"environment=(", mustParseError("environment=("))).Result,
+			},
+		},
+		{
+			name: "validate updating a selector-type cluster set with a malformed selector",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetSchema,
+				Operation: admissionv1beta1.Update,
+				Object:    newSelectorManagedClusterSetObj("dev-clusters", "environment=("),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "metadata.annotations",
+					fmt.Sprintf("the cluster selector %q of the cluster set does not parse as a label selector: %v",
+						"environment=(", mustParseError("environment=("))).Result,
+			},
+		},
+		{
+			name: "validate authorized creation of a reserved cluster set",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetObj("prod-clusters"),
+			},
+			reservedNamePrefixes:    []string{"prod-"},
+			allowReservedClusterSet: true,
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+			expectedSubresource: "reserve",
+		},
+		{
+			name: "validate unauthorized creation of a reserved cluster set",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetObj("prod-clusters"),
+			},
+			reservedNamePrefixes: []string{"prod-"},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: denyRequest(http.StatusForbidden, metav1.StatusReasonForbidden, "metadata.name",
+					"user \"\" is not allowed to create the cluster set \"prod-clusters\" with a reserved name prefix").Result,
+			},
+			expectedSubresource: "reserve",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			var actualSubresource string
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					sar := action.(clienttesting.CreateActionImpl).Object.(*authorizationv1.SubjectAccessReview)
+					actualSubresource = sar.Spec.ResourceAttributes.Subresource
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: c.allowReservedClusterSet,
+						},
+					}, nil
+				},
+			)
+
+			admissionHook := &ManagedClusterSetValidatingAdmissionHook{
+				kubeClient:              kubeClient,
+				ReservedNamePrefixes:    c.reservedNamePrefixes,
+				ReservedNameSubresource: "reserve",
+			}
+
+			actualResponse := admissionHook.Validate(c.request)
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+			if actualSubresource != c.expectedSubresource {
+				t.Errorf("expected the permission to be checked on subresource %q but got: %q", c.expectedSubresource, actualSubresource)
+			}
+		})
+	}
+}
+
+func TestManagedClusterSetValidateNotReady(t *testing.T) {
+	// a hook without a kube client has not finished its initialization yet
+	admissionHook := &ManagedClusterSetValidatingAdmissionHook{
+		ReservedNamePrefixes:    []string{"prod-"},
+		ReservedNameSubresource: "reserve",
+	}
+
+	actualResponse := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+		Resource:  managedclustersetSchema,
+		Operation: admissionv1beta1.Create,
+		Object:    newManagedClusterSetObj("prod-clusters"),
+	})
+
+	expectedResponse := denyRequest(http.StatusServiceUnavailable, metav1.StatusReasonServiceUnavailable, "",
+		"the webhook has not finished its initialization, retry the request later")
+	if !reflect.DeepEqual(actualResponse, expectedResponse) {
+		t.Errorf("expected %#v but got: %#v", expectedResponse.Result, actualResponse.Result)
+	}
+}
+
+func newSelectorManagedClusterSetObj(name, selector string) runtime.RawExtension {
+	managedClusterSet := &clusterv1beta1.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				clusterSelectorAnnotation: selector,
+			},
+		},
+	}
+	clusterSetObj, _ := json.Marshal(managedClusterSet)
+	return runtime.RawExtension{
+		Raw: clusterSetObj,
+	}
+}
+
+// mustParseError returns the parse error of a malformed label selector, to build the
+// expected denial message.
+func mustParseError(selector string) error {
+	_, err := labels.Parse(selector)
+	return err
+}
+
+func newManagedClusterSetObj(name string) runtime.RawExtension {
+	managedClusterSet := &clusterv1beta1.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+	clusterSetObj, _ := json.Marshal(managedClusterSet)
+	return runtime.RawExtension{
+		Raw: clusterSetObj,
+	}
+}
@@ -5,21 +5,100 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/spf13/pflag"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
+	clusterv1client "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	webhookmetrics "open-cluster-management.io/registration/pkg/webhook/metrics"
+	"open-cluster-management.io/registration/pkg/webhook/tracing"
+	"open-cluster-management.io/registration/pkg/webhook/warnings"
+)
+
+const defaultClusterSetName = "default"
+
+// defaultV1beta1DeprecationWarning is the admission warning attached to requests
+// submitted via the v1beta1 API version unless the message is overridden or emptied by
+// flag, so the warning is emitted out of the box.
+const defaultV1beta1DeprecationWarning = "cluster.open-cluster-management.io/v1beta1 ManagedClusterSetBinding is deprecated; migrate to cluster.open-cluster-management.io/v1beta2"
+
+// defaultClusterSetLookupRetries and defaultClusterSetLookupInterval bound how often a
+// missing target cluster set is re-read before the binding is rejected, so a binding
+// submitted right after its cluster set does not race the visibility of the freshly
+// created set. Both are exposed as command line flags.
+const (
+	defaultClusterSetLookupRetries  = 10
+	defaultClusterSetLookupInterval = 200 * time.Millisecond
 )
 
 // ManagedClusterSetBindingValidatingAdmissionHook will validate the creating/updating ManagedClusterSetBinding request.
 type ManagedClusterSetBindingValidatingAdmissionHook struct {
-	kubeClient kubernetes.Interface
+	kubeClient    kubernetes.Interface
+	clusterClient clusterv1client.Interface
+
+	// ClusterSetAliases maps a ManagedClusterSetBinding name to the name of the
+	// ManagedClusterSet it is allowed to target. A binding whose name differs from the
+	// target cluster set name is accepted only if such a mapping is configured.
+	ClusterSetAliases map[string]string
+
+	// BindNamespaceMapper maps the namespace of a ManagedClusterSetBinding to the
+	// namespace the bind SubjectAccessReview is made against, for hubs where the
+	// authorization of a namespace is anchored in a parent or tenant namespace. A nil
+	// mapper falls back to BindNamespaceMappings.
+	BindNamespaceMapper func(namespace string) string
+
+	// BindNamespaceMappings is the flag driven variant of BindNamespaceMapper. A
+	// binding namespace without an entry uses the binding's own namespace.
+	BindNamespaceMappings map[string]string
+
+	// RequireClusterSetExists, if true, rejects the creation of a
+	// ManagedClusterSetBinding whose target ManagedClusterSet does not exist. Disabled
+	// by default, a binding may be created ahead of its cluster set.
+	RequireClusterSetExists bool
+
+	// ExemptDefaultClusterSetFromExistence exempts a binding targeting the "default"
+	// cluster set from the existence check above, that set is typically populated
+	// lazily by the hub.
+	ExemptDefaultClusterSetFromExistence bool
+
+	// ClusterSetLookupRetries and ClusterSetLookupInterval configure how often and how
+	// quickly a missing target cluster set is re-read before the binding is rejected,
+	// so a binding created right after its cluster set is not spuriously denied.
+	ClusterSetLookupRetries  int
+	ClusterSetLookupInterval time.Duration
+
+	// V1beta1DeprecationWarning, if not empty, is attached as an admission warning to
+	// every request submitted via the v1beta1 API version, nudging users to migrate
+	// without changing the admission decision. The flag defaults the message to
+	// defaultV1beta1DeprecationWarning; an empty message disables the warning.
+	V1beta1DeprecationWarning string
+}
+
+// AddFlags registers the flags of the ManagedClusterSetBinding validating webhook
+func (a *ManagedClusterSetBindingValidatingAdmissionHook) AddFlags(fs *pflag.FlagSet) {
+	fs.StringToStringVar(&a.ClusterSetAliases, "cluster-set-binding-aliases", a.ClusterSetAliases,
+		"Comma separated alias=clusterset pairs. A ManagedClusterSetBinding named alias is accepted for the mapped cluster set in addition to a binding with the cluster set name itself.")
+	fs.StringToStringVar(&a.BindNamespaceMappings, "bind-namespace-mappings", a.BindNamespaceMappings,
+		"Comma separated namespace=authorizationNamespace pairs. The bind SubjectAccessReview for a ManagedClusterSetBinding in namespace is made against authorizationNamespace instead of the binding's own namespace.")
+	fs.StringVar(&a.V1beta1DeprecationWarning, "cluster-set-binding-v1beta1-deprecation-warning", defaultV1beta1DeprecationWarning,
+		"The admission warning attached to ManagedClusterSetBinding requests submitted via the v1beta1 API version. An empty message disables the warning.")
+	fs.BoolVar(&a.RequireClusterSetExists, "require-cluster-set-exists", a.RequireClusterSetExists,
+		"Reject the creation of a ManagedClusterSetBinding whose target ManagedClusterSet does not exist. Disabled by default, a binding may be created ahead of its cluster set.")
+	fs.BoolVar(&a.ExemptDefaultClusterSetFromExistence, "exempt-default-cluster-set-from-existence", true,
+		"Exempt a ManagedClusterSetBinding targeting the 'default' cluster set from the existence check enabled with --require-cluster-set-exists.")
+	fs.IntVar(&a.ClusterSetLookupRetries, "cluster-set-lookup-retries", defaultClusterSetLookupRetries,
+		"The number of times a missing target ManagedClusterSet is re-read before the binding is rejected, bridging the visibility gap of a freshly created set. Zero rejects on the first miss.")
+	fs.DurationVar(&a.ClusterSetLookupInterval, "cluster-set-lookup-interval", defaultClusterSetLookupInterval,
+		"The interval between the re-reads of a missing target ManagedClusterSet.")
 }
 
 // ValidatingResource is called by generic-admission-server on startup to register the returned REST resource through which the
@@ -37,6 +116,11 @@ func (a *ManagedClusterSetBindingValidatingAdmissionHook) ValidatingResource() (
 func (a *ManagedClusterSetBindingValidatingAdmissionHook) Validate(admissionSpec *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
 	klog.V(4).Infof("validate %q operation for object %q", admissionSpec.Operation, admissionSpec.Object)
 
+	return a.withDeprecationWarning(admissionSpec, a.validateRequest(admissionSpec))
+}
+
+// validateRequest makes the admission decision for a ManagedClusterSetBinding request.
+func (a *ManagedClusterSetBindingValidatingAdmissionHook) validateRequest(admissionSpec *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
 	// only validate the request for ManagedClusterSetBinding
 	if admissionSpec.Resource.Group != "cluster.open-cluster-management.io" ||
 		admissionSpec.Resource.Resource != "managedclustersetbindings" {
@@ -48,26 +132,55 @@ func (a *ManagedClusterSetBindingValidatingAdmissionHook) Validate(admissionSpec
 		return acceptRequest()
 	}
 
+	// the span stays a no-op unless a tracer provider was installed by the tracing setup
+	ctx, span := tracing.StartAdmissionSpan(context.Background(), "ManagedClusterSetBindingValidatingWebhook", admissionSpec)
+	status := a.validateBinding(ctx, admissionSpec)
+	tracing.EndAdmissionSpan(span, status)
+	return status
+}
+
+// validateBinding makes the admission decision for a ManagedClusterSetBinding create or
+// update request.
+func (a *ManagedClusterSetBindingValidatingAdmissionHook) validateBinding(ctx context.Context, admissionSpec *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
 	binding := &clusterv1beta1.ManagedClusterSetBinding{}
 	if err := json.Unmarshal(admissionSpec.Object.Raw, binding); err != nil {
-		return denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest,
+		return denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec",
 			fmt.Sprintf("Unable to unmarshal the ManagedClusterSetBinding object: %v", err))
 	}
 
-	// force the instance name to match the target cluster set name
-	if binding.Name != binding.Spec.ClusterSet {
-		return denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest,
+	// force the instance name to match the target cluster set name, unless the name is a
+	// configured alias of the target cluster set
+	if binding.Name != binding.Spec.ClusterSet && a.ClusterSetAliases[binding.Name] != binding.Spec.ClusterSet {
+		return denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.clusterSet",
 			"The ManagedClusterSetBinding must have the same name as the target ManagedClusterSet")
 	}
 
-	// check if the request user has permission to bind the target cluster set
 	if admissionSpec.Operation == admissionv1beta1.Create {
-		return a.allowBindingToClusterSet(binding.Spec.ClusterSet, admissionSpec.UserInfo)
+		// check if the target cluster set exists, if required
+		if status := a.requireClusterSetExists(ctx, binding.Spec.ClusterSet); !status.Allowed {
+			return status
+		}
+		// check if the request user has permission to bind the target cluster set
+		return a.allowBindingToClusterSet(ctx, binding.Namespace, binding.Spec.ClusterSet, admissionSpec.UserInfo)
 	}
 
 	return acceptRequest()
 }
 
+// withDeprecationWarning attaches the configured deprecation warning to a request that was
+// submitted via the v1beta1 API version. The admission decision itself is not changed.
+func (a *ManagedClusterSetBindingValidatingAdmissionHook) withDeprecationWarning(admissionSpec *admissionv1beta1.AdmissionRequest, status *admissionv1beta1.AdmissionResponse) *admissionv1beta1.AdmissionResponse {
+	if len(a.V1beta1DeprecationWarning) == 0 {
+		return status
+	}
+	if admissionSpec.Resource.Group != "cluster.open-cluster-management.io" ||
+		admissionSpec.Resource.Resource != "managedclustersetbindings" ||
+		admissionSpec.Resource.Version != "v1beta1" {
+		return status
+	}
+	return warnings.Append(status, warnings.SeverityWarning, "%s", a.V1beta1DeprecationWarning)
+}
+
 // Initialize is called by generic-admission-server on startup to setup initialization that ManagedClusterSetBinding webhook needs.
 func (a *ManagedClusterSetBindingValidatingAdmissionHook) Initialize(kubeClientConfig *rest.Config, stopCh <-chan struct{}) error {
 	var err error
@@ -75,12 +188,87 @@ func (a *ManagedClusterSetBindingValidatingAdmissionHook) Initialize(kubeClientC
 	if err != nil {
 		return err
 	}
+	a.clusterClient, err = clusterv1client.NewForConfig(kubeClientConfig)
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
 
+// ready reports whether the hook finished its initialization. The admission server only
+// serves after Initialize returned, but a request racing the startup must not reach the
+// rules that look up state outside the request object.
+func (a *ManagedClusterSetBindingValidatingAdmissionHook) ready() bool {
+	return a.kubeClient != nil && a.clusterClient != nil
+}
+
+// requireClusterSetExists rejects a binding whose target cluster set does not exist, if the
+// existence check is enabled. A missing cluster set is re-checked for a brief window before
+// the binding is rejected, it may have been created just before the binding and not be
+// visible yet.
+func (a *ManagedClusterSetBindingValidatingAdmissionHook) requireClusterSetExists(ctx context.Context, clusterSetName string) *admissionv1beta1.AdmissionResponse {
+	if !a.RequireClusterSetExists {
+		return acceptRequest()
+	}
+	if a.ExemptDefaultClusterSetFromExistence && clusterSetName == defaultClusterSetName {
+		return acceptRequest()
+	}
+
+	// the existence check looks up state outside the request object, return a retryable
+	// error instead of a wrong decision before the hook is ready
+	if !a.ready() {
+		return denyRequest(http.StatusServiceUnavailable, metav1.StatusReasonServiceUnavailable, "",
+			"the webhook has not finished its initialization, retry the request later")
+	}
+
+	// on a miss the set is re-read from the live apiserver for the configured number of
+	// retries, a set created just before the binding may not be visible yet
+	lookupCtx, lookupSpan := tracing.StartSpan(ctx, "ManagedClusterSetLookup")
+	var err error
+	for attempt := 0; ; attempt++ {
+		_, err = a.clusterClient.ClusterV1beta1().ManagedClusterSets().Get(lookupCtx, clusterSetName, metav1.GetOptions{})
+		if err == nil || !errors.IsNotFound(err) {
+			break
+		}
+		if attempt >= a.ClusterSetLookupRetries {
+			break
+		}
+		time.Sleep(a.ClusterSetLookupInterval)
+	}
+	tracing.EndSpan(lookupSpan, err)
+	switch {
+	case errors.IsNotFound(err):
+		return denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.clusterSet",
+			fmt.Sprintf("the target ManagedClusterSet %q does not exist", clusterSetName))
+	case err != nil:
+		return denyRequest(http.StatusServiceUnavailable, metav1.StatusReasonServiceUnavailable, "spec.clusterSet", err.Error())
+	}
+	return acceptRequest()
+}
+
+// bindAuthorizationNamespace returns the namespace the bind SubjectAccessReview for a
+// binding in the given namespace is made against. The binding's own namespace is used
+// unless a mapper or a configured mapping redirects it.
+func (a *ManagedClusterSetBindingValidatingAdmissionHook) bindAuthorizationNamespace(namespace string) string {
+	if a.BindNamespaceMapper != nil {
+		return a.BindNamespaceMapper(namespace)
+	}
+	if mapped, ok := a.BindNamespaceMappings[namespace]; ok {
+		return mapped
+	}
+	return namespace
+}
+
 // allowBindingToClusterSet checks if the user has permission to bind a particular cluster set
-func (a *ManagedClusterSetBindingValidatingAdmissionHook) allowBindingToClusterSet(clusterSetName string, userInfo authenticationv1.UserInfo) *admissionv1beta1.AdmissionResponse {
+func (a *ManagedClusterSetBindingValidatingAdmissionHook) allowBindingToClusterSet(ctx context.Context, namespace, clusterSetName string, userInfo authenticationv1.UserInfo) *admissionv1beta1.AdmissionResponse {
+	// the permission check looks up state outside the request object, return a retryable
+	// error instead of a wrong decision before the hook is ready
+	if !a.ready() {
+		return denyRequest(http.StatusServiceUnavailable, metav1.StatusReasonServiceUnavailable, "",
+			"the webhook has not finished its initialization, retry the request later")
+	}
+
 	extra := make(map[string]authorizationv1.ExtraValue)
 	for k, v := range userInfo.Extra {
 		extra[k] = authorizationv1.ExtraValue(v)
@@ -96,17 +284,23 @@ func (a *ManagedClusterSetBindingValidatingAdmissionHook) allowBindingToClusterS
 				Group:       "cluster.open-cluster-management.io",
 				Resource:    "managedclustersets",
 				Subresource: "bind",
+				Namespace:   a.bindAuthorizationNamespace(namespace),
 				Verb:        "create",
 				Name:        clusterSetName,
 			},
 		},
 	}
-	sar, err := a.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), sar, metav1.CreateOptions{})
+	sarCtx, sarSpan := tracing.StartSpan(ctx, "SubjectAccessReview")
+	sarStart := time.Now()
+	sar, err := a.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(sarCtx, sar, metav1.CreateOptions{})
+	tracing.EndSpan(sarSpan, err)
+	webhookmetrics.ObserveSubjectAccessReview("managedclustersets", sarStart, err, err == nil && sar.Status.Allowed)
 	if err != nil {
-		return denyRequest(http.StatusForbidden, metav1.StatusReasonForbidden, err.Error())
+		return denyRequest(http.StatusForbidden, metav1.StatusReasonForbidden, "spec.clusterSet", err.Error())
 	}
 	if !sar.Status.Allowed {
-		return denyRequest(http.StatusForbidden, metav1.StatusReasonForbidden, fmt.Sprintf("user %q is not allowed to bind cluster set %q", userInfo.Username, clusterSetName))
+		return denyRequest(http.StatusForbidden, metav1.StatusReasonForbidden, "spec.clusterSet",
+			fmt.Sprintf("user %q is not allowed to bind cluster set %q", userInfo.Username, clusterSetName))
 	}
 	return acceptRequest()
 }
@@ -117,7 +311,10 @@ func acceptRequest() *admissionv1beta1.AdmissionResponse {
 	}
 }
 
-func denyRequest(code int32, reason metav1.StatusReason, message string) *admissionv1beta1.AdmissionResponse {
+// denyRequest constructs a denial AdmissionResponse. Besides the human readable message, the
+// denial is encoded in the status details as a machine readable cause, so clients that parse
+// structured admission errors do not need to parse the message.
+func denyRequest(code int32, reason metav1.StatusReason, field, message string) *admissionv1beta1.AdmissionResponse {
 	return &admissionv1beta1.AdmissionResponse{
 		Allowed: false,
 		Result: &metav1.Status{
@@ -125,6 +322,15 @@ func denyRequest(code int32, reason metav1.StatusReason, message string) *admiss
 			Code:    code,
 			Reason:  reason,
 			Message: message,
+			Details: &metav1.StatusDetails{
+				Causes: []metav1.StatusCause{
+					{
+						Type:    metav1.CauseType(reason),
+						Field:   field,
+						Message: message,
+					},
+				},
+			},
 		},
 	}
 }
@@ -5,13 +5,17 @@ import (
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/spf13/pflag"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 )
 
@@ -27,6 +31,7 @@ func TestManagedClusterValidate(t *testing.T) {
 		request                  *admissionv1beta1.AdmissionRequest
 		expectedResponse         *admissionv1beta1.AdmissionResponse
 		allowBindingToClusterSet bool
+		clusterSetAliases        map[string]string
 	}{
 		{
 			name: "validate non-managedclustersetbindings request",
@@ -72,10 +77,36 @@ func TestManagedClusterValidate(t *testing.T) {
 			},
 			expectedResponse: &admissionv1beta1.AdmissionResponse{
 				Allowed: false,
-				Result: &metav1.Status{
-					Status: metav1.StatusFailure, Code: http.StatusBadRequest, Reason: metav1.StatusReasonBadRequest,
-					Message: "The ManagedClusterSetBinding must have the same name as the target ManagedClusterSet",
-				},
+				Result: denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.clusterSet",
+					"The ManagedClusterSetBinding must have the same name as the target ManagedClusterSet").Result,
+			},
+		},
+		{
+			name: "validate creating cluster set binding with aliased name",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetbindingSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetBindingObj("ns1", "csb1", "cs1", nil),
+			},
+			clusterSetAliases:        map[string]string{"csb1": "cs1"},
+			allowBindingToClusterSet: true,
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "validate creating cluster set binding with alias of another cluster set",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetbindingSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetBindingObj("ns1", "csb1", "cs2", nil),
+			},
+			clusterSetAliases:        map[string]string{"csb1": "cs1"},
+			allowBindingToClusterSet: true,
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.clusterSet",
+					"The ManagedClusterSetBinding must have the same name as the target ManagedClusterSet").Result,
 			},
 		},
 		{
@@ -87,10 +118,8 @@ func TestManagedClusterValidate(t *testing.T) {
 			},
 			expectedResponse: &admissionv1beta1.AdmissionResponse{
 				Allowed: false,
-				Result: &metav1.Status{
-					Status: metav1.StatusFailure, Code: http.StatusForbidden, Reason: metav1.StatusReasonForbidden,
-					Message: "user \"\" is not allowed to bind cluster set \"cs1\"",
-				},
+				Result: denyRequest(http.StatusForbidden, metav1.StatusReasonForbidden, "spec.clusterSet",
+					"user \"\" is not allowed to bind cluster set \"cs1\"").Result,
 			},
 		},
 		{
@@ -118,10 +147,8 @@ func TestManagedClusterValidate(t *testing.T) {
 			},
 			expectedResponse: &admissionv1beta1.AdmissionResponse{
 				Allowed: false,
-				Result: &metav1.Status{
-					Status: metav1.StatusFailure, Code: http.StatusBadRequest, Reason: metav1.StatusReasonBadRequest,
-					Message: "The ManagedClusterSetBinding must have the same name as the target ManagedClusterSet",
-				},
+				Result: denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.clusterSet",
+					"The ManagedClusterSetBinding must have the same name as the target ManagedClusterSet").Result,
 			},
 		},
 	}
@@ -142,7 +169,182 @@ func TestManagedClusterValidate(t *testing.T) {
 			)
 
 			admissionHook := &ManagedClusterSetBindingValidatingAdmissionHook{
-				kubeClient: kubeClient,
+				kubeClient:        kubeClient,
+				clusterClient:     clusterfake.NewSimpleClientset(),
+				ClusterSetAliases: c.clusterSetAliases,
+			}
+
+			actualResponse := admissionHook.Validate(c.request)
+			if !reflect.DeepEqual(actualResponse, c.expectedResponse) {
+				t.Errorf("expected %#v but got: %#v", c.expectedResponse.Result, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func TestV1beta1DeprecationWarning(t *testing.T) {
+	newBindingRequest := func(version string) *admissionv1beta1.AdmissionRequest {
+		return &admissionv1beta1.AdmissionRequest{
+			Resource: metav1.GroupVersionResource{
+				Group:    "cluster.open-cluster-management.io",
+				Version:  version,
+				Resource: "managedclustersetbindings",
+			},
+			Operation: admissionv1beta1.Update,
+			Object:    newManagedClusterSetBindingObj("ns1", "cs1", "cs1", nil),
+			OldObject: newManagedClusterSetBindingObj("ns1", "cs1", "cs1", nil),
+		}
+	}
+
+	cases := []struct {
+		name               string
+		deprecationWarning string
+		request            *admissionv1beta1.AdmissionRequest
+		expectedWarnings   []string
+	}{
+		{
+			name:               "a v1beta1 request gets the configured warning",
+			deprecationWarning: "cluster.open-cluster-management.io/v1beta1 ManagedClusterSetBinding is deprecated; migrate to v1beta2",
+			request:            newBindingRequest("v1beta1"),
+			expectedWarnings:   []string{"[registration-webhook] [warning] cluster.open-cluster-management.io/v1beta1 ManagedClusterSetBinding is deprecated; migrate to v1beta2"},
+		},
+		{
+			name:               "a v1beta2 request gets no warning",
+			deprecationWarning: "cluster.open-cluster-management.io/v1beta1 ManagedClusterSetBinding is deprecated; migrate to v1beta2",
+			request:            newBindingRequest("v1beta2"),
+		},
+		{
+			name:    "no warning is configured",
+			request: newBindingRequest("v1beta1"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			admissionHook := &ManagedClusterSetBindingValidatingAdmissionHook{
+				kubeClient:                kubefake.NewSimpleClientset(),
+				clusterClient:             clusterfake.NewSimpleClientset(),
+				V1beta1DeprecationWarning: c.deprecationWarning,
+			}
+
+			actualResponse := admissionHook.Validate(c.request)
+			if !actualResponse.Allowed {
+				t.Errorf("expected the request to be allowed but got: %#v", actualResponse.Result)
+			}
+			if !reflect.DeepEqual(actualResponse.Warnings, c.expectedWarnings) {
+				t.Errorf("expected warnings %v but got: %v", c.expectedWarnings, actualResponse.Warnings)
+			}
+		})
+	}
+}
+
+// TestV1beta1DeprecationWarningFlagDefault verifies the deprecation warning is emitted
+// out of the box and that an empty flag value disables it.
+func TestV1beta1DeprecationWarningFlagDefault(t *testing.T) {
+	admissionHook := &ManagedClusterSetBindingValidatingAdmissionHook{}
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	admissionHook.AddFlags(fs)
+	if err := fs.Parse(nil); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if admissionHook.V1beta1DeprecationWarning != defaultV1beta1DeprecationWarning {
+		t.Errorf("expected the default deprecation warning but got: %q", admissionHook.V1beta1DeprecationWarning)
+	}
+
+	disabledHook := &ManagedClusterSetBindingValidatingAdmissionHook{}
+	fs = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	disabledHook.AddFlags(fs)
+	if err := fs.Parse([]string{"--cluster-set-binding-v1beta1-deprecation-warning="}); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(disabledHook.V1beta1DeprecationWarning) != 0 {
+		t.Errorf("expected an empty flag value to disable the warning but got: %q", disabledHook.V1beta1DeprecationWarning)
+	}
+}
+
+func TestRequireClusterSetExists(t *testing.T) {
+	cases := []struct {
+		name                string
+		request             *admissionv1beta1.AdmissionRequest
+		existingClusterSets []runtime.Object
+		exemptDefault       bool
+		expectedResponse    *admissionv1beta1.AdmissionResponse
+	}{
+		{
+			name: "the target cluster set exists",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetbindingSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetBindingObj("ns1", "cs1", "cs1", nil),
+			},
+			existingClusterSets: []runtime.Object{newManagedClusterSet("cs1")},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "the target cluster set does not exist",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetbindingSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetBindingObj("ns1", "cs1", "cs1", nil),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.clusterSet",
+					"the target ManagedClusterSet \"cs1\" does not exist").Result,
+			},
+		},
+		{
+			name: "the missing default cluster set is exempt",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetbindingSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetBindingObj("ns1", "default", "default", nil),
+			},
+			exemptDefault: true,
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+			},
+		},
+		{
+			name: "the missing default cluster set is checked without the exemption",
+			request: &admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetbindingSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetBindingObj("ns1", "default", "default", nil),
+			},
+			expectedResponse: &admissionv1beta1.AdmissionResponse{
+				Allowed: false,
+				Result: denyRequest(http.StatusBadRequest, metav1.StatusReasonBadRequest, "spec.clusterSet",
+					"the target ManagedClusterSet \"default\" does not exist").Result,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: true,
+						},
+					}, nil
+				},
+			)
+
+			admissionHook := &ManagedClusterSetBindingValidatingAdmissionHook{
+				kubeClient:                           kubeClient,
+				clusterClient:                        clusterfake.NewSimpleClientset(c.existingClusterSets...),
+				RequireClusterSetExists:              true,
+				ExemptDefaultClusterSetFromExistence: c.exemptDefault,
+				// keep the missing-set cases from waiting out the default retries
+				ClusterSetLookupRetries:  2,
+				ClusterSetLookupInterval: time.Millisecond,
 			}
 
 			actualResponse := admissionHook.Validate(c.request)
@@ -153,6 +355,183 @@ func TestManagedClusterValidate(t *testing.T) {
 	}
 }
 
+// TestClusterSetLookupRetries verifies that the existence check re-reads a cluster set
+// that becomes visible only after the first lookups, so a binding created right after its
+// cluster set is not spuriously denied.
+func TestClusterSetLookupRetries(t *testing.T) {
+	cases := []struct {
+		name            string
+		visibleAfter    int
+		retries         int
+		expectedAllowed bool
+	}{
+		{
+			name:            "the set becomes visible within the retries",
+			visibleAfter:    2,
+			retries:         3,
+			expectedAllowed: true,
+		},
+		{
+			name:         "the set stays invisible beyond the retries",
+			visibleAfter: 5,
+			retries:      3,
+		},
+		{
+			name:         "no retries rejects on the first miss",
+			visibleAfter: 1,
+			retries:      0,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: true,
+						},
+					}, nil
+				},
+			)
+
+			// the cluster set exists on the apiserver, but becomes visible to the
+			// webhook only after the first lookups, like a freshly created set
+			clusterClient := clusterfake.NewSimpleClientset(newManagedClusterSet("cs1"))
+			lookups := 0
+			clusterClient.PrependReactor(
+				"get",
+				"managedclustersets",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					lookups++
+					if lookups <= c.visibleAfter {
+						return true, nil, errors.NewNotFound(
+							clusterv1beta1.Resource("managedclustersets"), "cs1")
+					}
+					return false, nil, nil
+				},
+			)
+
+			admissionHook := &ManagedClusterSetBindingValidatingAdmissionHook{
+				kubeClient:               kubeClient,
+				clusterClient:            clusterClient,
+				RequireClusterSetExists:  true,
+				ClusterSetLookupRetries:  c.retries,
+				ClusterSetLookupInterval: time.Millisecond,
+			}
+
+			actualResponse := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetbindingSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetBindingObj("ns1", "cs1", "cs1", nil),
+			})
+			if actualResponse.Allowed != c.expectedAllowed {
+				t.Errorf("expected allowed %v but got: %#v", c.expectedAllowed, actualResponse.Result)
+			}
+		})
+	}
+}
+
+func newManagedClusterSet(name string) *clusterv1beta1.ManagedClusterSet {
+	return &clusterv1beta1.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+}
+
+// TestBindSubjectAccessReviewNamespace verifies the namespace the bind
+// SubjectAccessReview is made against, with and without a configured mapping to a parent
+// or tenant namespace.
+func TestBindSubjectAccessReviewNamespace(t *testing.T) {
+	cases := []struct {
+		name              string
+		namespaceMapper   func(namespace string) string
+		namespaceMappings map[string]string
+		expectedNamespace string
+	}{
+		{
+			name:              "the binding's own namespace is used by default",
+			expectedNamespace: "ns1",
+		},
+		{
+			name:              "a configured mapping redirects the namespace",
+			namespaceMappings: map[string]string{"ns1": "tenant-a"},
+			expectedNamespace: "tenant-a",
+		},
+		{
+			name:              "a namespace without a mapping keeps its own namespace",
+			namespaceMappings: map[string]string{"ns2": "tenant-b"},
+			expectedNamespace: "ns1",
+		},
+		{
+			name:              "a mapper function takes precedence over the mappings",
+			namespaceMapper:   func(namespace string) string { return "parent-" + namespace },
+			namespaceMappings: map[string]string{"ns1": "tenant-a"},
+			expectedNamespace: "parent-ns1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var sarNamespace string
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"subjectaccessreviews",
+				func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
+					sar := action.(clienttesting.CreateActionImpl).Object.(*authorizationv1.SubjectAccessReview)
+					sarNamespace = sar.Spec.ResourceAttributes.Namespace
+					return true, &authorizationv1.SubjectAccessReview{
+						Status: authorizationv1.SubjectAccessReviewStatus{
+							Allowed: true,
+						},
+					}, nil
+				},
+			)
+
+			admissionHook := &ManagedClusterSetBindingValidatingAdmissionHook{
+				kubeClient:            kubeClient,
+				clusterClient:         clusterfake.NewSimpleClientset(),
+				BindNamespaceMapper:   c.namespaceMapper,
+				BindNamespaceMappings: c.namespaceMappings,
+			}
+
+			actualResponse := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+				Resource:  managedclustersetbindingSchema,
+				Operation: admissionv1beta1.Create,
+				Object:    newManagedClusterSetBindingObj("ns1", "cs1", "cs1", nil),
+			})
+			if !actualResponse.Allowed {
+				t.Fatalf("expected the request to be allowed but got: %#v", actualResponse.Result)
+			}
+			if sarNamespace != c.expectedNamespace {
+				t.Errorf("expected the SubjectAccessReview namespace %q but got: %q", c.expectedNamespace, sarNamespace)
+			}
+		})
+	}
+}
+
+func TestManagedClusterSetBindingValidateNotReady(t *testing.T) {
+	// a hook without a kube client has not finished its initialization yet
+	admissionHook := &ManagedClusterSetBindingValidatingAdmissionHook{}
+
+	actualResponse := admissionHook.Validate(&admissionv1beta1.AdmissionRequest{
+		Resource:  managedclustersetbindingSchema,
+		Operation: admissionv1beta1.Create,
+		Object:    newManagedClusterSetBindingObj("ns1", "cs1", "cs1", nil),
+	})
+
+	expectedResponse := denyRequest(http.StatusServiceUnavailable, metav1.StatusReasonServiceUnavailable, "",
+		"the webhook has not finished its initialization, retry the request later")
+	if !reflect.DeepEqual(actualResponse, expectedResponse) {
+		t.Errorf("expected %#v but got: %#v", expectedResponse.Result, actualResponse.Result)
+	}
+}
+
 func newManagedClusterSetBindingObj(namespace, name, clusterSetName string, labels map[string]string) runtime.RawExtension {
 	managedClusterSetBinding := &clusterv1beta1.ManagedClusterSetBinding{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	registrationmetrics "open-cluster-management.io/registration/pkg/metrics"
+)
+
+func TestObserveSubjectAccessReview(t *testing.T) {
+	// complete the deferred metrics registration with the default prefix
+	registrationmetrics.SetPrefix("")
+
+	// an allowed call records only a latency observation, a failed call additionally
+	// counts as transient and a denial as denied
+	ObserveSubjectAccessReview("managedclusters", time.Now(), nil, true)
+	ObserveSubjectAccessReview("managedclustersets", time.Now(), errors.New("connection refused"), false)
+	ObserveSubjectAccessReview("managedclustersets", time.Now(), nil, false)
+
+	expectedObservations := map[string]uint64{
+		"managedclusters":    1,
+		"managedclustersets": 2,
+	}
+	expectedErrors := map[string]float64{
+		"managedclustersets/transient": 1,
+		"managedclustersets/denied":    1,
+	}
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	actualObservations := map[string]uint64{}
+	actualErrors := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			switch family.GetName() {
+			case "registration_webhook_sar_duration_seconds":
+				actualObservations[labels["resource"]] = metric.GetHistogram().GetSampleCount()
+			case "registration_webhook_sar_errors_total":
+				actualErrors[labels["resource"]+"/"+labels["reason"]] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+
+	for resource, expected := range expectedObservations {
+		if actual := actualObservations[resource]; actual != expected {
+			t.Errorf("expected %d latency observations for resource %q but got: %d", expected, resource, actual)
+		}
+	}
+	for key, expected := range expectedErrors {
+		if actual := actualErrors[key]; actual != expected {
+			t.Errorf("expected error count %v for %q but got: %v", expected, key, actual)
+		}
+	}
+	if count, ok := actualErrors["managedclusters/transient"]; ok && count > 0 {
+		t.Errorf("expected no transient errors for the allowed call but got: %v", count)
+	}
+}
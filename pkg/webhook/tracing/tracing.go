@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpgrpc"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OTLPEndpoint is the endpoint of the OTLP collector the admission spans are exported
+// to, e.g. "otel-collector:4317". An empty endpoint disables the tracing, spans are then
+// created against the no-op global tracer provider and cost nearly nothing. It is
+// exposed as a command line flag.
+var OTLPEndpoint string
+
+// tracerName names the instrumentation scope of the spans created by this package.
+const tracerName = "open-cluster-management.io/registration/pkg/webhook"
+
+// Attribute keys of the admission spans. They are stable identifiers, trace queries may
+// key off them to correlate slow admission requests with their SubjectAccessReview
+// latency.
+const (
+	attributeResource  = attribute.Key("admission.resource")
+	attributeOperation = attribute.Key("admission.operation")
+	attributeAllowed   = attribute.Key("admission.allowed")
+	attributeReason    = attribute.Key("admission.reason")
+)
+
+// Setup installs a tracer provider exporting to the configured OTLP endpoint as the
+// global tracer provider. Without a configured endpoint it does nothing and the spans
+// created by the webhook handlers stay no-ops. The returned shutdown function flushes
+// the outstanding spans.
+func Setup(ctx context.Context) (shutdown func(), err error) {
+	if len(OTLPEndpoint) == 0 {
+		return func() {}, nil
+	}
+
+	exporter, err := otlp.NewExporter(ctx, otlpgrpc.NewDriver(
+		otlpgrpc.WithEndpoint(OTLPEndpoint),
+		otlpgrpc.WithInsecure(),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("unable to set up the OTLP exporter for endpoint %q: %w", OTLPEndpoint, err)
+	}
+
+	provider := tracesdk.NewTracerProvider(tracesdk.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	return func() {
+		// a fresh context, the caller typically shuts down because its context ended
+		if err := provider.Shutdown(context.Background()); err != nil {
+			otel.Handle(err)
+		}
+	}, nil
+}
+
+// StartAdmissionSpan starts a span covering the handling of the given admission request,
+// carrying the requested resource and operation as attributes. The returned context
+// propagates the span to the child spans of the lookups the handler performs.
+func StartAdmissionSpan(ctx context.Context, name string, request *admissionv1beta1.AdmissionRequest) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, name)
+	span.SetAttributes(
+		attributeResource.String(request.Resource.Resource),
+		attributeOperation.String(string(request.Operation)),
+	)
+	return ctx, span
+}
+
+// EndAdmissionSpan records the admission decision on the span and ends it. A denial
+// additionally carries the status reason of the denial.
+func EndAdmissionSpan(span trace.Span, response *admissionv1beta1.AdmissionResponse) {
+	span.SetAttributes(attributeAllowed.Bool(response.Allowed))
+	if !response.Allowed && response.Result != nil {
+		span.SetAttributes(attributeReason.String(string(response.Result.Reason)))
+	}
+	span.End()
+}
+
+// StartSpan starts a child span of the admission span in the given context, e.g. around
+// a SubjectAccessReview call.
+func StartSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name)
+}
+
+// EndSpan records the given error, if any, on the span and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
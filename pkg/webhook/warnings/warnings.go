@@ -0,0 +1,54 @@
+// Package warnings builds the admission warnings the registration webhooks attach to
+// responses for soft rule violations. Every warning carries the same prefix and an
+// optional severity tag, so a user reading the kubectl output can attribute a warning
+// to the registration webhooks and judge its urgency, regardless of the rule that
+// produced it.
+package warnings
+
+import (
+	"fmt"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+)
+
+// Suppressed disables all admission warnings. Admission decisions are not affected, the
+// soft rules only lose their messages. It is exposed as a command line flag.
+var Suppressed bool
+
+// prefix is attached to every admission warning, so a client can attribute the warning
+// to the registration webhooks.
+const prefix = "registration-webhook"
+
+// Severity tags a warning with its urgency. It is only a hint to the reader, a warning
+// never changes the admission decision.
+type Severity string
+
+const (
+	// SeverityNone omits the severity tag.
+	SeverityNone Severity = ""
+	// SeverityInfo tags a purely informational warning, e.g. a check that could not
+	// complete and failed open.
+	SeverityInfo Severity = "info"
+	// SeverityWarning tags a condition that likely needs attention, e.g. a
+	// configuration that is accepted but will not work.
+	SeverityWarning Severity = "warning"
+)
+
+// Format returns the given message with the shared prefix and the severity tag, if any.
+func Format(severity Severity, format string, args ...interface{}) string {
+	message := fmt.Sprintf(format, args...)
+	if len(severity) == 0 {
+		return fmt.Sprintf("[%s] %s", prefix, message)
+	}
+	return fmt.Sprintf("[%s] [%s] %s", prefix, severity, message)
+}
+
+// Append attaches a formatted warning to the admission response, unless warnings are
+// suppressed.
+func Append(status *admissionv1beta1.AdmissionResponse, severity Severity, format string, args ...interface{}) *admissionv1beta1.AdmissionResponse {
+	if Suppressed {
+		return status
+	}
+	status.Warnings = append(status.Warnings, Format(severity, format, args...))
+	return status
+}
@@ -0,0 +1,50 @@
+package warnings
+
+import (
+	"reflect"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+)
+
+func TestAppend(t *testing.T) {
+	cases := []struct {
+		name             string
+		suppressed       bool
+		expectedWarnings []string
+	}{
+		{
+			name: "multiple soft rule violations produce one formatted warning each",
+			expectedWarnings: []string{
+				"[registration-webhook] [warning] all certificates in the CA bundle of client config \"https://hub.example.com:6443\" are expired",
+				"[registration-webhook] [info] host \"hub.example.com\" of url \"https://hub.example.com:6443\" could not be resolved within 2s",
+				"[registration-webhook] this is a warning without a severity",
+			},
+		},
+		{
+			name:       "suppressed warnings leave the response untouched",
+			suppressed: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			Suppressed = c.suppressed
+			defer func() { Suppressed = false }()
+
+			status := &admissionv1beta1.AdmissionResponse{Allowed: true}
+			status = Append(status, SeverityWarning,
+				"all certificates in the CA bundle of client config %q are expired", "https://hub.example.com:6443")
+			status = Append(status, SeverityInfo,
+				"host %q of url %q could not be resolved within 2s", "hub.example.com", "https://hub.example.com:6443")
+			status = Append(status, SeverityNone, "this is a warning without a severity")
+
+			if !status.Allowed {
+				t.Errorf("expected the warnings to leave the admission decision untouched")
+			}
+			if !reflect.DeepEqual(status.Warnings, c.expectedWarnings) {
+				t.Errorf("expected warnings %v but got: %v", c.expectedWarnings, status.Warnings)
+			}
+		})
+	}
+}
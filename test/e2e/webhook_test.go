@@ -363,6 +363,35 @@ var _ = ginkgo.Describe("Admission webhook", func() {
 				)))
 			})
 
+			ginkgo.It("Should respond bad request when removing all client configs from an accepted managed cluster", func() {
+				ginkgo.By(fmt.Sprintf("accept managed cluster %q", clusterName))
+				err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+					managedCluster, err := clusterClient.ClusterV1().ManagedClusters().Get(context.TODO(), clusterName, metav1.GetOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+					managedCluster.Spec.HubAcceptsClient = true
+					_, err = clusterClient.ClusterV1().ManagedClusters().Update(context.TODO(), managedCluster, metav1.UpdateOptions{})
+					return err
+				})
+				gomega.Expect(err).ToNot(gomega.HaveOccurred())
+
+				ginkgo.By(fmt.Sprintf("remove all client configs of managed cluster %q", clusterName))
+				err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+					managedCluster, err := clusterClient.ClusterV1().ManagedClusters().Get(context.TODO(), clusterName, metav1.GetOptions{})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+					managedCluster.Spec.ManagedClusterClientConfigs = nil
+					_, err = clusterClient.ClusterV1().ManagedClusters().Update(context.TODO(), managedCluster, metav1.UpdateOptions{})
+					return err
+				})
+				gomega.Expect(err).To(gomega.HaveOccurred())
+				gomega.Expect(errors.IsBadRequest(err)).Should(gomega.BeTrue())
+				gomega.Expect(err.Error()).Should(gomega.Equal(fmt.Sprintf(
+					"admission webhook \"%s\" denied the request: at least one client config is required for an accepted managed cluster, the client configs cannot all be removed",
+					admissionName,
+				)))
+			})
+
 			ginkgo.It("Should forbid the request when updating an unaccepted managed cluster to accepted by unauthorized user", func() {
 				sa := fmt.Sprintf("webhook-sa-%s", rand.String(6))
 				ginkgo.By(fmt.Sprintf("accept managed cluster %q by an unauthorized user %q", clusterName, sa))